@@ -0,0 +1,48 @@
+package main
+
+import (
+	"flag"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/RedisAI/redisai-go/redisai/gateway"
+	"github.com/RedisAI/redisai-go/redisai/kserve"
+	"github.com/gomodule/redigo/redis"
+)
+
+var (
+	host     = flag.String("host", "127.0.0.1:6379", "Redis host.")
+	password = flag.String("password", "", "Redis password.")
+	listen   = flag.String("listen", ":8080", "Address the HTTP gateway listens on.")
+	maxIdle  = flag.Int("max-idle", 16, "Maximum number of idle connections to Redis.")
+	v2       = flag.Bool("kserve", false, "Also serve the Open Inference (KServe V2) protocol routes under /v2/.")
+	maxBody  = flag.Int64("max-body-bytes", gateway.DefaultMaxBodyBytes, "Maximum size of the request bodies, 0 for no limit.")
+)
+
+/*
+ * Serves the RedisAI HTTP/JSON gateway
+ */
+func main() {
+	flag.Parse()
+	pool := &redis.Pool{
+		MaxIdle:     *maxIdle,
+		IdleTimeout: 240 * time.Second,
+		Dial: func() (redis.Conn, error) {
+			return redis.Dial("tcp", *host, redis.DialPassword(*password))
+		},
+	}
+	defer pool.Close()
+
+	log.Printf("Serving the RedisAI gateway for %s on %s", *host, *listen)
+	mux := http.NewServeMux()
+	handler := gateway.NewHandler(pool)
+	handler.MaxBodyBytes = *maxBody
+	mux.Handle("/", handler)
+	if *v2 {
+		v2Handler := kserve.NewHandler(pool)
+		v2Handler.MaxBodyBytes = *maxBody
+		mux.Handle("/v2/", v2Handler)
+	}
+	log.Fatal(http.ListenAndServe(*listen, mux))
+}
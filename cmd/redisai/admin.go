@@ -0,0 +1,148 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"strconv"
+
+	"github.com/RedisAI/redisai-go/redisai"
+)
+
+func infoShow(client *redisai.Client, args []string) error {
+	positional, err := parseArgs(flag.NewFlagSet("info show", flag.ContinueOnError), args, "key")
+	if err != nil {
+		return err
+	}
+	info, err := client.Info(positional[0])
+	if err != nil {
+		return err
+	}
+	return printJSON(info)
+}
+
+func infoReset(client *redisai.Client, args []string) error {
+	positional, err := parseArgs(flag.NewFlagSet("info reset", flag.ContinueOnError), args, "key")
+	if err != nil {
+		return err
+	}
+	reply, err := client.ResetStat(positional[0])
+	if err != nil {
+		return err
+	}
+	fmt.Println(reply)
+	return nil
+}
+
+func configBackendsPath(client *redisai.Client, args []string) error {
+	positional, err := parseArgs(flag.NewFlagSet("config backendspath", flag.ContinueOnError), args, "path")
+	if err != nil {
+		return err
+	}
+	reply, err := client.SetBackendsPath(positional[0])
+	if err != nil {
+		return err
+	}
+	fmt.Println(reply)
+	return nil
+}
+
+func configLoadBackend(client *redisai.Client, args []string) error {
+	positional, err := parseArgs(flag.NewFlagSet("config loadbackend", flag.ContinueOnError), args, "backend", "path")
+	if err != nil {
+		return err
+	}
+	return client.LoadBackend(positional[0], positional[1])
+}
+
+func configGet(client *redisai.Client, args []string) error {
+	if _, err := parseArgs(flag.NewFlagSet("config get", flag.ContinueOnError), args); err != nil {
+		return err
+	}
+	backendsPath, err := client.ConfigGetBackendsPath()
+	if err != nil {
+		return err
+	}
+	chunkSize, err := client.ConfigGetModelChunkSize()
+	if err != nil {
+		return err
+	}
+	backends, err := client.LoadedBackends()
+	if err != nil {
+		return err
+	}
+	capabilities, err := client.Capabilities()
+	if err != nil {
+		return err
+	}
+	return printJSON(map[string]interface{}{
+		"version":          capabilities.Version,
+		"backendspath":     backendsPath,
+		"model_chunk_size": chunkSize,
+		"backends":         backends,
+	})
+}
+
+func configChunkSize(client *redisai.Client, args []string) error {
+	positional, err := parseArgs(flag.NewFlagSet("config chunksize", flag.ContinueOnError), args, "bytes")
+	if err != nil {
+		return err
+	}
+	size, err := strconv.ParseInt(positional[0], 10, 64)
+	if err != nil {
+		return fmt.Errorf("config chunksize: invalid size %s", positional[0])
+	}
+	return client.ConfigSetModelChunkSize(size)
+}
+
+func configEnsureBackend(client *redisai.Client, args []string) error {
+	fs := flag.NewFlagSet("config ensurebackend", flag.ContinueOnError)
+	path := fs.String("path", "", "Path of the backend library. The default library of the backends path is loaded when empty.")
+	positional, err := parseArgs(fs, args, "backend")
+	if err != nil {
+		return err
+	}
+	loaded, err := client.EnsureBackendAt(positional[0], *path)
+	if err != nil {
+		return err
+	}
+	if loaded {
+		fmt.Printf("%s loaded\n", positional[0])
+	} else {
+		fmt.Printf("%s already loaded\n", positional[0])
+	}
+	return nil
+}
+
+func modelList(client *redisai.Client, args []string) error {
+	return keyList(client.ScanModels(), "model list", args)
+}
+
+func scriptList(client *redisai.Client, args []string) error {
+	return keyList(client.ScanScripts(), "script list", args)
+}
+
+func tensorList(client *redisai.Client, args []string) error {
+	return keyList(client.ScanTensors(), "tensor list", args)
+}
+
+func keyList(scanner *redisai.KeyScanner, name string, args []string) error {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	match := fs.String("match", "", "Glob-style pattern filtering the key names.")
+	meta := fs.Bool("meta", false, "Print the metadata of every key as JSON.")
+	if _, err := parseArgs(fs, args); err != nil {
+		return err
+	}
+	scanner.Match = *match
+	scanner.WithMeta = *meta
+	if *meta {
+		keys, err := scanner.All()
+		if err != nil {
+			return err
+		}
+		return printJSON(keys)
+	}
+	for scanner.Next() {
+		fmt.Println(scanner.KeyInfo().Key)
+	}
+	return scanner.Err()
+}
@@ -0,0 +1,79 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/RedisAI/redisai-go/redisai"
+)
+
+func backupFlags(name string) (*flag.FlagSet, *redisai.BackupOptions) {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	options := &redisai.BackupOptions{}
+	fs.StringVar(&options.Match, "match", "", "Glob-style pattern of the keys to include.")
+	fs.StringVar(&options.Tag, "tag", "", "Only include the models and scripts with this tag.")
+	return fs, options
+}
+
+func backupCreate(client *redisai.Client, args []string) error {
+	fs, options := backupFlags("backup create")
+	positional, err := parseArgs(fs, args, "archive.tar")
+	if err != nil {
+		return err
+	}
+	file, err := os.Create(positional[0])
+	if err != nil {
+		return err
+	}
+	manifest, err := redisai.Backup(client, file, *options)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(positional[0])
+		return err
+	}
+	fmt.Printf("%d models and %d scripts written to %s\n", len(manifest.Models), len(manifest.Scripts), positional[0])
+	return nil
+}
+
+func backupRestore(client *redisai.Client, args []string) error {
+	fs, options := backupFlags("backup restore")
+	fs.BoolVar(&options.Verify, "verify", false, "Compare the restored content with the archive hashes.")
+	positional, err := parseArgs(fs, args, "archive.tar")
+	if err != nil {
+		return err
+	}
+	file, err := os.Open(positional[0])
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	manifest, err := redisai.Restore(client, file, *options)
+	if manifest != nil {
+		fmt.Printf("%d models and %d scripts restored\n", len(manifest.Models), len(manifest.Scripts))
+	}
+	return err
+}
+
+func backupVerify(client *redisai.Client, args []string) error {
+	positional, err := parseArgs(flag.NewFlagSet("backup verify", flag.ContinueOnError), args, "archive.tar")
+	if err != nil {
+		return err
+	}
+	file, err := os.Open(positional[0])
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	manifest, err := redisai.ReadBackupManifest(file)
+	if err != nil {
+		return err
+	}
+	if err = redisai.VerifyBackup(client, manifest); err != nil {
+		return err
+	}
+	fmt.Printf("%d models and %d scripts match the archive\n", len(manifest.Models), len(manifest.Scripts))
+	return nil
+}
@@ -0,0 +1,98 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/RedisAI/redisai-go/redisai"
+	"github.com/RedisAI/redisai-go/redisai/benchmark"
+)
+
+func benchmarkRun(client *redisai.Client, args []string) error {
+	fs := flag.NewFlagSet("benchmark run", flag.ContinueOnError)
+	var inputs inputList
+	var inputFiles fileInputList
+	var outputs stringList
+	fs.Var(&inputs, "input", "Input tensor as name=DTYPE:dim,dim:value,value. Can be repeated, in the model inputs order.")
+	fs.Var(&inputFiles, "input-file", "Input tensor as name=file.npy or name=file.json. Can be repeated, after the inline inputs.")
+	fs.Var(&outputs, "outputs", "Comma separated names of the model outputs.")
+	mode := fs.String("mode", benchmark.ModeDag, "Execution mode: dag, commands or pipeline.")
+	depth := fs.Int("pipeline-depth", 10, "Number of predictions sent in a single pipeline, in pipeline mode.")
+	timeout := fs.Int64("timeout", 0, "Execution timeout in milliseconds.")
+	concurrency := fs.Int("concurrency", 1, "Number of concurrent workers.")
+	rate := fs.Float64("rate", 0, "Target operations per second. Zero runs every worker back to back (closed loop).")
+	duration := fs.Duration("duration", 0, "Duration of the measured run.")
+	requests := fs.Int64("requests", 0, "Number of measured operations.")
+	warmup := fs.Duration("warmup", 0, "Duration of the unmeasured warm-up.")
+	out := fs.String("json", "", "File to export the result to as JSON.")
+	positional, err := parseArgs(fs, args, "key")
+	if err != nil {
+		return err
+	}
+	if *duration == 0 && *requests == 0 {
+		return fmt.Errorf("benchmark run: either -duration or -requests must be given")
+	}
+	for pos, name := range inputFiles.names {
+		if err = inputs.add(name, inputFiles.tensors[pos]); err != nil {
+			return err
+		}
+	}
+	workload := benchmark.ModelWorkload{
+		ModelKey:      positional[0],
+		InputNames:    inputs.names,
+		Inputs:        inputs.tensors,
+		Outputs:       outputs,
+		Timeout:       *timeout,
+		Mode:          *mode,
+		PipelineDepth: *depth,
+	}
+	op, err := workload.Operation()
+	if err != nil {
+		return err
+	}
+	config := benchmark.Config{
+		Concurrency: *concurrency,
+		Rate:        *rate,
+		Duration:    *duration,
+		Requests:    *requests,
+		Warmup:      *warmup,
+	}
+	result, err := benchmark.Run(positional[0]+" "+workload.Mode, client.Pool, config, op)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintln(os.Stderr, result)
+	if len(*out) == 0 {
+		return nil
+	}
+	data, err := json.MarshalIndent(result, "", "  ")
+	if err != nil {
+		return err
+	}
+	return writeOutput(*out, append(data, '\n'))
+}
+
+// fileInputList is a flag.Value accumulating input tensors read from files
+type fileInputList struct {
+	names   []string
+	tensors []redisai.TensorInterface
+}
+
+func (l *fileInputList) String() string { return strings.Join(l.names, ",") }
+
+func (l *fileInputList) Set(value string) error {
+	nameAndPath := strings.SplitN(value, "=", 2)
+	if len(nameAndPath) != 2 {
+		return fmt.Errorf("invalid input %s, expected name=file", value)
+	}
+	tensor, err := readTensorFile(nameAndPath[1])
+	if err != nil {
+		return err
+	}
+	l.names = append(l.names, nameAndPath[0])
+	l.tensors = append(l.tensors, tensor)
+	return nil
+}
@@ -0,0 +1,206 @@
+// Command redisai manages the models, scripts and tensors of a RedisAI server.
+//
+// Usage:
+//
+//	redisai [connection flags] <command> <subcommand> [flags] [arguments]
+//
+// Run redisai -h for the list of commands.
+package main
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/RedisAI/redisai-go/redisai"
+	"github.com/gomodule/redigo/redis"
+)
+
+var (
+	host          = flag.String("host", "127.0.0.1:6379", "Redis host.")
+	password      = flag.String("password", "", "Redis password.")
+	useTLS        = flag.Bool("tls", false, "Establish a secure TLS connection.")
+	tlsCertFile   = flag.String("tls-cert-file", "", "A X.509 certificate to use for authenticating the client to the server. The file should be PEM formatted.")
+	tlsKeyFile    = flag.String("tls-key-file", "", "A X.509 private key to use for authenticating the client to the server. The file should be PEM formatted.")
+	tlsCaCertFile = flag.String("tls-ca-cert-file", "", "A PEM encoded CA's certificate file.")
+	tlsSkipVerify = flag.Bool("tls-skip-verify", false, "Skip the verification of the server's certificate chain and host name.")
+)
+
+// command is a leaf of the command tree, receiving the arguments that follow its name
+type command struct {
+	usage string
+	run   func(client *redisai.Client, args []string) error
+}
+
+var commands = map[string]map[string]command{
+	"model": {
+		"store": {"[flags] <key> <file>", modelStore},
+		"get":   {"[flags] <key>", modelGet},
+		"del":   {"<key>", modelDel},
+		"run":   {"[flags] <key>", modelRun},
+		"list":  {"[flags]", modelList},
+	},
+	"script": {
+		"store": {"[flags] <key> <file>", scriptStore},
+		"get":   {"[flags] <key>", scriptGet},
+		"del":   {"<key>", scriptDel},
+		"list":  {"[flags]", scriptList},
+	},
+	"tensor": {
+		"set":  {"<key> <file.npy|file.json>", tensorSet},
+		"get":  {"[flags] <key>", tensorGet},
+		"list": {"[flags]", tensorList},
+	},
+	"info": {
+		"show":  {"<key>", infoShow},
+		"reset": {"<key>", infoReset},
+	},
+	"manifest": {
+		"sync": {"[flags] <manifest.json>", manifestSync},
+	},
+	"backup": {
+		"create":  {"[flags] <archive.tar>", backupCreate},
+		"restore": {"[flags] <archive.tar>", backupRestore},
+		"verify":  {"<archive.tar>", backupVerify},
+	},
+	"migrate": {
+		"diff": {"[flags] <destination host>", migrateDiff},
+		"copy": {"[flags] <destination host>", migrateCopy},
+	},
+	"benchmark": {
+		"run": {"[flags] <key>", benchmarkRun},
+	},
+	"config": {
+		"backendspath":  {"<path>", configBackendsPath},
+		"loadbackend":   {"<TF|TORCH|ONNX|TFLITE> <path>", configLoadBackend},
+		"get":           {"", configGet},
+		"chunksize":     {"<bytes>", configChunkSize},
+		"ensurebackend": {"[flags] <TF|TORCH|ONNX|TFLITE>", configEnsureBackend},
+	},
+}
+
+func usage() {
+	fmt.Fprintf(flag.CommandLine.Output(), "Usage: %s [flags] <command> <subcommand> [arguments]\n\nCommands:\n", os.Args[0])
+	for _, name := range []string{"model", "script", "tensor", "info", "config", "manifest", "backup", "migrate", "benchmark"} {
+		subNames := make([]string, 0, len(commands[name]))
+		for subName := range commands[name] {
+			subNames = append(subNames, subName)
+		}
+		sort.Strings(subNames)
+		for _, subName := range subNames {
+			fmt.Fprintf(flag.CommandLine.Output(), "  %s %s %s\n", name, subName, commands[name][subName].usage)
+		}
+	}
+	fmt.Fprintf(flag.CommandLine.Output(), "\nFlags:\n")
+	flag.PrintDefaults()
+}
+
+func main() {
+	flag.Usage = usage
+	flag.Parse()
+	if flag.NArg() < 2 {
+		usage()
+		os.Exit(2)
+	}
+	cmd, ok := commands[flag.Arg(0)][flag.Arg(1)]
+	if !ok {
+		fmt.Fprintf(os.Stderr, "unknown command %s\n", strings.Join(flag.Args()[:2], " "))
+		usage()
+		os.Exit(2)
+	}
+	pool, err := newPool()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	defer pool.Close()
+	client := redisai.Connect("", pool)
+	err = cmd.run(client, flag.Args()[2:])
+	if closeErr := client.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+func newPool() (*redis.Pool, error) {
+	return newPoolTo(*host, *password)
+}
+
+// newPoolTo returns a pool of connections to address, sharing the TLS settings of the connection flags
+func newPoolTo(address, password string) (*redis.Pool, error) {
+	options := []redis.DialOption{redis.DialPassword(password)}
+	if *useTLS || len(*tlsCertFile) > 0 || len(*tlsCaCertFile) > 0 {
+		tlsConfig := &tls.Config{InsecureSkipVerify: *tlsSkipVerify}
+		if len(*tlsCertFile) > 0 {
+			cert, err := tls.LoadX509KeyPair(*tlsCertFile, *tlsKeyFile)
+			if err != nil {
+				return nil, err
+			}
+			tlsConfig.Certificates = []tls.Certificate{cert}
+		}
+		if len(*tlsCaCertFile) > 0 {
+			caCert, err := ioutil.ReadFile(*tlsCaCertFile)
+			if err != nil {
+				return nil, err
+			}
+			tlsConfig.RootCAs = x509.NewCertPool()
+			tlsConfig.RootCAs.AppendCertsFromPEM(caCert)
+		}
+		options = append(options, redis.DialUseTLS(true), redis.DialTLSConfig(tlsConfig), redis.DialTLSSkipVerify(*tlsSkipVerify))
+	}
+	return &redis.Pool{Dial: func() (redis.Conn, error) {
+		return redis.Dial("tcp", address, options...)
+	}}, nil
+}
+
+// stringList is a flag.Value holding a comma separated list of strings
+type stringList []string
+
+func (l *stringList) String() string { return strings.Join(*l, ",") }
+
+func (l *stringList) Set(value string) error {
+	*l = nil
+	for _, v := range strings.Split(value, ",") {
+		if v = strings.TrimSpace(v); len(v) > 0 {
+			*l = append(*l, v)
+		}
+	}
+	return nil
+}
+
+// parseArgs parses the flags of a subcommand, and checks the number of positional arguments
+func parseArgs(fs *flag.FlagSet, args []string, positional ...string) ([]string, error) {
+	fs.Usage = func() {
+		if len(positional) == 0 {
+			fmt.Fprintf(fs.Output(), "Usage: %s [flags]\n", fs.Name())
+		} else {
+			fmt.Fprintf(fs.Output(), "Usage: %s [flags] <%s>\n", fs.Name(), strings.Join(positional, "> <"))
+		}
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return nil, err
+	}
+	if fs.NArg() != len(positional) {
+		fs.Usage()
+		return nil, fmt.Errorf("%s: expected %d arguments, got %d", fs.Name(), len(positional), fs.NArg())
+	}
+	return fs.Args(), nil
+}
+
+// writeOutput writes data to path, or to the standard output when path is empty
+func writeOutput(path string, data []byte) error {
+	if len(path) == 0 {
+		_, err := os.Stdout.Write(data)
+		return err
+	}
+	return ioutil.WriteFile(path, data, 0644)
+}
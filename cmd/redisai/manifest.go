@@ -0,0 +1,35 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+
+	"github.com/RedisAI/redisai-go/redisai"
+)
+
+func manifestSync(client *redisai.Client, args []string) error {
+	fs := flag.NewFlagSet("manifest sync", flag.ContinueOnError)
+	dryRun := fs.Bool("dry-run", false, "Print the plan without changing the server.")
+	prune := fs.Bool("prune", false, "Delete the keys previously synced that the manifest no longer lists.")
+	stateKey := fs.String("state-key", redisai.DefaultManifestStateKey, "Key of the hash recording the synced keys.")
+	asJSON := fs.Bool("json", false, "Print the plan as JSON.")
+	positional, err := parseArgs(fs, args, "manifest.json")
+	if err != nil {
+		return err
+	}
+	manifest, err := redisai.LoadManifest(positional[0])
+	if err != nil {
+		return err
+	}
+	plan, err := redisai.Sync(client, manifest, redisai.SyncOptions{DryRun: *dryRun, Prune: *prune, StateKey: *stateKey})
+	if plan != nil {
+		if *asJSON {
+			if errPrint := printJSON(plan); err == nil {
+				err = errPrint
+			}
+		} else {
+			fmt.Print(plan)
+		}
+	}
+	return err
+}
@@ -0,0 +1,66 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+
+	"github.com/RedisAI/redisai-go/redisai"
+)
+
+func migrateDiff(client *redisai.Client, args []string) error {
+	return migrate(client, "migrate diff", args, false)
+}
+
+func migrateCopy(client *redisai.Client, args []string) error {
+	return migrate(client, "migrate copy", args, true)
+}
+
+// migrate compares the server of the connection flags with the destination host, and copies the drifted keys
+func migrate(client *redisai.Client, name string, args []string, copyDrifted bool) error {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	destinationPassword := fs.String("destination-password", "", "Password of the destination server. The -password flag is used when empty.")
+	match := fs.String("match", "", "Glob-style pattern of the keys to compare.")
+	tensors := fs.Bool("tensors", false, "Compare the tensors persisted in the keyspace too.")
+	asJSON := fs.Bool("json", false, "Print the report as JSON.")
+	var only stringList
+	if copyDrifted {
+		fs.Var(&only, "only", "Comma separated drifts to copy, among missing, tag, metadata and content. Every drift is copied when empty.")
+	}
+	positional, err := parseArgs(fs, args, "destination host")
+	if err != nil {
+		return err
+	}
+	if len(*destinationPassword) == 0 {
+		*destinationPassword = *password
+	}
+	pool, err := newPoolTo(positional[0], *destinationPassword)
+	if err != nil {
+		return err
+	}
+	defer pool.Close()
+	destination := redisai.Connect("", pool)
+	defer destination.Close()
+
+	options := redisai.MigrateOptions{Match: *match, Copy: copyDrifted, Tensors: *tensors}
+	if len(only) > 0 {
+		options.Select = func(drift redisai.Drift) bool {
+			for _, selected := range only {
+				if drift.Drift == selected {
+					return true
+				}
+			}
+			return false
+		}
+	}
+	report, err := redisai.Migrate(client, destination, options)
+	if report != nil {
+		if *asJSON {
+			if errPrint := printJSON(report); err == nil {
+				err = errPrint
+			}
+		} else {
+			fmt.Print(report)
+		}
+	}
+	return err
+}
@@ -0,0 +1,178 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/RedisAI/redisai-go/redisai"
+	"github.com/RedisAI/redisai-go/redisai/gateway"
+	"github.com/RedisAI/redisai-go/redisai/implementations"
+)
+
+func modelStore(client *redisai.Client, args []string) error {
+	fs := flag.NewFlagSet("model store", flag.ContinueOnError)
+	backend := fs.String("backend", redisai.BackendTF, "Model backend: TF, TORCH, ORT or TFLITE.")
+	device := fs.String("device", redisai.DeviceCPU, "Device to run the model on: CPU or GPU.")
+	tag := fs.String("tag", "", "Model tag.")
+	batchSize := fs.Int64("batchsize", 0, "Maximum size of any batch of incoming requests.")
+	minBatchSize := fs.Int64("minbatchsize", 0, "Minimum size of any batch of incoming requests.")
+	minBatchTimeout := fs.Int64("minbatchtimeout", 0, "Time in milliseconds to wait for a batch to reach minbatchsize.")
+	var inputs, outputs stringList
+	fs.Var(&inputs, "inputs", "Comma separated names of the model inputs (TF only).")
+	fs.Var(&outputs, "outputs", "Comma separated names of the model outputs (TF only).")
+	warmupRuns := fs.Int("warmup", 0, "Number of warm-up executions run once the model is stored.")
+	var warmupInputs inputList
+	fs.Var(&warmupInputs, "warmup-input", "Warm-up input tensor as name=DTYPE:dim,dim:value,value. Can be repeated, in the model inputs order.")
+	var warmupOutputs stringList
+	fs.Var(&warmupOutputs, "warmup-outputs", "Comma separated names of the warm-up outputs. Defaults to -outputs.")
+	positional, err := parseArgs(fs, args, "key", "file")
+	if err != nil {
+		return err
+	}
+	model := implementations.NewModel(*backend, *device)
+	if err = model.SetBlobFromFile(positional[1]); err != nil {
+		return err
+	}
+	model.SetTag(*tag)
+	model.SetBatchSize(*batchSize)
+	model.SetMinBatchSize(*minBatchSize)
+	model.SetMinBatchTimeout(*minBatchTimeout)
+	model.SetInputs(inputs)
+	model.SetOutputs(outputs)
+	if *warmupRuns <= 0 {
+		return client.ModelStoreFromModel(positional[0], model)
+	}
+	warmup := redisai.ModelWarmup{Runs: *warmupRuns, Tensors: warmupInputs.tensors, Outputs: warmupOutputs}
+	for _, name := range warmupInputs.names {
+		warmup.Inputs = append(warmup.Inputs, redisai.TensorSignature{Name: name})
+	}
+	report, err := client.ModelStoreFromModelWithWarmup(positional[0], model, warmup)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("warm-up: %d runs, first %v, last %v\n", len(report.Latencies), report.First(), report.Last())
+	return nil
+}
+
+func modelGet(client *redisai.Client, args []string) error {
+	fs := flag.NewFlagSet("model get", flag.ContinueOnError)
+	out := fs.String("out", "", "File to write the model blob to. The metadata is printed when not given.")
+	positional, err := parseArgs(fs, args, "key")
+	if err != nil {
+		return err
+	}
+	if len(*out) > 0 {
+		return modelGetBlob(client, positional[0], *out)
+	}
+	meta, err := client.ModelGetMeta(positional[0])
+	if err != nil {
+		return err
+	}
+	return printJSON(meta)
+}
+
+// modelGetBlob writes the model blob to the output file, removing the file on failure
+func modelGetBlob(client *redisai.Client, key, path string) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if _, err = client.ModelGetBlob(key, file); err != nil {
+		file.Close()
+		os.Remove(path)
+		return err
+	}
+	return file.Close()
+}
+
+func modelDel(client *redisai.Client, args []string) error {
+	positional, err := parseArgs(flag.NewFlagSet("model del", flag.ContinueOnError), args, "key")
+	if err != nil {
+		return err
+	}
+	return client.ModelDel(positional[0])
+}
+
+func modelRun(client *redisai.Client, args []string) error {
+	fs := flag.NewFlagSet("model run", flag.ContinueOnError)
+	var inputs inputList
+	var outputs stringList
+	fs.Var(&inputs, "input", "Input tensor as name=DTYPE:dim,dim:value,value. Can be repeated, in the model inputs order.")
+	fs.Var(&outputs, "outputs", "Comma separated names of the model outputs to print.")
+	timeout := fs.Int64("timeout", 0, "Execution timeout in milliseconds.")
+	positional, err := parseArgs(fs, args, "key")
+	if err != nil {
+		return err
+	}
+	predictor := redisai.NewModelPredictor(client, positional[0], inputs.names, outputs)
+	predictor.Timeout = *timeout
+	results, err := predictor.Predict(inputs.tensors)
+	if err != nil {
+		return err
+	}
+	response := gateway.ExecuteResponse{Outputs: make([]gateway.Tensor, len(outputs))}
+	for pos, name := range outputs {
+		if response.Outputs[pos], err = gateway.FromTensor(name, results[name], false); err != nil {
+			return err
+		}
+	}
+	return printJSON(response)
+}
+
+// inputList is a flag.Value accumulating inline input tensors
+type inputList struct {
+	names   []string
+	tensors map[string]redisai.TensorInterface
+}
+
+func (l *inputList) String() string { return strings.Join(l.names, ",") }
+
+func (l *inputList) Set(value string) error {
+	name, tensor, err := parseInlineTensor(value)
+	if err != nil {
+		return err
+	}
+	return l.add(name, tensor)
+}
+
+func (l *inputList) add(name string, tensor redisai.TensorInterface) error {
+	if l.tensors == nil {
+		l.tensors = make(map[string]redisai.TensorInterface)
+	}
+	if _, ok := l.tensors[name]; ok {
+		return fmt.Errorf("duplicate input %s", name)
+	}
+	l.names = append(l.names, name)
+	l.tensors[name] = tensor
+	return nil
+}
+
+// parseInlineTensor parses a tensor given as name=DTYPE:dim,dim:value,value
+func parseInlineTensor(value string) (name string, tensor redisai.TensorInterface, err error) {
+	nameAndTensor := strings.SplitN(value, "=", 2)
+	if len(nameAndTensor) != 2 {
+		return "", nil, fmt.Errorf("invalid input %s, expected name=DTYPE:dims:values", value)
+	}
+	parts := strings.Split(nameAndTensor[1], ":")
+	if len(parts) != 3 {
+		return "", nil, fmt.Errorf("invalid input %s, expected name=DTYPE:dims:values", value)
+	}
+	dims, err := redisai.TensorDataFromStrings(redisai.TypeInt64, strings.Split(parts[1], ","))
+	if err != nil {
+		return "", nil, fmt.Errorf("invalid input %s dimensions: %v", value, err)
+	}
+	data, err := redisai.TensorDataFromStrings(strings.ToUpper(parts[0]), strings.Split(parts[2], ","))
+	if err != nil {
+		return "", nil, fmt.Errorf("invalid input %s values: %v", value, err)
+	}
+	return nameAndTensor[0], implementations.NewAiTensorWithData(parts[0], dims.([]int64), data), nil
+}
+
+func printJSON(value interface{}) error {
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(value)
+}
@@ -0,0 +1,110 @@
+package main
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/RedisAI/redisai-go/redisai"
+)
+
+var npyMagic = []byte("\x93NUMPY")
+
+var npyDescrToType = map[string]string{
+	"<f4": redisai.TypeFloat,
+	"<f8": redisai.TypeDouble,
+	"|i1": redisai.TypeInt8,
+	"<i2": redisai.TypeInt16,
+	"<i4": redisai.TypeInt32,
+	"<i8": redisai.TypeInt64,
+	"|u1": redisai.TypeUint8,
+	"<u2": redisai.TypeUint16,
+}
+
+var npyHeaderRegexp = regexp.MustCompile(`'descr':\s*'([^']+)'.*'fortran_order':\s*(True|False).*'shape':\s*\(([^)]*)\)`)
+
+// decodeNpy decodes a .npy file holding a little-endian, C ordered array
+func decodeNpy(content []byte) (dtype string, shape []int64, blob []byte, err error) {
+	if len(content) < 10 || !bytes.Equal(content[:6], npyMagic) {
+		return "", nil, nil, errors.New("not a .npy file")
+	}
+	var header string
+	switch content[6] {
+	case 1:
+		headerLen := int(binary.LittleEndian.Uint16(content[8:10]))
+		if len(content) < 10+headerLen {
+			return "", nil, nil, errors.New("truncated .npy header")
+		}
+		header, blob = string(content[10:10+headerLen]), content[10+headerLen:]
+	case 2, 3:
+		if len(content) < 12 {
+			return "", nil, nil, errors.New("truncated .npy header")
+		}
+		headerLen := int(binary.LittleEndian.Uint32(content[8:12]))
+		if len(content) < 12+headerLen {
+			return "", nil, nil, errors.New("truncated .npy header")
+		}
+		header, blob = string(content[12:12+headerLen]), content[12+headerLen:]
+	default:
+		return "", nil, nil, fmt.Errorf("unsupported .npy version %d", content[6])
+	}
+	// the header keys are written in alphabetical order by numpy
+	match := npyHeaderRegexp.FindStringSubmatch(header)
+	if match == nil {
+		return "", nil, nil, fmt.Errorf("invalid .npy header %s", header)
+	}
+	dtype, ok := npyDescrToType[match[1]]
+	if !ok {
+		return "", nil, nil, fmt.Errorf("unsupported .npy dtype %s", match[1])
+	}
+	if match[2] == "True" {
+		return "", nil, nil, errors.New("fortran ordered .npy arrays are not supported")
+	}
+	for _, dim := range strings.Split(match[3], ",") {
+		if dim = strings.TrimSpace(dim); len(dim) == 0 {
+			continue
+		}
+		value, err := strconv.ParseInt(dim, 10, 64)
+		if err != nil {
+			return "", nil, nil, fmt.Errorf("invalid .npy shape %s", match[3])
+		}
+		shape = append(shape, value)
+	}
+	return dtype, shape, blob, nil
+}
+
+// encodeNpy encodes the tensor blob as a version 1.0 .npy file
+func encodeNpy(dtype string, shape []int64, blob []byte) ([]byte, error) {
+	var descr string
+	for d, t := range npyDescrToType {
+		if t == dtype {
+			descr = d
+		}
+	}
+	if len(descr) == 0 {
+		return nil, fmt.Errorf("unsupported tensor type %s", dtype)
+	}
+	dims := make([]string, len(shape))
+	for pos, dim := range shape {
+		dims[pos] = strconv.FormatInt(dim, 10)
+	}
+	shapeStr := strings.Join(dims, ", ")
+	if len(shape) == 1 {
+		shapeStr += ","
+	}
+	header := fmt.Sprintf("{'descr': '%s', 'fortran_order': False, 'shape': (%s), }", descr, shapeStr)
+	// the header is padded with spaces and terminated by a newline so that the data is 64 bytes aligned
+	padding := 64 - (10+len(header)+1)%64
+	header += strings.Repeat(" ", padding%64) + "\n"
+	var buf bytes.Buffer
+	buf.Write(npyMagic)
+	buf.Write([]byte{1, 0})
+	_ = binary.Write(&buf, binary.LittleEndian, uint16(len(header)))
+	buf.WriteString(header)
+	buf.Write(blob)
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,72 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/RedisAI/redisai-go/redisai"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNpy_RoundTrip(t *testing.T) {
+	tests := []struct {
+		name  string
+		dtype string
+		shape []int64
+		data  interface{}
+	}{
+		{"float-2d", redisai.TypeFloat, []int64{2, 2}, []float32{1.1, 2.2, 3.3, 4.4}},
+		{"int64-1d", redisai.TypeInt64, []int64{3}, []int64{1, -2, 3}},
+		{"uint8-scalar", redisai.TypeUint8, []int64{}, []uint8{7}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			blob, err := redisai.TensorDataToBlob(tt.data)
+			assert.Nil(t, err)
+			content, err := encodeNpy(tt.dtype, tt.shape, blob)
+			assert.Nil(t, err)
+			assert.Equal(t, 0, (len(content)-len(blob))%64)
+			dtype, shape, gotBlob, err := decodeNpy(content)
+			assert.Nil(t, err)
+			assert.Equal(t, tt.dtype, dtype)
+			assert.Equal(t, len(tt.shape), len(shape))
+			assert.Equal(t, blob, gotBlob)
+		})
+	}
+}
+
+func TestDecodeNpy_Errors(t *testing.T) {
+	withHeader := func(header string) []byte {
+		return append([]byte{0x93, 'N', 'U', 'M', 'P', 'Y', 1, 0, byte(len(header)), 0}, header...)
+	}
+	tests := []struct {
+		name    string
+		content []byte
+	}{
+		{"not-npy", []byte("{}")},
+		{"truncated", []byte("\x93NUMPY\x01\x00\xff\x00{")},
+		{"version", []byte("\x93NUMPY\x04\x00\x00\x00")},
+		{"header", withHeader("{'descr': '<f4'}")},
+		{"dtype", withHeader("{'descr': '>f4', 'fortran_order': False, 'shape': (1,), }")},
+		{"fortran", withHeader("{'descr': '<f4', 'fortran_order': True, 'shape': (2, 1), }")},
+		{"shape", withHeader("{'descr': '<f4', 'fortran_order': False, 'shape': (a,), }")},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, _, _, err := decodeNpy(tt.content)
+			assert.NotNil(t, err)
+		})
+	}
+}
+
+func TestParseInlineTensor(t *testing.T) {
+	name, tensor, err := parseInlineTensor("a=FLOAT:2,2:1.1,2.2,3.3,4.4")
+	assert.Nil(t, err)
+	assert.Equal(t, "a", name)
+	assert.Equal(t, []int64{2, 2}, tensor.Shape())
+	assert.Equal(t, []float32{1.1, 2.2, 3.3, 4.4}, tensor.Data())
+
+	for _, value := range []string{"a", "a=FLOAT:2", "a=FLOAT:x:1", "a=FLOAT:1:x", "a=BOOL:1:1"} {
+		_, _, err = parseInlineTensor(value)
+		assert.NotNil(t, err, value)
+	}
+}
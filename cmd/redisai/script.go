@@ -0,0 +1,59 @@
+package main
+
+import (
+	"flag"
+	"io/ioutil"
+
+	"github.com/RedisAI/redisai-go/redisai"
+	"github.com/RedisAI/redisai-go/redisai/implementations"
+)
+
+func scriptStore(client *redisai.Client, args []string) error {
+	fs := flag.NewFlagSet("script store", flag.ContinueOnError)
+	device := fs.String("device", redisai.DeviceCPU, "Device to run the script on: CPU or GPU.")
+	tag := fs.String("tag", "", "Script tag.")
+	var entryPoints stringList
+	fs.Var(&entryPoints, "entry-points", "Comma separated names of the script entry point functions.")
+	positional, err := parseArgs(fs, args, "key", "file")
+	if err != nil {
+		return err
+	}
+	source, err := ioutil.ReadFile(positional[1])
+	if err != nil {
+		return err
+	}
+	script := implementations.NewScript(*device)
+	script.SetTag(*tag)
+	script.SetSource(string(source))
+	script.SetEntryPoints(entryPoints)
+	return client.ScriptStoreFromInterface(positional[0], script)
+}
+
+func scriptGet(client *redisai.Client, args []string) error {
+	fs := flag.NewFlagSet("script get", flag.ContinueOnError)
+	out := fs.String("out", "", "File to write the script source to. The metadata is printed when not given.")
+	positional, err := parseArgs(fs, args, "key")
+	if err != nil {
+		return err
+	}
+	if len(*out) > 0 {
+		source, err := client.ScriptGetSource(positional[0])
+		if err != nil {
+			return err
+		}
+		return writeOutput(*out, []byte(source))
+	}
+	meta, err := client.ScriptGetMeta(positional[0])
+	if err != nil {
+		return err
+	}
+	return printJSON(meta)
+}
+
+func scriptDel(client *redisai.Client, args []string) error {
+	positional, err := parseArgs(flag.NewFlagSet("script del", flag.ContinueOnError), args, "key")
+	if err != nil {
+		return err
+	}
+	return client.ScriptDel(positional[0])
+}
@@ -0,0 +1,82 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"io/ioutil"
+	"strings"
+
+	"github.com/RedisAI/redisai-go/redisai"
+	"github.com/RedisAI/redisai-go/redisai/gateway"
+	"github.com/RedisAI/redisai-go/redisai/implementations"
+)
+
+func tensorSet(client *redisai.Client, args []string) error {
+	positional, err := parseArgs(flag.NewFlagSet("tensor set", flag.ContinueOnError), args, "key", "file")
+	if err != nil {
+		return err
+	}
+	tensor, err := readTensorFile(positional[1])
+	if err != nil {
+		return err
+	}
+	return client.TensorSetFromTensor(positional[0], tensor)
+}
+
+// readTensorFile reads a tensor from a .npy file, or from a JSON file holding a gateway.Tensor.
+// The content of .npy files is decoded to typed values so that the tensor type is kept.
+func readTensorFile(path string) (redisai.TensorInterface, error) {
+	content, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	if strings.HasSuffix(path, ".npy") {
+		dtype, shape, blob, err := decodeNpy(content)
+		if err != nil {
+			return nil, err
+		}
+		data, err := redisai.TensorDataFromBlob(dtype, blob)
+		if err != nil {
+			return nil, err
+		}
+		return implementations.NewAiTensorWithData(dtype, shape, data), nil
+	}
+	var tensor gateway.Tensor
+	if err = json.Unmarshal(content, &tensor); err != nil {
+		return nil, err
+	}
+	return tensor.ToTensor()
+}
+
+func tensorGet(client *redisai.Client, args []string) error {
+	fs := flag.NewFlagSet("tensor get", flag.ContinueOnError)
+	out := fs.String("out", "", "File to write the tensor to, as .npy or .json. The tensor is printed as JSON when not given.")
+	positional, err := parseArgs(fs, args, "key")
+	if err != nil {
+		return err
+	}
+	dtype, shape, data, err := client.TensorGetValues(positional[0])
+	if err != nil {
+		return err
+	}
+	if strings.HasSuffix(*out, ".npy") {
+		blob, err := redisai.TensorDataToBlob(data)
+		if err != nil {
+			return err
+		}
+		content, err := encodeNpy(dtype, shape, blob)
+		if err != nil {
+			return err
+		}
+		return writeOutput(*out, content)
+	}
+	tensor, err := gateway.FromTensor(positional[0], implementations.NewAiTensorWithData(dtype, shape, data), false)
+	if err != nil {
+		return err
+	}
+	content, err := json.MarshalIndent(tensor, "", "  ")
+	if err != nil {
+		return err
+	}
+	return writeOutput(*out, append(content, '\n'))
+}
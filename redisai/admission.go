@@ -0,0 +1,231 @@
+package redisai
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// Reasons of the AdmissionError
+const (
+	// ShedQueueFull is the reason of the calls shed as the queue of their class is full
+	ShedQueueFull = "queue full"
+	// ShedQueueTimeout is the reason of the calls shed as they waited longer than the QueueTimeout of their class
+	ShedQueueTimeout = "queue timeout"
+	// ShedCanceled is the reason of the calls shed as the context of the client was done while they waited
+	ShedCanceled = "canceled"
+)
+
+// AdmissionError is returned, without issuing the command, for the executions shed by an AdmissionController
+type AdmissionError struct {
+	Key    string
+	Class  string
+	Reason string
+}
+
+func (e *AdmissionError) Error() string {
+	return fmt.Sprintf("redisai: execution of %s shed for class %s: %s", e.Key, e.Class, e.Reason)
+}
+
+// AdmissionClass is a priority class of an AdmissionController
+type AdmissionClass struct {
+	Name string
+	// Reserved is the number of in-flight executions reserved to the class, out of the maxInFlight of the controller
+	Reserved int
+	// MaxQueue is the number of executions of the class waiting for admission, beyond which they are shed.
+	// Zero means the executions are shed rather than queued once the class is saturated
+	MaxQueue int
+	// QueueTimeout is the time an execution waits for admission before being shed. Zero means no timeout
+	QueueTimeout time.Duration
+}
+
+// AdmissionClassStats are the statistics of a priority class of an AdmissionController
+type AdmissionClassStats struct {
+	Name     string
+	InFlight int
+	Queued   int
+	Admitted uint64
+	Shed     uint64
+}
+
+// admissionClass is the state of a priority class
+type admissionClass struct {
+	AdmissionClass
+	inFlight int
+	queue    []*admissionWaiter
+	admitted uint64
+	shed     uint64
+}
+
+type admissionWaiter struct {
+	key      string
+	admitted chan struct{}
+}
+
+// AdmissionController bounds the in-flight model, script and DAG executions of the clients sharing it, overall and
+// per model key, and queues the executions beyond the bounds by priority class.
+//
+// The classes are given by decreasing priority: the queued executions of a class are admitted before the ones of
+// the next classes, in their arrival order. The in-flight executions reserved to a class can only be used by it,
+// so that a lower priority class is not starved, nor a higher priority class crowded out. The executions are shed
+// with an AdmissionError when the queue of their class is full, or once they waited for its QueueTimeout.
+//
+// An AdmissionController is safe for concurrent use. Executions are tracked by model key, or script key for the
+// DAGs without models.
+type AdmissionController struct {
+	mu sync.Mutex
+	// shared is the number of in-flight executions that are not reserved to a class
+	shared              int
+	maxInFlightPerModel int
+	classes             []*admissionClass
+	perModel            map[string]int
+}
+
+// NewAdmissionController returns an AdmissionController admitting up to maxInFlight executions overall, and
+// maxInFlightPerModel per model key, in the given classes by decreasing priority. A zero maxInFlightPerModel
+// means no limit per model.
+func NewAdmissionController(maxInFlight, maxInFlightPerModel int, classes ...AdmissionClass) (*AdmissionController, error) {
+	if maxInFlight <= 0 {
+		return nil, fmt.Errorf("redisai.NewAdmissionController: maxInFlight must be positive, got %d", maxInFlight)
+	}
+	if len(classes) == 0 {
+		return nil, fmt.Errorf("redisai.NewAdmissionController: no admission class")
+	}
+	a := &AdmissionController{shared: maxInFlight, maxInFlightPerModel: maxInFlightPerModel, perModel: map[string]int{}}
+	for _, class := range classes {
+		if a.class(class.Name) != nil {
+			return nil, fmt.Errorf("redisai.NewAdmissionController: duplicate class %s", class.Name)
+		}
+		a.shared -= class.Reserved
+		a.classes = append(a.classes, &admissionClass{AdmissionClass: class})
+	}
+	if a.shared < 0 {
+		return nil, fmt.Errorf("redisai.NewAdmissionController: the classes reserve more than %d executions", maxInFlight)
+	}
+	return a, nil
+}
+
+// SetAdmissionControl makes the client issue its model, script and DAG executions through controller, in the
+// given priority class. On a pipelined client, the executions are only bounded while they are queued.
+func (c *Client) SetAdmissionControl(controller *AdmissionController, class string) error {
+	admissionClass := controller.class(class)
+	if admissionClass == nil {
+		return fmt.Errorf("redisai.SetAdmissionControl: unknown class %s", class)
+	}
+	c.admission, c.admissionClass = controller, admissionClass
+	return nil
+}
+
+// Stats returns the statistics of the classes, by decreasing priority
+func (a *AdmissionController) Stats() []AdmissionClassStats {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	stats := make([]AdmissionClassStats, 0, len(a.classes))
+	for _, class := range a.classes {
+		stats = append(stats, AdmissionClassStats{Name: class.Name, InFlight: class.inFlight, Queued: len(class.queue), Admitted: class.admitted, Shed: class.shed})
+	}
+	return stats
+}
+
+func (a *AdmissionController) class(name string) *admissionClass {
+	for _, class := range a.classes {
+		if class.Name == name {
+			return class
+		}
+	}
+	return nil
+}
+
+// acquire waits for the admission of an execution of key, and returns the function releasing it
+func (a *AdmissionController) acquire(ctx context.Context, key string, class *admissionClass) (release func(), err error) {
+	release = func() { a.release(key, class) }
+	a.mu.Lock()
+	// the queued executions never fit in the bounds, as they are admitted as soon as they do
+	if a.admissible(key, class) {
+		a.admit(key, class)
+		a.mu.Unlock()
+		return release, nil
+	}
+	if len(class.queue) >= class.MaxQueue {
+		class.shed++
+		a.mu.Unlock()
+		return nil, &AdmissionError{Key: key, Class: class.Name, Reason: ShedQueueFull}
+	}
+	waiter := &admissionWaiter{key: key, admitted: make(chan struct{})}
+	class.queue = append(class.queue, waiter)
+	a.mu.Unlock()
+
+	var timeout <-chan time.Time
+	if class.QueueTimeout > 0 {
+		timer := time.NewTimer(class.QueueTimeout)
+		defer timer.Stop()
+		timeout = timer.C
+	}
+	reason := ShedQueueTimeout
+	select {
+	case <-waiter.admitted:
+		return release, nil
+	case <-timeout:
+	case <-ctx.Done():
+		reason = ShedCanceled
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	for pos, queued := range class.queue {
+		if queued == waiter {
+			class.queue = append(class.queue[:pos], class.queue[pos+1:]...)
+			class.shed++
+			return nil, &AdmissionError{Key: key, Class: class.Name, Reason: reason}
+		}
+	}
+	// admitted while timing out
+	return release, nil
+}
+
+// admissible reports whether an execution of key in class fits in the bounds, with the lock held
+func (a *AdmissionController) admissible(key string, class *admissionClass) bool {
+	if a.maxInFlightPerModel > 0 && a.perModel[key] >= a.maxInFlightPerModel {
+		return false
+	}
+	if class.inFlight < class.Reserved {
+		return true
+	}
+	sharedInUse := 0
+	for _, other := range a.classes {
+		if other.inFlight > other.Reserved {
+			sharedInUse += other.inFlight - other.Reserved
+		}
+	}
+	return sharedInUse < a.shared
+}
+
+func (a *AdmissionController) admit(key string, class *admissionClass) {
+	class.inFlight++
+	class.admitted++
+	a.perModel[key]++
+}
+
+// release ends an execution of key, and admits the queued executions that fit in the bounds, by priority
+func (a *AdmissionController) release(key string, class *admissionClass) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	class.inFlight--
+	if a.perModel[key]--; a.perModel[key] <= 0 {
+		delete(a.perModel, key)
+	}
+	for _, queued := range a.classes {
+		// the executions of a saturated model are skipped, so that they do not hold back the other models
+		for pos := 0; pos < len(queued.queue); {
+			waiter := queued.queue[pos]
+			if !a.admissible(waiter.key, queued) {
+				pos++
+				continue
+			}
+			queued.queue = append(queued.queue[:pos], queued.queue[pos+1:]...)
+			a.admit(waiter.key, queued)
+			close(waiter.admitted)
+		}
+	}
+}
@@ -0,0 +1,168 @@
+package redisai
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewAdmissionController(t *testing.T) {
+	tests := []struct {
+		name    string
+		max     int
+		classes []AdmissionClass
+		wantErr bool
+	}{
+		{"valid", 4, []AdmissionClass{{Name: "api", Reserved: 2}, {Name: "batch", Reserved: 1}}, false},
+		{"no-max", 0, []AdmissionClass{{Name: "api"}}, true},
+		{"no-class", 4, nil, true},
+		{"duplicate-class", 4, []AdmissionClass{{Name: "api"}, {Name: "api"}}, true},
+		{"over-reserved", 2, []AdmissionClass{{Name: "api", Reserved: 2}, {Name: "batch", Reserved: 1}}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewAdmissionController(tt.max, 0, tt.classes...)
+			assert.Equal(t, tt.wantErr, err != nil)
+		})
+	}
+}
+
+func TestAdmissionController_Reserved(t *testing.T) {
+	a, err := NewAdmissionController(3, 0, AdmissionClass{Name: "api", Reserved: 1}, AdmissionClass{Name: "batch", Reserved: 1})
+	assert.Nil(t, err)
+	api, batch := a.class("api"), a.class("batch")
+	ctx := context.Background()
+
+	// batch takes its reserved and the shared executions, but not the api reserved one
+	for i := 0; i < 2; i++ {
+		_, err = a.acquire(ctx, "m", batch)
+		assert.Nil(t, err)
+	}
+	_, err = a.acquire(ctx, "m", batch)
+	assert.Equal(t, &AdmissionError{Key: "m", Class: "batch", Reason: ShedQueueFull}, err)
+	_, err = a.acquire(ctx, "m", api)
+	assert.Nil(t, err)
+	assert.Equal(t, []AdmissionClassStats{{Name: "api", InFlight: 1, Admitted: 1}, {Name: "batch", InFlight: 2, Admitted: 2, Shed: 1}}, a.Stats())
+}
+
+func TestAdmissionController_Priority(t *testing.T) {
+	a, _ := NewAdmissionController(1, 0, AdmissionClass{Name: "api", MaxQueue: 1}, AdmissionClass{Name: "batch", MaxQueue: 1})
+	api, batch := a.class("api"), a.class("batch")
+	ctx := context.Background()
+	release, err := a.acquire(ctx, "m", batch)
+	assert.Nil(t, err)
+
+	admitted := make(chan string, 2)
+	go func() {
+		if _, err := a.acquire(ctx, "m", batch); err == nil {
+			admitted <- "batch"
+		}
+	}()
+	waitQueued(t, a, "batch", 1)
+	go func() {
+		if release, err := a.acquire(ctx, "m", api); err == nil {
+			admitted <- "api"
+			release()
+		}
+	}()
+	waitQueued(t, a, "api", 1)
+
+	// the api execution queued last is admitted first
+	release()
+	assert.Equal(t, "api", <-admitted)
+	assert.Equal(t, "batch", <-admitted)
+}
+
+func TestAdmissionController_PerModel(t *testing.T) {
+	a, _ := NewAdmissionController(4, 1, AdmissionClass{Name: "api", MaxQueue: 1})
+	api := a.class("api")
+	ctx := context.Background()
+	release, err := a.acquire(ctx, "slow", api)
+	assert.Nil(t, err)
+
+	admitted := make(chan struct{})
+	go func() {
+		if _, err := a.acquire(ctx, "slow", api); err == nil {
+			close(admitted)
+		}
+	}()
+	waitQueued(t, a, "api", 1)
+	// a queued execution of a saturated model does not hold back the other models
+	_, err = a.acquire(ctx, "fast", api)
+	assert.Nil(t, err)
+	release()
+	<-admitted
+	assert.Equal(t, []AdmissionClassStats{{Name: "api", InFlight: 2, Admitted: 3}}, a.Stats())
+}
+
+func TestAdmissionController_QueueDeadline(t *testing.T) {
+	a, _ := NewAdmissionController(1, 0, AdmissionClass{Name: "api", MaxQueue: 1, QueueTimeout: 10 * time.Millisecond})
+	api := a.class("api")
+	_, err := a.acquire(context.Background(), "m", api)
+	assert.Nil(t, err)
+
+	_, err = a.acquire(context.Background(), "m", api)
+	assert.Equal(t, &AdmissionError{Key: "m", Class: "api", Reason: ShedQueueTimeout}, err)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = a.acquire(ctx, "m", api)
+	assert.Equal(t, &AdmissionError{Key: "m", Class: "api", Reason: ShedCanceled}, err)
+	assert.EqualError(t, err, "redisai: execution of m shed for class api: canceled")
+	assert.Equal(t, []AdmissionClassStats{{Name: "api", InFlight: 1, Admitted: 1, Shed: 2}}, a.Stats())
+}
+
+func TestClient_SetAdmissionControl(t *testing.T) {
+	a, _ := NewAdmissionController(1, 0, AdmissionClass{Name: "api"})
+	c, conn := createFakeClient(func(cmd string, args []interface{}) (interface{}, error) {
+		return "OK", nil
+	})
+	assert.NotNil(t, c.SetAdmissionControl(a, "batch"))
+	assert.Nil(t, c.SetAdmissionControl(a, "api"))
+	assert.Nil(t, c.ModelExecute("m", []string{"a"}, []string{"b"}))
+	assert.Equal(t, []AdmissionClassStats{{Name: "api", Admitted: 1}}, a.Stats())
+
+	// saturated by another client
+	_, err := a.acquire(context.Background(), "other", a.class("api"))
+	assert.Nil(t, err)
+	assert.Equal(t, &AdmissionError{Key: "m", Class: "api", Reason: ShedQueueFull}, c.ModelExecute("m", []string{"a"}, []string{"b"}))
+	assert.Nil(t, c.TensorSet("a", TypeFloat32, []int64{1}, []float32{1}))
+	assert.Equal(t, []string{"AI.MODELEXECUTE", "AI.TENSORSET"}, conn.CommandNames())
+}
+
+func TestClient_SetAdmissionControl_QueueWait(t *testing.T) {
+	a, _ := NewAdmissionController(1, 0, AdmissionClass{Name: "api", MaxQueue: 1})
+	c, _ := createFakeClient(func(cmd string, args []interface{}) (interface{}, error) {
+		return "OK", nil
+	})
+	assert.Nil(t, c.SetAdmissionControl(a, "api"))
+	var events []string
+	hook := &recordingHook{name: "hook", events: &events}
+	c.AddHook(hook)
+
+	release, err := a.acquire(context.Background(), "other", a.class("api"))
+	assert.Nil(t, err)
+	go func() {
+		waitQueued(t, a, "api", 1)
+		time.Sleep(20 * time.Millisecond)
+		release()
+	}()
+	assert.Nil(t, c.ModelExecute("m", []string{"a"}, []string{"b"}))
+	assert.True(t, hook.infos[0].QueueWait >= 20*time.Millisecond)
+	assert.True(t, hook.infos[0].Duration < hook.infos[0].QueueWait)
+}
+
+// waitQueued waits for the queue of a class to hold n executions
+func waitQueued(t *testing.T, a *AdmissionController, class string, n int) {
+	deadline := time.Now().Add(time.Second)
+	for time.Now().Before(deadline) {
+		for _, stats := range a.Stats() {
+			if stats.Name == class && stats.Queued == n {
+				return
+			}
+		}
+		time.Sleep(time.Millisecond)
+	}
+	t.Fatalf("the queue of %s does not hold %d executions", class, n)
+}
@@ -0,0 +1,295 @@
+package redisai
+
+import (
+	"archive/tar"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/gomodule/redigo/redis"
+)
+
+// BackupFormatVersion is the version of the archives written by Backup
+const BackupFormatVersion = 1
+
+// BackupManifestName is the name of the archive entry holding the BackupManifest
+const BackupManifestName = "manifest.json"
+
+// BackupModel describes a model of a backup archive, whose blob is stored in the File entry
+type BackupModel struct {
+	Key    string `json:"key"`
+	File   string `json:"file"`
+	SHA256 string `json:"sha256"`
+	ModelMeta
+}
+
+// BackupScript describes a script of a backup archive, whose source is stored in the File entry
+type BackupScript struct {
+	Key    string `json:"key"`
+	File   string `json:"file"`
+	SHA256 string `json:"sha256"`
+	ScriptMeta
+}
+
+// BackupManifest lists the models and scripts of a backup archive, along with their metadata
+type BackupManifest struct {
+	Version int            `json:"version"`
+	Created time.Time      `json:"created"`
+	Models  []BackupModel  `json:"models"`
+	Scripts []BackupScript `json:"scripts"`
+}
+
+// BackupOptions filters the models and scripts written by Backup or restored by Restore
+type BackupOptions struct {
+	// Match is a glob-style pattern filtering the keys. Empty means every key
+	Match string
+	// Tag keeps only the models and scripts with this tag. Empty means every tag
+	Tag string
+	// Verify compares the hashes of the restored models and scripts with the archive ones after Restore
+	Verify bool
+	// Dial opens the connections of Backup to the other master nodes of a Redis Cluster, see ClusterNodes.
+	// Nil dials them over TCP
+	Dial func(address string) (redis.Conn, error)
+}
+
+func (o BackupOptions) keep(key, tag string) bool {
+	return (len(o.Match) == 0 || globMatch(o.Match, key)) && (len(o.Tag) == 0 || o.Tag == tag)
+}
+
+// Backup writes every model and script of the server to w as a tar archive, and returns its manifest.
+//
+// The archive holds a models/<n> entry per model blob and a scripts/<n> entry per script source, followed by
+// the BackupManifestName entry describing them. The blobs are read one at a time.
+//
+// On a Redis Cluster, the keys of every master node are backed up: the nodes are discovered with ClusterNodes,
+// and every key is read from the node holding it.
+func Backup(client *Client, w io.Writer, options BackupOptions) (*BackupManifest, error) {
+	if client.PipelineActive {
+		return nil, errors.New("redisai.Backup: the server can't be read on a pipelined client")
+	}
+	nodes, err := ClusterNodes(client, options.Dial)
+	if err != nil {
+		return nil, fmt.Errorf("redisai.Backup: %v", err)
+	}
+	defer CloseClusterNodes(client, nodes)
+	manifest := &BackupManifest{Version: BackupFormatVersion, Created: time.Now().UTC(), Models: []BackupModel{}, Scripts: []BackupScript{}}
+	archive := tar.NewWriter(w)
+
+	keys, nodeOf, err := backupKeys(nodes, KeyTypeModel, options.Match)
+	if err != nil {
+		return nil, err
+	}
+	for _, key := range keys {
+		node := nodeOf[key]
+		meta, err := node.ModelGetMeta(key)
+		if err != nil {
+			return nil, fmt.Errorf("redisai.Backup: model %s: %v", key, err)
+		}
+		if !options.keep(key, meta.Tag) {
+			continue
+		}
+		var blob bytes.Buffer
+		if _, err = node.ModelGetBlob(key, &blob); err != nil {
+			return nil, fmt.Errorf("redisai.Backup: model %s: %v", key, err)
+		}
+		entry := BackupModel{Key: key, File: fmt.Sprintf("models/%d", len(manifest.Models)), SHA256: manifestHash(blob.Bytes()), ModelMeta: meta}
+		if err = backupWriteEntry(archive, entry.File, blob.Bytes(), manifest.Created); err != nil {
+			return nil, err
+		}
+		manifest.Models = append(manifest.Models, entry)
+	}
+
+	if keys, nodeOf, err = backupKeys(nodes, KeyTypeScript, options.Match); err != nil {
+		return nil, err
+	}
+	for _, key := range keys {
+		reply, err := nodeOf[key].DoOrSend("AI.SCRIPTGET", scriptGetFlatArgs(key), nil)
+		if err != nil {
+			return nil, fmt.Errorf("redisai.Backup: script %s: %v", key, err)
+		}
+		device, tag, source, entryPoints, err := scriptGetParseReply(reply)
+		if err != nil {
+			return nil, fmt.Errorf("redisai.Backup: script %s: %v", key, err)
+		}
+		if !options.keep(key, tag) {
+			continue
+		}
+		entry := BackupScript{Key: key, File: fmt.Sprintf("scripts/%d", len(manifest.Scripts)), SHA256: manifestHash([]byte(source)),
+			ScriptMeta: ScriptMeta{Device: device, Tag: tag, EntryPoints: entryPoints}}
+		if err = backupWriteEntry(archive, entry.File, []byte(source), manifest.Created); err != nil {
+			return nil, err
+		}
+		manifest.Scripts = append(manifest.Scripts, entry)
+	}
+
+	content, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	if err = backupWriteEntry(archive, BackupManifestName, content, manifest.Created); err != nil {
+		return nil, err
+	}
+	return manifest, archive.Close()
+}
+
+// backupKeys returns the sorted keys of type keyType held by the nodes, along with the node holding each one
+func backupKeys(nodes []*Client, keyType, match string) ([]string, map[string]*Client, error) {
+	nodeOf, err := scanClusterKeys(nodes, keyType, match)
+	if err != nil {
+		return nil, nil, fmt.Errorf("redisai.Backup: %v", err)
+	}
+	keys := make([]string, 0, len(nodeOf))
+	for key := range nodeOf {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys, nodeOf, nil
+}
+
+func backupWriteEntry(archive *tar.Writer, name string, content []byte, modTime time.Time) error {
+	header := &tar.Header{Name: name, Mode: 0644, Size: int64(len(content)), ModTime: modTime, Typeflag: tar.TypeReg}
+	if err := archive.WriteHeader(header); err != nil {
+		return err
+	}
+	_, err := archive.Write(content)
+	return err
+}
+
+// ReadBackupManifest reads the manifest of a backup archive, skipping the blobs and sources
+func ReadBackupManifest(r io.Reader) (*BackupManifest, error) {
+	manifest, _, err := readBackup(r, false)
+	return manifest, err
+}
+
+// readBackup reads a backup archive and checks its entries against the manifest hashes.
+// As the manifest is the last entry, the contents are held in memory when requested.
+func readBackup(r io.Reader, withContents bool) (*BackupManifest, map[string][]byte, error) {
+	archive := tar.NewReader(r)
+	contents := make(map[string][]byte)
+	var manifest *BackupManifest
+	for {
+		header, err := archive.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, nil, fmt.Errorf("redisai: invalid backup archive: %v", err)
+		}
+		if header.Name != BackupManifestName {
+			if withContents {
+				if contents[header.Name], err = ioutil.ReadAll(archive); err != nil {
+					return nil, nil, fmt.Errorf("redisai: invalid backup archive: %v", err)
+				}
+			}
+			continue
+		}
+		manifest = &BackupManifest{}
+		if err = json.NewDecoder(archive).Decode(manifest); err != nil {
+			return nil, nil, fmt.Errorf("redisai: invalid backup manifest: %v", err)
+		}
+	}
+	if manifest == nil {
+		return nil, nil, fmt.Errorf("redisai: invalid backup archive: no %s entry", BackupManifestName)
+	}
+	if manifest.Version != BackupFormatVersion {
+		return nil, nil, fmt.Errorf("redisai: unsupported backup version %d", manifest.Version)
+	}
+	if !withContents {
+		return manifest, nil, nil
+	}
+	check := func(key, file, hash string) error {
+		content, ok := contents[file]
+		if !ok {
+			return fmt.Errorf("redisai: invalid backup archive: no %s entry for %s", file, key)
+		}
+		if manifestHash(content) != hash {
+			return fmt.Errorf("redisai: invalid backup archive: the %s entry of %s is corrupted", file, key)
+		}
+		return nil
+	}
+	for _, model := range manifest.Models {
+		if err := check(model.Key, model.File, model.SHA256); err != nil {
+			return nil, nil, err
+		}
+	}
+	for _, script := range manifest.Scripts {
+		if err := check(script.Key, script.File, script.SHA256); err != nil {
+			return nil, nil, err
+		}
+	}
+	return manifest, contents, nil
+}
+
+// Restore stores the models and scripts of a backup archive written by Backup, overwriting the existing keys,
+// and returns the manifest of the restored ones. The archive is held in memory while restoring.
+// With options.Verify, the restored content is read back and compared with the archive with VerifyBackup.
+func Restore(client *Client, r io.Reader, options BackupOptions) (*BackupManifest, error) {
+	if options.Verify && client.PipelineActive {
+		return nil, errors.New("redisai.Restore: the restore can't be verified on a pipelined client")
+	}
+	archived, contents, err := readBackup(r, true)
+	if err != nil {
+		return nil, err
+	}
+	restored := &BackupManifest{Version: archived.Version, Created: archived.Created, Models: []BackupModel{}, Scripts: []BackupScript{}}
+	for _, model := range archived.Models {
+		if !options.keep(model.Key, model.Tag) {
+			continue
+		}
+		err = client.ModelStore(model.Key, model.Backend, model.Device, model.Tag, model.BatchSize, model.MinBatchSize, model.MinBatchTimeout, model.Inputs, model.Outputs, contents[model.File])
+		if err != nil {
+			return restored, fmt.Errorf("redisai.Restore: model %s: %v", model.Key, err)
+		}
+		restored.Models = append(restored.Models, model)
+	}
+	for _, script := range archived.Scripts {
+		if !options.keep(script.Key, script.Tag) {
+			continue
+		}
+		if err = client.ScriptStoreWithTag(script.Key, script.Device, string(contents[script.File]), script.EntryPoints, script.Tag); err != nil {
+			return restored, fmt.Errorf("redisai.Restore: script %s: %v", script.Key, err)
+		}
+		restored.Scripts = append(restored.Scripts, script)
+	}
+	if options.Verify {
+		err = VerifyBackup(client, restored)
+	}
+	return restored, err
+}
+
+// VerifyBackup compares the hashes of the models blobs and scripts sources held by the server with the manifest
+// ones, and returns an error listing the keys that are missing or differ
+func VerifyBackup(client *Client, manifest *BackupManifest) error {
+	if client.PipelineActive {
+		return errors.New("redisai.VerifyBackup: the server can't be read on a pipelined client")
+	}
+	var mismatches []string
+	for _, model := range manifest.Models {
+		h := sha256.New()
+		if _, err := client.ModelGetBlob(model.Key, h); err != nil {
+			mismatches = append(mismatches, fmt.Sprintf("model %s (%v)", model.Key, err))
+		} else if hex.EncodeToString(h.Sum(nil)) != model.SHA256 {
+			mismatches = append(mismatches, "model "+model.Key)
+		}
+	}
+	for _, script := range manifest.Scripts {
+		source, err := client.ScriptGetSource(script.Key)
+		if err != nil {
+			mismatches = append(mismatches, fmt.Sprintf("script %s (%v)", script.Key, err))
+		} else if manifestHash([]byte(source)) != script.SHA256 {
+			mismatches = append(mismatches, "script "+script.Key)
+		}
+	}
+	if len(mismatches) > 0 {
+		return fmt.Errorf("redisai.VerifyBackup: content differs for %s", strings.Join(mismatches, ", "))
+	}
+	return nil
+}
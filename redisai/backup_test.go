@@ -0,0 +1,242 @@
+package redisai
+
+import (
+	"archive/tar"
+	"bytes"
+	"io/ioutil"
+	"strings"
+	"testing"
+
+	"github.com/RedisAI/redisai-go/redisai/internal/redistest"
+	"github.com/gomodule/redigo/redis"
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeBackupServer stores the models, scripts and tensors in memory, with their reply to AI.MODELGET META,
+// AI.SCRIPTGET META and AI.TENSORGET META BLOB
+type fakeBackupServer struct {
+	models  map[string][]interface{}
+	blobs   map[string][]byte
+	scripts map[string][]interface{}
+	sources map[string]string
+	tensors map[string][]interface{}
+}
+
+func newFakeBackupServer() *fakeBackupServer {
+	return &fakeBackupServer{models: map[string][]interface{}{}, blobs: map[string][]byte{}, scripts: map[string][]interface{}{},
+		sources: map[string]string{}, tensors: map[string][]interface{}{}}
+}
+
+func (s *fakeBackupServer) handle(cmd string, args []interface{}) (interface{}, error) {
+	key, _ := redis.String(args[0], nil)
+	switch cmd {
+	case "SCAN":
+		keys := []interface{}{}
+		entries := s.models
+		switch args[len(args)-1] {
+		case KeyTypeScript:
+			entries = s.scripts
+		case KeyTypeTensor:
+			entries = s.tensors
+		}
+		for key := range entries {
+			if args[1] != "MATCH" || globMatch(args[2].(string), key) {
+				keys = append(keys, []byte(key))
+			}
+		}
+		return []interface{}{[]byte("0"), keys}, nil
+	case "AI.MODELGET":
+		if s.models[key] == nil {
+			return nil, redis.Error("ERR model key is empty")
+		}
+		switch {
+		case len(args) == 2 && args[1] == "BLOB":
+			return s.blobs[key], nil
+		case args[len(args)-1] == "BLOB":
+			return append(s.models[key], []byte("blob"), s.blobs[key]), nil
+		}
+		return s.models[key], nil
+	case "AI.SCRIPTGET":
+		if s.scripts[key] == nil {
+			return nil, redis.Error("ERR script key is empty")
+		}
+		if args[1] == "SOURCE" {
+			return []byte(s.sources[key]), nil
+		}
+		return append(s.scripts[key], []byte("source"), []byte(s.sources[key])), nil
+	case "AI.MODELSTORE":
+		reply := []interface{}{[]byte("backend"), []byte(args[1].(string)), []byte("device"), []byte(args[2].(string))}
+		for pos := 3; pos < len(args); pos++ {
+			switch args[pos] {
+			case "TAG":
+				reply = append(reply, []byte("tag"), []byte(args[pos+1].(string)))
+				pos++
+			case "BATCHSIZE":
+				reply = append(reply, []byte("batchsize"), args[pos+1])
+				pos++
+			case "INPUTS", "OUTPUTS":
+				names := []interface{}{}
+				for _, name := range args[pos+2 : pos+2+args[pos+1].(int)] {
+					names = append(names, []byte(name.(string)))
+				}
+				reply = append(reply, []byte(strings.ToLower(args[pos].(string))), names)
+				pos += 1 + args[pos+1].(int)
+			case "BLOB":
+				s.blobs[key] = args[pos+1].([]byte)
+				pos++
+			}
+		}
+		s.models[key] = reply
+		return "OK", nil
+	case "AI.SCRIPTSTORE":
+		reply := []interface{}{[]byte("device"), []byte(args[1].(string))}
+		for pos := 2; pos < len(args); pos++ {
+			switch args[pos] {
+			case "TAG":
+				reply = append(reply, []byte("tag"), []byte(args[pos+1].(string)))
+				pos++
+			case "ENTRY_POINTS":
+				names := []interface{}{}
+				for _, name := range args[pos+2 : pos+2+args[pos+1].(int)] {
+					names = append(names, []byte(name.(string)))
+				}
+				reply = append(reply, []byte("Entry Points"), names)
+				pos += 1 + args[pos+1].(int)
+			case "SOURCE":
+				s.sources[key] = args[pos+1].(string)
+				pos++
+			}
+		}
+		s.scripts[key] = reply
+		return "OK", nil
+	case "AI.TENSORGET":
+		return s.tensors[key], nil
+	case "AI.TENSORSET":
+		shape := []interface{}{}
+		for _, dim := range args[2 : len(args)-2] {
+			shape = append(shape, dim)
+		}
+		s.tensors[key] = []interface{}{[]byte("dtype"), []byte(args[1].(string)), []byte("shape"), shape, []byte("blob"), args[len(args)-1]}
+		return "OK", nil
+	}
+	return nil, redis.Error("ERR unknown command " + cmd)
+}
+
+// handleCluster replies to CLUSTER NODES with nodes, and to the other commands as handle
+func (s *fakeBackupServer) handleCluster(nodes string) redistest.Handler {
+	return func(cmd string, args []interface{}) (interface{}, error) {
+		if cmd == "CLUSTER" {
+			return []byte(nodes), nil
+		}
+		return s.handle(cmd, args)
+	}
+}
+
+func TestBackup_Restore(t *testing.T) {
+	source := newFakeBackupServer()
+	c, _ := createFakeClient(source.handle)
+	assert.Nil(t, c.ModelStore("fraud", BackendTF, DeviceCPU, "v1", 8, 0, 0, []string{"a", "b"}, []string{"mul"}, []byte("fraud blob")))
+	assert.Nil(t, c.ModelStore("churn", BackendONNX, DeviceCPU, "v2", 0, 0, 0, nil, nil, []byte("churn blob")))
+	assert.Nil(t, c.ScriptStoreWithTag("preprocess", DeviceCPU, "def f(a): return a", []string{"f"}, "v1"))
+
+	var archive bytes.Buffer
+	manifest, err := Backup(c, &archive, BackupOptions{})
+	assert.Nil(t, err)
+	assert.Len(t, manifest.Models, 2)
+	assert.Equal(t, "churn", manifest.Models[0].Key)
+	assert.Equal(t, ModelMeta{Backend: BackendTF, Device: DeviceCPU, Tag: "v1", BatchSize: 8, Inputs: []string{"a", "b"}, Outputs: []string{"mul"}}, manifest.Models[1].ModelMeta)
+	assert.Equal(t, []BackupScript{{Key: "preprocess", File: "scripts/0", SHA256: manifestHash([]byte("def f(a): return a")),
+		ScriptMeta: ScriptMeta{Device: DeviceCPU, Tag: "v1", EntryPoints: []string{"f"}}}}, manifest.Scripts)
+
+	read, err := ReadBackupManifest(bytes.NewReader(archive.Bytes()))
+	assert.Nil(t, err)
+	assert.Equal(t, manifest.Models, read.Models)
+
+	target := newFakeBackupServer()
+	c, conn := createFakeClient(target.handle)
+	restored, err := Restore(c, bytes.NewReader(archive.Bytes()), BackupOptions{Tag: "v1", Verify: true})
+	assert.Nil(t, err)
+	assert.Len(t, restored.Models, 1)
+	assert.Len(t, restored.Scripts, 1)
+	assert.Equal(t, []string{"AI.MODELSTORE", "AI.SCRIPTSTORE", "AI.MODELGET", "AI.SCRIPTGET"}, conn.CommandNames())
+	assert.Equal(t, []byte("fraud blob"), target.blobs["fraud"])
+	assert.Equal(t, source.models["fraud"], target.models["fraud"])
+	assert.Equal(t, source.scripts["preprocess"], target.scripts["preprocess"])
+
+	target.blobs["fraud"] = []byte("tampered")
+	err = VerifyBackup(c, restored)
+	assert.EqualError(t, err, "redisai.VerifyBackup: content differs for model fraud")
+	delete(target.scripts, "preprocess")
+	assert.Contains(t, VerifyBackup(c, restored).Error(), "script preprocess (ERR script key is empty)")
+}
+
+func TestBackup_Match(t *testing.T) {
+	server := newFakeBackupServer()
+	c, _ := createFakeClient(server.handle)
+	assert.Nil(t, c.ModelStore("fraud:v1", BackendTF, DeviceCPU, "", 0, 0, 0, nil, nil, []byte("blob")))
+	assert.Nil(t, c.ModelStore("churn:v1", BackendTF, DeviceCPU, "", 0, 0, 0, nil, nil, []byte("blob")))
+	manifest, err := Backup(c, ioutil.Discard, BackupOptions{Match: "fraud:*"})
+	assert.Nil(t, err)
+	assert.Equal(t, []BackupModel{{Key: "fraud:v1", File: "models/0", SHA256: manifestHash([]byte("blob")), ModelMeta: ModelMeta{Backend: BackendTF, Device: DeviceCPU}}}, manifest.Models)
+}
+
+func TestBackup_Cluster(t *testing.T) {
+	nodes := "1 10.0.0.1:6379@16379 myself,master - 0 0 1 connected 0-8191\n" +
+		"2 10.0.0.2:6379@16379 master - 0 0 2 connected 8192-16383\n" +
+		"3 10.0.0.3:6379@16379 slave 2 0 0 2 connected\n"
+	first, second := newFakeBackupServer(), newFakeBackupServer()
+	c, _ := createFakeClient(first.handleCluster(nodes))
+	secondConn := &redistest.Conn{Handler: second.handleCluster(nodes)}
+	other := Connect("", &redis.Pool{Dial: func() (redis.Conn, error) { return secondConn, nil }})
+	assert.Nil(t, c.ModelStore("fraud", BackendTF, DeviceCPU, "", 0, 0, 0, nil, nil, []byte("fraud blob")))
+	assert.Nil(t, other.ModelStore("churn", BackendTF, DeviceCPU, "", 0, 0, 0, nil, nil, []byte("churn blob")))
+	assert.Nil(t, other.ScriptStoreWithTag("script", DeviceCPU, "def f(a): return a", []string{"f"}, ""))
+
+	var dialed []string
+	manifest, err := Backup(c, ioutil.Discard, BackupOptions{Dial: func(address string) (redis.Conn, error) {
+		dialed = append(dialed, address)
+		return secondConn, nil
+	}})
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"10.0.0.2:6379"}, dialed)
+	assert.Len(t, manifest.Models, 2)
+	assert.Equal(t, "churn", manifest.Models[0].Key)
+	assert.Equal(t, manifestHash([]byte("churn blob")), manifest.Models[0].SHA256)
+	assert.Equal(t, "fraud", manifest.Models[1].Key)
+	assert.Equal(t, manifestHash([]byte("fraud blob")), manifest.Models[1].SHA256)
+	assert.Len(t, manifest.Scripts, 1)
+}
+
+func TestRestore_InvalidArchive(t *testing.T) {
+	writeArchive := func(entries map[string]string) []byte {
+		var archive bytes.Buffer
+		writer := tar.NewWriter(&archive)
+		for name, content := range entries {
+			assert.Nil(t, writer.WriteHeader(&tar.Header{Name: name, Mode: 0644, Size: int64(len(content))}))
+			_, err := writer.Write([]byte(content))
+			assert.Nil(t, err)
+		}
+		assert.Nil(t, writer.Close())
+		return archive.Bytes()
+	}
+	manifest := `{"version":1,"models":[{"key":"m","file":"models/0","sha256":"` + manifestHash([]byte("blob")) + `","backend":"TF","device":"CPU"}]}`
+	tests := []struct {
+		name    string
+		entries map[string]string
+	}{
+		{"no-manifest", map[string]string{"models/0": "blob"}},
+		{"bad-version", map[string]string{BackupManifestName: `{"version":2}`}},
+		{"missing-entry", map[string]string{BackupManifestName: manifest}},
+		{"corrupted-entry", map[string]string{BackupManifestName: manifest, "models/0": "blub"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, conn := createFakeClient(newFakeBackupServer().handle)
+			_, err := Restore(c, bytes.NewReader(writeArchive(tt.entries)), BackupOptions{})
+			assert.NotNil(t, err)
+			assert.Empty(t, conn.Commands())
+		})
+	}
+	_, err := Restore(nil, bytes.NewReader([]byte("not a tar archive")), BackupOptions{})
+	assert.NotNil(t, err)
+}
@@ -0,0 +1,248 @@
+package redisai
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"sync"
+	"time"
+
+	"github.com/RedisAI/redisai-go/redisai/implementations"
+)
+
+// ErrBatcherClosed is returned by Batcher.Predict once the Batcher is closed
+var ErrBatcherClosed = errors.New("redisai.Batcher: batcher is closed")
+
+type batchRequest struct {
+	inputs  map[string]TensorInterface
+	size    int64
+	outputs map[string]TensorInterface
+	err     error
+	done    chan struct{}
+}
+
+// Batcher collects concurrent predictions for the same model and runs them as a single execution.
+//
+// Requests are accumulated until MaxBatchSize samples are collected or MaxLatency elapsed since the first
+// request of the batch. A request that would take the batch past MaxBatchSize is left for the next batch, and
+// a request of more than MaxBatchSize samples is run alone. The inputs of the collected requests are then concatenated along their first (batch)
+// dimension, the model is executed once through a Predictor, and its outputs are split back to each caller.
+//
+// All the executions are issued from a single goroutine, so a Batcher can be shared by concurrent callers
+// even though the underlying Client is not safe for concurrent use. The Client must not be used elsewhere
+// while the Batcher is running.
+type Batcher struct {
+	Predictor    *Predictor
+	MaxBatchSize int64
+	MaxLatency   time.Duration
+
+	requests  chan *batchRequest
+	closed    chan struct{}
+	closeOnce sync.Once
+	wg        sync.WaitGroup
+}
+
+// NewBatcher returns a running Batcher for the model stored at modelKey.
+// Use Close to stop it once it is no longer needed.
+func NewBatcher(client *Client, modelKey string, inputs, outputs []string, maxBatchSize int64, maxLatency time.Duration) *Batcher {
+	return NewBatcherFromPredictor(NewModelPredictor(client, modelKey, inputs, outputs), maxBatchSize, maxLatency)
+}
+
+// NewBatcherFromPredictor returns a running Batcher issuing its batches through predictor
+func NewBatcherFromPredictor(predictor *Predictor, maxBatchSize int64, maxLatency time.Duration) *Batcher {
+	b := &Batcher{
+		Predictor:    predictor,
+		MaxBatchSize: maxBatchSize,
+		MaxLatency:   maxLatency,
+		requests:     make(chan *batchRequest),
+		closed:       make(chan struct{}),
+	}
+	b.wg.Add(1)
+	go b.run()
+	return b
+}
+
+// Predict queues the prediction for the next batch and waits for its outputs.
+// The first dimension of every input tensor is the batch dimension, and must be the same across inputs.
+// The data of every input tensor must be a slice holding the values of its shape.
+func (b *Batcher) Predict(inputs map[string]TensorInterface) (map[string]TensorInterface, error) {
+	size, err := b.requestSize(inputs)
+	if err != nil {
+		return nil, err
+	}
+	request := &batchRequest{inputs: inputs, size: size, done: make(chan struct{})}
+	select {
+	case b.requests <- request:
+	case <-b.closed:
+		return nil, ErrBatcherClosed
+	}
+	<-request.done
+	return request.outputs, request.err
+}
+
+// Close stops the Batcher after running the batch in progress
+func (b *Batcher) Close() error {
+	b.closeOnce.Do(func() { close(b.closed) })
+	b.wg.Wait()
+	return nil
+}
+
+func (b *Batcher) requestSize(inputs map[string]TensorInterface) (size int64, err error) {
+	for _, name := range b.Predictor.Inputs {
+		tensor, ok := inputs[name]
+		if !ok {
+			return 0, fmt.Errorf("redisai.Batcher.Predict: missing input tensor %s", name)
+		}
+		shape := tensor.Shape()
+		if len(shape) == 0 {
+			return 0, fmt.Errorf("redisai.Batcher.Predict: input tensor %s has no batch dimension", name)
+		}
+		// the batch is split back at offsets computed from the shapes, which must then match the data
+		length := int64(1)
+		for _, dim := range shape {
+			if dim < 0 {
+				return 0, fmt.Errorf("redisai.Batcher.Predict: input tensor %s has invalid shape %v", name, shape)
+			}
+			length *= dim
+		}
+		if data := reflect.ValueOf(tensor.Data()); data.Kind() != reflect.Slice || int64(data.Len()) != length {
+			return 0, fmt.Errorf("redisai.Batcher.Predict: input tensor %s data doesn't hold the %d values of its shape %v", name, length, shape)
+		}
+		if size != 0 && shape[0] != size {
+			return 0, fmt.Errorf("redisai.Batcher.Predict: input tensor %s has batch size %d, expected %d", name, shape[0], size)
+		}
+		size = shape[0]
+	}
+	return
+}
+
+func (b *Batcher) run() {
+	defer b.wg.Done()
+	// pending is the request that did not fit in the previous batch
+	var pending *batchRequest
+	for {
+		first := pending
+		pending = nil
+		if first == nil {
+			select {
+			case first = <-b.requests:
+			case <-b.closed:
+				return
+			}
+		}
+		batch := []*batchRequest{first}
+		size := first.size
+		timer := time.NewTimer(b.MaxLatency)
+	collect:
+		for size < b.MaxBatchSize {
+			select {
+			case request := <-b.requests:
+				if size+request.size > b.MaxBatchSize {
+					pending = request
+					break collect
+				}
+				batch = append(batch, request)
+				size += request.size
+			case <-timer.C:
+				break collect
+			case <-b.closed:
+				break collect
+			}
+		}
+		timer.Stop()
+		b.execute(batch)
+	}
+}
+
+// execute runs the batch, replying to every request in it
+func (b *Batcher) execute(batch []*batchRequest) {
+	defer func() {
+		for _, request := range batch {
+			close(request.done)
+		}
+	}()
+	inputs, accepted := b.concatInputs(batch)
+	if len(accepted) == 0 {
+		return
+	}
+	outputs, err := b.Predictor.Predict(inputs)
+	if err == nil {
+		err = splitOutputs(b.Predictor.Outputs, outputs, accepted)
+	}
+	if err != nil {
+		for _, request := range accepted {
+			request.err = err
+		}
+	}
+}
+
+// concatInputs concatenates the inputs of the batch requests along the batch dimension.
+// Requests whose inputs don't match the type and inner dimensions of the first request are
+// replied with an error and left out of the batch.
+func (b *Batcher) concatInputs(batch []*batchRequest) (inputs map[string]TensorInterface, accepted []*batchRequest) {
+	inputs = make(map[string]TensorInterface, len(b.Predictor.Inputs))
+	for _, request := range batch {
+		if len(accepted) > 0 {
+			if request.err = batchCompatible(b.Predictor.Inputs, accepted[0].inputs, request.inputs); request.err != nil {
+				continue
+			}
+		}
+		accepted = append(accepted, request)
+	}
+	var size int64
+	for _, request := range accepted {
+		size += request.size
+	}
+	for _, name := range b.Predictor.Inputs {
+		first := accepted[0].inputs[name]
+		data := reflect.MakeSlice(first.Dtype(), 0, 0)
+		for _, request := range accepted {
+			data = reflect.AppendSlice(data, reflect.ValueOf(request.inputs[name].Data()))
+		}
+		shape := append([]int64{size}, first.Shape()[1:]...)
+		inputs[name] = implementations.NewAiTensorWithData("", shape, data.Interface())
+	}
+	return
+}
+
+func batchCompatible(names []string, reference, inputs map[string]TensorInterface) error {
+	for _, name := range names {
+		want, got := reference[name], inputs[name]
+		if want.Dtype() != got.Dtype() {
+			return fmt.Errorf("redisai.Batcher.Predict: input tensor %s has type %v, the batch expects %v", name, got.Dtype(), want.Dtype())
+		}
+		if !reflect.DeepEqual(want.Shape()[1:], got.Shape()[1:]) {
+			return fmt.Errorf("redisai.Batcher.Predict: input tensor %s has shape %v, the batch expects [N %v]", name, got.Shape(), want.Shape()[1:])
+		}
+	}
+	return nil
+}
+
+// splitOutputs splits every output tensor along its batch dimension, assigning to each request its slice
+func splitOutputs(names []string, outputs map[string]TensorInterface, requests []*batchRequest) error {
+	var size int64
+	for _, request := range requests {
+		size += request.size
+		request.outputs = make(map[string]TensorInterface, len(names))
+	}
+	for _, name := range names {
+		output := outputs[name]
+		shape := output.Shape()
+		if len(shape) == 0 || shape[0] != size {
+			return fmt.Errorf("redisai.Batcher: output tensor %s has shape %v, expected a batch dimension of %d", name, shape, size)
+		}
+		data := reflect.ValueOf(output.Data())
+		if data.Kind() != reflect.Slice || int64(data.Len())%size != 0 {
+			return fmt.Errorf("redisai.Batcher: output tensor %s data can't be split in %d samples", name, size)
+		}
+		stride := int64(data.Len()) / size
+		var offset int64
+		for _, request := range requests {
+			requestData := data.Slice(int(offset*stride), int((offset+request.size)*stride)).Interface()
+			requestShape := append([]int64{request.size}, shape[1:]...)
+			request.outputs[name] = implementations.NewAiTensorWithData("", requestShape, requestData)
+			offset += request.size
+		}
+	}
+	return nil
+}
@@ -0,0 +1,132 @@
+package redisai
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/RedisAI/redisai-go/redisai/implementations"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBatcher_Predict(t *testing.T) {
+	reply := []interface{}{[]byte("dtype"), []byte(TypeInt64), []byte("shape"), []interface{}{int64(3), int64(2)}, []byte("values"), []interface{}{int64(1), int64(2), int64(3), int64(4), int64(5), int64(6)}}
+	c, conn := createFakeClient(func(cmd string, args []interface{}) (interface{}, error) {
+		return []interface{}{"OK", "OK", reply}, nil
+	})
+	b := NewBatcher(c, "mymodel", []string{"a"}, []string{"b"}, 3, time.Minute)
+	defer b.Close()
+
+	var wg sync.WaitGroup
+	results := make([]map[string]TensorInterface, 3)
+	for i := 0; i < 3; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			outputs, err := b.Predict(map[string]TensorInterface{
+				"a": implementations.NewAiTensorWithData(TypeInt64, []int64{1, 2}, []int64{int64(i), int64(i)}),
+			})
+			assert.Nil(t, err)
+			results[i] = outputs
+		}(i)
+	}
+	wg.Wait()
+	assert.Equal(t, []string{"AI.DAGEXECUTE"}, conn.CommandNames())
+	// the three samples are sent as a single [3 2] tensor
+	assert.Equal(t, []interface{}{int64(3), int64(2)}, conn.Commands()[0][7:9])
+	seen := map[int64]bool{}
+	for _, outputs := range results {
+		assert.Equal(t, []int64{1, 2}, outputs["b"].Shape())
+		data := outputs["b"].Data().([]int64)
+		assert.Equal(t, data[0]+1, data[1])
+		seen[data[0]] = true
+	}
+	assert.Equal(t, map[int64]bool{1: true, 3: true, 5: true}, seen)
+}
+
+func TestBatcher_Predict_MaxLatency(t *testing.T) {
+	reply := []interface{}{[]byte("dtype"), []byte(TypeInt64), []byte("shape"), []interface{}{int64(1)}, []byte("values"), []interface{}{int64(7)}}
+	c, _ := createFakeClient(func(cmd string, args []interface{}) (interface{}, error) {
+		return []interface{}{"OK", "OK", reply}, nil
+	})
+	b := NewBatcher(c, "mymodel", []string{"a"}, []string{"b"}, 10, 10*time.Millisecond)
+	outputs, err := b.Predict(map[string]TensorInterface{
+		"a": implementations.NewAiTensorWithData(TypeInt64, []int64{1}, []int64{1}),
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, []int64{7}, outputs["b"].Data())
+	assert.Nil(t, b.Close())
+	_, err = b.Predict(map[string]TensorInterface{
+		"a": implementations.NewAiTensorWithData(TypeInt64, []int64{1}, []int64{1}),
+	})
+	assert.Equal(t, ErrBatcherClosed, err)
+}
+
+func TestBatcher_Predict_MaxBatchSize(t *testing.T) {
+	reply := []interface{}{[]byte("dtype"), []byte(TypeInt64), []byte("shape"), []interface{}{int64(2)}, []byte("values"), []interface{}{int64(1), int64(2)}}
+	c, conn := createFakeClient(func(cmd string, args []interface{}) (interface{}, error) {
+		return []interface{}{"OK", "OK", reply}, nil
+	})
+	b := NewBatcher(c, "mymodel", []string{"a"}, []string{"b"}, 3, 10*time.Millisecond)
+	defer b.Close()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 2; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := b.Predict(map[string]TensorInterface{
+				"a": implementations.NewAiTensorWithData(TypeInt64, []int64{2}, []int64{1, 2}),
+			})
+			assert.Nil(t, err)
+		}()
+	}
+	wg.Wait()
+	// the second request does not fit in the first batch, and is run in a batch of its own
+	assert.Equal(t, []string{"AI.DAGEXECUTE", "AI.DAGEXECUTE"}, conn.CommandNames())
+	for _, command := range conn.Commands() {
+		assert.Equal(t, int64(2), command[7])
+	}
+}
+
+func TestBatcher_Predict_Errors(t *testing.T) {
+	c, _ := createFakeClient(func(cmd string, args []interface{}) (interface{}, error) {
+		return nil, errors.New("ERR model not found")
+	})
+	b := NewBatcher(c, "mymodel", []string{"a"}, []string{"b"}, 2, time.Minute)
+	defer b.Close()
+
+	_, err := b.Predict(map[string]TensorInterface{})
+	assert.NotNil(t, err)
+	for _, data := range []interface{}{nil, int64(1)} {
+		_, err = b.Predict(map[string]TensorInterface{
+			"a": implementations.NewAiTensorWithData("", []int64{1}, data),
+		})
+		assert.NotNil(t, err)
+	}
+	// the data must match the shape, or the batch would be split at the wrong offsets
+	for _, shape := range [][]int64{{1, 2}, {1, -3}} {
+		_, err = b.Predict(map[string]TensorInterface{
+			"a": implementations.NewAiTensorWithData("", shape, []int64{1, 2, 3}),
+		})
+		assert.NotNil(t, err)
+	}
+
+	var wg sync.WaitGroup
+	errs := make([]error, 2)
+	for i, data := range []interface{}{[]int64{1}, []float32{1}} {
+		wg.Add(1)
+		go func(i int, data interface{}) {
+			defer wg.Done()
+			_, errs[i] = b.Predict(map[string]TensorInterface{
+				"a": implementations.NewAiTensorWithData("", []int64{1}, data),
+			})
+		}(i, data)
+	}
+	wg.Wait()
+	// one request is rejected for its type, the other one gets the execution error
+	assert.NotNil(t, errs[0])
+	assert.NotNil(t, errs[1])
+	assert.NotEqual(t, errs[0].Error(), errs[1].Error())
+}
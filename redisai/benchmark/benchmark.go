@@ -0,0 +1,192 @@
+// Package benchmark drives configurable load against a RedisAI server and reports throughput and latency percentiles.
+//
+// A benchmark runs an Operation from Concurrency workers, each one with its own redisai.Client on top of a
+// shared pool. Without a Rate the workers issue operations back to back (closed loop). With a Rate the
+// operations are scheduled at fixed intervals regardless of the server response time (open loop), and their
+// latency is measured from their scheduled start so that queueing delays are accounted for.
+package benchmark
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/RedisAI/redisai-go/redisai"
+	"github.com/gomodule/redigo/redis"
+)
+
+// Operation is a single benchmarked request, issued by the given worker through its client
+type Operation func(client *redisai.Client, worker int) error
+
+// Config holds the parameters of a benchmark run
+type Config struct {
+	// Concurrency is the number of workers issuing operations
+	Concurrency int `json:"concurrency"`
+	// Rate is the target number of operations per second across workers. Zero means closed loop
+	Rate float64 `json:"rate"`
+	// Duration bounds the measured part of the run
+	Duration time.Duration `json:"duration"`
+	// Requests bounds the number of measured operations. Zero means no bound
+	Requests int64 `json:"requests"`
+	// Warmup is the duration for which operations are issued but not measured
+	Warmup time.Duration `json:"warmup"`
+}
+
+// LatencyReport holds the latency distribution of a run, in microseconds
+type LatencyReport struct {
+	Min  int64 `json:"min_us"`
+	Mean int64 `json:"mean_us"`
+	P50  int64 `json:"p50_us"`
+	P90  int64 `json:"p90_us"`
+	P95  int64 `json:"p95_us"`
+	P99  int64 `json:"p99_us"`
+	P999 int64 `json:"p999_us"`
+	Max  int64 `json:"max_us"`
+}
+
+// Result is the outcome of a benchmark run, meant to be exported as JSON and compared across runs
+type Result struct {
+	Name       string        `json:"name"`
+	Config     Config        `json:"config"`
+	Requests   int64         `json:"requests"`
+	Errors     int64         `json:"errors"`
+	FirstError string        `json:"first_error,omitempty"`
+	Elapsed    float64       `json:"elapsed_seconds"`
+	Throughput float64       `json:"throughput_ops"`
+	Latency    LatencyReport `json:"latency"`
+
+	histogram *Histogram
+}
+
+// Histogram returns the latency histogram of the successful operations
+func (r *Result) Histogram() *Histogram {
+	return r.histogram
+}
+
+type worker struct {
+	histogram  *Histogram
+	requests   int64
+	errors     int64
+	firstError error
+}
+
+// Run runs the benchmark of op against the server reached through pool
+func Run(name string, pool *redis.Pool, config Config, op Operation) (*Result, error) {
+	if config.Concurrency <= 0 {
+		return nil, errors.New("benchmark: concurrency must be positive")
+	}
+	if config.Duration <= 0 && config.Requests <= 0 {
+		return nil, errors.New("benchmark: either a duration or a number of requests must be given")
+	}
+	var schedule chan time.Time
+	stop := make(chan struct{})
+	if config.Rate > 0 {
+		schedule = make(chan time.Time, config.Concurrency)
+		go scheduleOperations(schedule, stop, config.Rate)
+	}
+
+	start := time.Now()
+	measureFrom := start.Add(config.Warmup)
+	var deadline time.Time
+	if config.Duration > 0 {
+		deadline = measureFrom.Add(config.Duration)
+	}
+	var issued int64
+	workers := make([]*worker, config.Concurrency)
+	var wg sync.WaitGroup
+	for id := range workers {
+		w := &worker{histogram: NewHistogram()}
+		workers[id] = w
+		wg.Add(1)
+		go func(id int) {
+			defer wg.Done()
+			client := redisai.Connect("", pool)
+			defer client.Close()
+			for {
+				scheduled := time.Now()
+				if schedule != nil {
+					scheduled = <-schedule
+				}
+				if !deadline.IsZero() && scheduled.After(deadline) {
+					return
+				}
+				measured := !scheduled.Before(measureFrom)
+				if measured && config.Requests > 0 && atomic.AddInt64(&issued, 1) > config.Requests {
+					return
+				}
+				err := op(client, id)
+				latency := time.Since(scheduled)
+				if !measured {
+					continue
+				}
+				w.requests++
+				if err != nil {
+					w.errors++
+					if w.firstError == nil {
+						w.firstError = err
+					}
+					continue
+				}
+				w.histogram.Record(latency)
+			}
+		}(id)
+	}
+	wg.Wait()
+	close(stop)
+	elapsed := time.Since(measureFrom)
+
+	result := &Result{Name: name, Config: config, Elapsed: elapsed.Seconds(), histogram: NewHistogram()}
+	for _, w := range workers {
+		result.histogram.Merge(w.histogram)
+		result.Requests += w.requests
+		result.Errors += w.errors
+		if w.firstError != nil && len(result.FirstError) == 0 {
+			result.FirstError = w.firstError.Error()
+		}
+	}
+	if elapsed > 0 {
+		result.Throughput = float64(result.Requests-result.Errors) / elapsed.Seconds()
+	}
+	h := result.histogram
+	result.Latency = LatencyReport{
+		Min:  int64(h.Min() / time.Microsecond),
+		Mean: int64(h.Mean() / time.Microsecond),
+		P50:  int64(h.Percentile(50) / time.Microsecond),
+		P90:  int64(h.Percentile(90) / time.Microsecond),
+		P95:  int64(h.Percentile(95) / time.Microsecond),
+		P99:  int64(h.Percentile(99) / time.Microsecond),
+		P999: int64(h.Percentile(99.9) / time.Microsecond),
+		Max:  int64(h.Max() / time.Microsecond),
+	}
+	return result, nil
+}
+
+// scheduleOperations emits the scheduled start time of every operation at the given rate, until stop is closed.
+// The start times are kept evenly spaced even when the workers lag behind.
+func scheduleOperations(schedule chan<- time.Time, stop <-chan struct{}, rate float64) {
+	interval := time.Duration(float64(time.Second) / rate)
+	next := time.Now()
+	for {
+		if wait := time.Until(next); wait > 0 {
+			select {
+			case <-time.After(wait):
+			case <-stop:
+				return
+			}
+		}
+		select {
+		case schedule <- next:
+		case <-stop:
+			return
+		}
+		next = next.Add(interval)
+	}
+}
+
+// String returns a human readable summary of the result
+func (r *Result) String() string {
+	return fmt.Sprintf("%s: %d requests, %d errors in %.2fs, %.1f ops/s, latency p50 %dus p99 %dus p99.9 %dus max %dus",
+		r.Name, r.Requests, r.Errors, r.Elapsed, r.Throughput, r.Latency.P50, r.Latency.P99, r.Latency.P999, r.Latency.Max)
+}
@@ -0,0 +1,130 @@
+package benchmark
+
+import (
+	"encoding/json"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/RedisAI/redisai-go/redisai"
+	"github.com/RedisAI/redisai-go/redisai/implementations"
+	"github.com/RedisAI/redisai-go/redisai/internal/redistest"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRun_Requests(t *testing.T) {
+	pool, _ := redistest.NewPool(nil)
+	var calls int64
+	result, err := Run("count", pool, Config{Concurrency: 4, Requests: 100}, func(client *redisai.Client, worker int) error {
+		if atomic.AddInt64(&calls, 1)%10 == 0 {
+			return errors.New("failed")
+		}
+		return nil
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, int64(100), calls)
+	assert.Equal(t, int64(100), result.Requests)
+	assert.Equal(t, int64(10), result.Errors)
+	assert.Equal(t, "failed", result.FirstError)
+	assert.Equal(t, int64(90), result.Histogram().Count())
+
+	encoded, err := json.Marshal(result)
+	assert.Nil(t, err)
+	var decoded map[string]interface{}
+	assert.Nil(t, json.Unmarshal(encoded, &decoded))
+	assert.Equal(t, float64(100), decoded["requests"])
+	assert.Contains(t, decoded["latency"], "p99_us")
+}
+
+func TestRun_Rate(t *testing.T) {
+	pool, _ := redistest.NewPool(nil)
+	var calls int64
+	result, err := Run("rate", pool, Config{Concurrency: 2, Rate: 200, Duration: 200 * time.Millisecond}, func(client *redisai.Client, worker int) error {
+		atomic.AddInt64(&calls, 1)
+		return nil
+	})
+	assert.Nil(t, err)
+	// 40 operations are scheduled in 200ms at 200 ops/s
+	assert.InDelta(t, 40, result.Requests, 5)
+	assert.Equal(t, calls, result.Requests)
+}
+
+func TestRun_InvalidConfig(t *testing.T) {
+	pool, _ := redistest.NewPool(nil)
+	op := func(client *redisai.Client, worker int) error { return nil }
+	_, err := Run("invalid", pool, Config{Requests: 1}, op)
+	assert.NotNil(t, err)
+	_, err = Run("invalid", pool, Config{Concurrency: 1}, op)
+	assert.NotNil(t, err)
+}
+
+func TestModelWorkload_Operation(t *testing.T) {
+	tensorReply := []interface{}{[]byte("dtype"), []byte(redisai.TypeFloat), []byte("shape"), []interface{}{int64(1)}, []byte("values"), []interface{}{[]byte("2")}}
+	workload := ModelWorkload{
+		ModelKey:      "mymodel",
+		InputNames:    []string{"a"},
+		Inputs:        map[string]redisai.TensorInterface{"a": implementations.NewAiTensorWithData(redisai.TypeFloat, []int64{1}, []float32{1})},
+		Outputs:       []string{"b"},
+		PipelineDepth: 2,
+	}
+	tests := []struct {
+		name     string
+		mode     string
+		wantCmds []string
+	}{
+		{"dag", ModeDag, []string{"AI.DAGEXECUTE"}},
+		{"commands", ModeCommands, []string{"AI.TENSORSET", "AI.MODELEXECUTE", "AI.TENSORGET"}},
+		{"pipeline", ModePipeline, []string{"AI.TENSORSET", "AI.MODELEXECUTE", "AI.TENSORGET", "AI.TENSORSET", "AI.MODELEXECUTE", "AI.TENSORGET"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pool, conn := redistest.NewPool(func(cmd string, args []interface{}) (interface{}, error) {
+				switch cmd {
+				case "AI.DAGEXECUTE":
+					return []interface{}{"OK", "OK", tensorReply}, nil
+				case "AI.TENSORGET":
+					return tensorReply, nil
+				}
+				return "OK", nil
+			})
+			wl := workload
+			wl.Mode = tt.mode
+			op, err := wl.Operation()
+			assert.Nil(t, err)
+			client := redisai.Connect("", pool)
+			assert.Nil(t, op(client, 3))
+			assert.Equal(t, tt.wantCmds, conn.CommandNames())
+			if tt.mode != ModeDag {
+				assert.Equal(t, "{mymodel}:benchmark:3:input:a", conn.Commands()[0][1])
+			}
+			assert.False(t, client.PipelineActive)
+		})
+	}
+
+	// the connection is closed when the pipeline fails half-sent, instead of leaving its replies pending
+	pool, conn := redistest.NewPool(nil)
+	wl := workload
+	wl.Mode = ModePipeline
+	wl.InputNames = []string{"a", "c"}
+	wl.Inputs = map[string]redisai.TensorInterface{
+		"a": workload.Inputs["a"],
+		"c": implementations.NewAiTensorWithData(redisai.TypeFloat, []int64{1}, "invalid"),
+	}
+	op, err := wl.Operation()
+	assert.Nil(t, err)
+	client := redisai.Connect("", pool)
+	assert.NotNil(t, op(client, 3))
+	assert.Equal(t, []string{"AI.TENSORSET"}, conn.CommandNames())
+	assert.Nil(t, client.ActiveConn)
+	assert.False(t, client.PipelineActive)
+
+	wl = workload
+	wl.Mode = "unknown"
+	_, err = wl.Operation()
+	assert.NotNil(t, err)
+	wl.Mode = ModeDag
+	wl.InputNames = []string{"missing"}
+	_, err = wl.Operation()
+	assert.NotNil(t, err)
+}
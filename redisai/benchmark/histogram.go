@@ -0,0 +1,128 @@
+package benchmark
+
+import (
+	"math"
+	"math/bits"
+	"time"
+)
+
+// subBucketBits is the number of bits of precision kept for every recorded value,
+// bounding the relative error of the reported percentiles to 1/2^subBucketBits
+const subBucketBits = 7
+
+const subBucketCount = 1 << subBucketBits
+
+// Histogram is a log-linear latency histogram in the spirit of HDR histograms: values are grouped in
+// power of two ranges, each one split in subBucketCount linear sub-buckets. It is not safe for concurrent use.
+type Histogram struct {
+	counts []int64
+	count  int64
+	sum    float64
+	min    int64
+	max    int64
+}
+
+// NewHistogram returns an empty Histogram
+func NewHistogram() *Histogram {
+	return &Histogram{counts: make([]int64, subBucketCount+(64-subBucketBits)*subBucketCount/2), min: math.MaxInt64}
+}
+
+func bucketIndex(value int64) int {
+	if value < subBucketCount {
+		return int(value)
+	}
+	exponent := bits.Len64(uint64(value)) - subBucketBits
+	return subBucketCount + (exponent-1)*subBucketCount/2 + int(value>>uint(exponent)) - subBucketCount/2
+}
+
+// bucketValue returns the highest value recorded in the bucket
+func bucketValue(index int) int64 {
+	if index < subBucketCount {
+		return int64(index)
+	}
+	index -= subBucketCount
+	exponent := index/(subBucketCount/2) + 1
+	offset := int64(index%(subBucketCount/2) + subBucketCount/2)
+	return (offset+1)<<uint(exponent) - 1
+}
+
+// Record adds a duration to the histogram, with microsecond resolution
+func (h *Histogram) Record(d time.Duration) {
+	value := int64(d / time.Microsecond)
+	if value < 0 {
+		value = 0
+	}
+	h.counts[bucketIndex(value)]++
+	h.count++
+	h.sum += float64(value)
+	if value < h.min {
+		h.min = value
+	}
+	if value > h.max {
+		h.max = value
+	}
+}
+
+// Merge adds the values recorded by other to the histogram
+func (h *Histogram) Merge(other *Histogram) {
+	for pos, count := range other.counts {
+		h.counts[pos] += count
+	}
+	h.count += other.count
+	h.sum += other.sum
+	if other.min < h.min {
+		h.min = other.min
+	}
+	if other.max > h.max {
+		h.max = other.max
+	}
+}
+
+// Count returns the number of recorded values
+func (h *Histogram) Count() int64 {
+	return h.count
+}
+
+// Percentile returns the value below which the given percentage of the recorded values fall
+func (h *Histogram) Percentile(percentile float64) time.Duration {
+	if h.count == 0 {
+		return 0
+	}
+	target := int64(math.Ceil(percentile / 100 * float64(h.count)))
+	if target < 1 {
+		target = 1
+	}
+	var seen int64
+	for pos, count := range h.counts {
+		seen += count
+		if seen >= target {
+			value := bucketValue(pos)
+			if value > h.max {
+				value = h.max
+			}
+			return time.Duration(value) * time.Microsecond
+		}
+	}
+	return time.Duration(h.max) * time.Microsecond
+}
+
+// Min returns the lowest recorded value
+func (h *Histogram) Min() time.Duration {
+	if h.count == 0 {
+		return 0
+	}
+	return time.Duration(h.min) * time.Microsecond
+}
+
+// Max returns the highest recorded value
+func (h *Histogram) Max() time.Duration {
+	return time.Duration(h.max) * time.Microsecond
+}
+
+// Mean returns the average of the recorded values
+func (h *Histogram) Mean() time.Duration {
+	if h.count == 0 {
+		return 0
+	}
+	return time.Duration(h.sum/float64(h.count)) * time.Microsecond
+}
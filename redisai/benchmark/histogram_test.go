@@ -0,0 +1,59 @@
+package benchmark
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHistogram_Percentile(t *testing.T) {
+	h := NewHistogram()
+	for i := 1; i <= 10000; i++ {
+		h.Record(time.Duration(i) * time.Microsecond)
+	}
+	tests := []struct {
+		name       string
+		percentile float64
+		want       time.Duration
+	}{
+		{"p0", 0, time.Microsecond},
+		{"p50", 50, 5000 * time.Microsecond},
+		{"p99", 99, 9900 * time.Microsecond},
+		{"p99.9", 99.9, 9990 * time.Microsecond},
+		{"p100", 100, 10000 * time.Microsecond},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := h.Percentile(tt.percentile)
+			// values are kept with 7 bits of precision
+			assert.InDelta(t, float64(tt.want), float64(got), float64(tt.want)/64)
+			assert.True(t, got >= tt.want)
+		})
+	}
+	assert.Equal(t, int64(10000), h.Count())
+	assert.Equal(t, time.Microsecond, h.Min())
+	assert.Equal(t, 10000*time.Microsecond, h.Max())
+	assert.Equal(t, 5000*time.Microsecond, h.Mean())
+}
+
+func TestHistogram_Merge(t *testing.T) {
+	h1 := NewHistogram()
+	h1.Record(10 * time.Microsecond)
+	h2 := NewHistogram()
+	h2.Record(time.Second)
+	h2.Record(time.Hour)
+	h1.Merge(h2)
+	assert.Equal(t, int64(3), h1.Count())
+	assert.Equal(t, 10*time.Microsecond, h1.Min())
+	assert.Equal(t, time.Hour, h1.Max())
+	assert.Equal(t, 10*time.Microsecond, h1.Percentile(33))
+	assert.InDelta(t, float64(time.Second), float64(h1.Percentile(50)), float64(time.Second)/64)
+}
+
+func TestHistogram_Empty(t *testing.T) {
+	h := NewHistogram()
+	assert.Equal(t, time.Duration(0), h.Percentile(99))
+	assert.Equal(t, time.Duration(0), h.Min())
+	assert.Equal(t, time.Duration(0), h.Mean())
+}
@@ -0,0 +1,139 @@
+package benchmark
+
+import (
+	"fmt"
+
+	"github.com/RedisAI/redisai-go/redisai"
+	"github.com/gomodule/redigo/redis"
+)
+
+// Execution modes of the model operations
+const (
+	// ModeDag runs every prediction as a single AI.DAGEXECUTE, without writing tensors to the keyspace
+	ModeDag = "dag"
+	// ModeCommands runs every prediction as AI.TENSORSET, AI.MODELEXECUTE and AI.TENSORGET round trips
+	ModeCommands = "commands"
+	// ModePipeline sends the commands of PipelineDepth predictions in a single pipeline
+	ModePipeline = "pipeline"
+)
+
+// ModelWorkload describes the model executions issued by a benchmark
+type ModelWorkload struct {
+	ModelKey string
+	// InputNames holds the input names in the model inputs order
+	InputNames []string
+	Inputs     map[string]redisai.TensorInterface
+	Outputs    []string
+	// Timeout is the execution timeout in milliseconds. Zero means no timeout
+	Timeout int64
+	// Mode is one of ModeDag, ModeCommands or ModePipeline
+	Mode string
+	// PipelineDepth is the number of predictions of a single operation in ModePipeline
+	PipelineDepth int
+}
+
+// Operation returns the Operation running a prediction of the workload.
+// In ModePipeline an operation is made of PipelineDepth predictions and its latency covers all of them.
+func (wl ModelWorkload) Operation() (Operation, error) {
+	for _, name := range wl.InputNames {
+		if _, ok := wl.Inputs[name]; !ok {
+			return nil, fmt.Errorf("benchmark: missing input tensor %s", name)
+		}
+	}
+	switch wl.Mode {
+	case ModeDag, "":
+		return wl.dagOperation, nil
+	case ModeCommands:
+		return wl.commandsOperation, nil
+	case ModePipeline:
+		if wl.PipelineDepth <= 0 {
+			return nil, fmt.Errorf("benchmark: pipeline depth must be positive")
+		}
+		return wl.pipelineOperation, nil
+	}
+	return nil, fmt.Errorf("benchmark: unknown mode %s", wl.Mode)
+}
+
+func (wl ModelWorkload) dagOperation(client *redisai.Client, worker int) error {
+	predictor := redisai.NewModelPredictor(client, wl.ModelKey, wl.InputNames, wl.Outputs)
+	predictor.Timeout = wl.Timeout
+	_, err := predictor.Predict(wl.Inputs)
+	return err
+}
+
+func (wl ModelWorkload) commandsOperation(client *redisai.Client, worker int) error {
+	inputKeys, outputKeys := wl.keys(worker)
+	for pos, name := range wl.InputNames {
+		if err := client.TensorSetFromTensor(inputKeys[pos], wl.Inputs[name]); err != nil {
+			return err
+		}
+	}
+	if err := client.ModelExecuteWithTimeout(wl.ModelKey, inputKeys, outputKeys, wl.Timeout); err != nil {
+		return err
+	}
+	for _, key := range outputKeys {
+		if _, err := client.TensorGet(key, redisai.TensorContentTypeBlob); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (wl ModelWorkload) pipelineOperation(client *redisai.Client, worker int) (err error) {
+	inputKeys, outputKeys := wl.keys(worker)
+	client.Pipeline(0)
+	sending := true
+	defer func() {
+		if sending && client.ActiveConn != nil {
+			// the replies of the commands sent before the failure are pending on the connection, which is
+			// closed rather than left to the next commands: the pool drains it or discards it
+			_ = client.ActiveConn.Close()
+			client.ActiveConn = nil
+		}
+		if errDisable := client.DisablePipeline(); err == nil {
+			err = errDisable
+		}
+	}()
+	sent := 0
+	for i := 0; i < wl.PipelineDepth; i++ {
+		for pos, name := range wl.InputNames {
+			if err = client.TensorSetFromTensor(inputKeys[pos], wl.Inputs[name]); err != nil {
+				return
+			}
+		}
+		if err = client.ModelExecuteWithTimeout(wl.ModelKey, inputKeys, outputKeys, wl.Timeout); err != nil {
+			return
+		}
+		for _, key := range outputKeys {
+			if _, err = client.DoOrSend("AI.TENSORGET", redis.Args{key, redisai.TensorContentTypeBlob}, nil); err != nil {
+				return
+			}
+		}
+		sent += len(inputKeys) + 1 + len(outputKeys)
+	}
+	if err = client.Flush(); err != nil {
+		return
+	}
+	sending = false
+	// every reply is received, so that the connection is left in a consistent state
+	for i := 0; i < sent; i++ {
+		if _, errReceive := client.Receive(); errReceive != nil && err == nil {
+			err = errReceive
+		}
+	}
+	return
+}
+
+// keys returns the keys of the tensors of a worker, under the model's hash tag
+func (wl ModelWorkload) keys(worker int) (inputKeys, outputKeys []string) {
+	prefix := fmt.Sprintf("{%s}:benchmark:%d:", redisai.HashTag(wl.ModelKey), worker)
+	inputKeys = make([]string, len(wl.InputNames))
+	for pos, name := range wl.InputNames {
+		inputKeys[pos] = prefix + "input:" + name
+	}
+	outputKeys = make([]string, len(wl.Outputs))
+	for pos, name := range wl.Outputs {
+		outputKeys[pos] = prefix + "output:" + name
+	}
+	return
+}
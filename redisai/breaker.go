@@ -0,0 +1,318 @@
+package redisai
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// DefaultBreakerWindow is the number of last calls the error rate of a CircuitBreaker is computed on
+	DefaultBreakerWindow = 20
+	// DefaultBreakerMinCalls is the number of calls a key needs before its error rate can trip the circuit
+	DefaultBreakerMinCalls = 10
+	// DefaultBreakerFailureRate is the error rate tripping the circuit of a key
+	DefaultBreakerFailureRate = 0.5
+	// DefaultBreakerConsecutiveTimeouts is the number of consecutive timeouts tripping the circuit of a key
+	DefaultBreakerConsecutiveTimeouts = 3
+	// DefaultBreakerOpenTimeout is the time a circuit stays open before letting probes through
+	DefaultBreakerOpenTimeout = 30 * time.Second
+	// DefaultBreakerHalfOpenProbes is the number of successful probes closing a half-open circuit
+	DefaultBreakerHalfOpenProbes = 1
+)
+
+// CircuitState is the state of the circuit of a model or script key
+type CircuitState int
+
+const (
+	// CircuitClosed lets every call through
+	CircuitClosed CircuitState = iota
+	// CircuitOpen fails every call fast with a CircuitOpenError
+	CircuitOpen
+	// CircuitHalfOpen lets a limited number of probes through, and fails the other calls fast
+	CircuitHalfOpen
+)
+
+func (s CircuitState) String() string {
+	switch s {
+	case CircuitClosed:
+		return "closed"
+	case CircuitOpen:
+		return "open"
+	case CircuitHalfOpen:
+		return "half-open"
+	}
+	return fmt.Sprintf("CircuitState(%d)", int(s))
+}
+
+// CircuitOpenError is returned, without issuing the command, for the calls to a model or script whose circuit is open
+type CircuitOpenError struct {
+	Key   string
+	State CircuitState
+	// Until is the time the circuit lets probes through, zero while the probes of a half-open circuit are in flight
+	Until time.Time
+}
+
+func (e *CircuitOpenError) Error() string {
+	if e.Until.IsZero() {
+		return fmt.Sprintf("redisai: circuit %s for %s", e.State, e.Key)
+	}
+	return fmt.Sprintf("redisai: circuit %s for %s until %s", e.State, e.Key, e.Until.Format(time.RFC3339))
+}
+
+// CircuitBreaker fails fast the calls to the models and scripts that keep failing or timing out.
+//
+// The executions of a model or script, and the DAGs running them, are tracked by model key, or script key for the
+// DAGs without models. The circuit of a key trips when its error rate over its last Window calls reaches
+// FailureRate, after at least MinCalls calls, or after ConsecutiveTimeouts consecutive timeouts. While open, the
+// calls fail with a CircuitOpenError without being issued. After OpenTimeout, the circuit is half-open: up to
+// HalfOpenProbes calls are let through, and close the circuit once they all succeed, or open it again on the
+// first failure.
+//
+// The timeouts are the connection timeouts, and the executions the server reports as TIMEDOUT once they exceed
+// their TIMEOUT.
+//
+// A CircuitBreaker is safe for concurrent use, and can be shared by several clients, see Client.SetCircuitBreaker.
+// The fields must be set before its first use; the zero fields take the default values, so that the zero
+// CircuitBreaker is ready to use.
+type CircuitBreaker struct {
+	Window      int
+	MinCalls    int
+	FailureRate float64
+	// ConsecutiveTimeouts is the number of consecutive timeouts tripping the circuit, negative to only trip on
+	// the error rate
+	ConsecutiveTimeouts int
+	OpenTimeout         time.Duration
+	HalfOpenProbes      int
+	// OnStateChange, when set, is invoked on every state change of a circuit, outside of the breaker lock
+	OnStateChange func(key string, from, to CircuitState)
+	// IsFailure reports whether a command error counts as a failure. By default, every error but the
+	// UnsupportedFeatureError ones counts. The TIMEDOUT replies always count
+	IsFailure func(err error) bool
+
+	mu       sync.Mutex
+	circuits map[string]*circuit
+	now      func() time.Time
+}
+
+// circuit is the state of a single key
+type circuit struct {
+	state CircuitState
+	// outcomes is the ring of the last calls, true for the failures
+	outcomes []bool
+	next     int
+	calls    int
+	failures int
+	timeouts int
+	until    time.Time
+	// probes is the number of half-open probes in flight, successes the number of successful ones
+	probes    int
+	successes int
+}
+
+// NewCircuitBreaker returns a CircuitBreaker with the default settings
+func NewCircuitBreaker() *CircuitBreaker {
+	return &CircuitBreaker{
+		Window:              DefaultBreakerWindow,
+		MinCalls:            DefaultBreakerMinCalls,
+		FailureRate:         DefaultBreakerFailureRate,
+		ConsecutiveTimeouts: DefaultBreakerConsecutiveTimeouts,
+		OpenTimeout:         DefaultBreakerOpenTimeout,
+		HalfOpenProbes:      DefaultBreakerHalfOpenProbes,
+		circuits:            map[string]*circuit{},
+		now:                 time.Now,
+	}
+}
+
+// SetCircuitBreaker makes the client issue the model, script and DAG executions through breaker.
+// The circuit of a model or script is reset when it is stored or deleted through the client.
+// On a pipelined client, the outcome of the calls is unknown: they are not accounted for, and are rejected while the
+// circuit is open or half-open.
+func (c *Client) SetCircuitBreaker(breaker *CircuitBreaker) {
+	c.breaker = breaker
+	c.OnModelChange(breaker.Reset)
+}
+
+// State returns the state of the circuit of a model or script key
+func (b *CircuitBreaker) State(key string) CircuitState {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if c, ok := b.circuits[key]; ok {
+		if c.state == CircuitOpen && !b.clock().Before(c.until) {
+			return CircuitHalfOpen
+		}
+		return c.state
+	}
+	return CircuitClosed
+}
+
+// Reset closes the circuit of a key and forgets its calls
+func (b *CircuitBreaker) Reset(key string) {
+	b.mu.Lock()
+	from := CircuitClosed
+	if c, ok := b.circuits[key]; ok {
+		from = c.state
+		delete(b.circuits, key)
+	}
+	b.mu.Unlock()
+	b.notify(key, from, CircuitClosed)
+}
+
+// executionKey returns the model, or script, key of an execution, or an empty string for the other commands
+func executionKey(info *CommandInfo) string {
+	name := strings.ToUpper(info.Name)
+	if !strings.Contains(name, "RUN") && !strings.Contains(name, "EXECUTE") {
+		return ""
+	}
+	if len(info.Model) > 0 {
+		return info.Model
+	}
+	return info.Script
+}
+
+// timedOut reports whether the reply of an execution is the TIMEDOUT status of an execution exceeding its TIMEOUT
+func timedOut(reply interface{}) bool {
+	status, ok := reply.(string)
+	return ok && status == "TIMEDOUT"
+}
+
+// allow returns a CircuitOpenError when the call to key is rejected. A probe call of a half-open circuit must
+// be followed by record, so calls whose outcome is unknown are not taken as probes
+func (b *CircuitBreaker) allow(key string, probe bool) (err error) {
+	b.mu.Lock()
+	c := b.circuit(key)
+	from := c.state
+	if c.state == CircuitOpen && !b.clock().Before(c.until) {
+		c.state, c.probes, c.successes = CircuitHalfOpen, 0, 0
+	}
+	switch {
+	case c.state == CircuitOpen:
+		err = &CircuitOpenError{Key: key, State: CircuitOpen, Until: c.until}
+	case c.state == CircuitHalfOpen && (!probe || c.probes+c.successes >= b.halfOpenProbes()):
+		err = &CircuitOpenError{Key: key, State: CircuitHalfOpen}
+	case c.state == CircuitHalfOpen:
+		c.probes++
+	}
+	to := c.state
+	b.mu.Unlock()
+	b.notify(key, from, to)
+	return err
+}
+
+// record accounts for the outcome of an allowed call to key, given its reply and error
+func (b *CircuitBreaker) record(key string, reply interface{}, err error) {
+	failure := err != nil
+	if failure && b.IsFailure != nil {
+		failure = b.IsFailure(err)
+	} else if failure {
+		_, unsupported := err.(*UnsupportedFeatureError)
+		failure = !unsupported
+	}
+	timeout := failure && ErrorClass(err) == ErrorClassTimeout || err == nil && timedOut(reply)
+	failure = failure || timeout
+	b.mu.Lock()
+	c := b.circuit(key)
+	from := c.state
+	switch c.state {
+	case CircuitHalfOpen:
+		if c.probes > 0 {
+			c.probes--
+		}
+		if failure {
+			b.open(c)
+		} else if c.successes++; c.successes >= b.halfOpenProbes() {
+			*c = circuit{}
+		}
+	case CircuitClosed:
+		c.add(b.Window, failure)
+		if timeout {
+			c.timeouts++
+		} else {
+			c.timeouts = 0
+		}
+		consecutiveTimeouts := b.ConsecutiveTimeouts
+		if consecutiveTimeouts == 0 {
+			consecutiveTimeouts = DefaultBreakerConsecutiveTimeouts
+		}
+		tripped := consecutiveTimeouts > 0 && c.timeouts >= consecutiveTimeouts
+		minCalls, failureRate := b.MinCalls, b.FailureRate
+		if minCalls <= 0 {
+			minCalls = DefaultBreakerMinCalls
+		}
+		if failureRate <= 0 {
+			failureRate = DefaultBreakerFailureRate
+		}
+		if c.calls >= minCalls && c.failures > 0 && float64(c.failures) >= failureRate*float64(c.calls) {
+			tripped = true
+		}
+		if tripped {
+			b.open(c)
+		}
+	}
+	to := c.state
+	b.mu.Unlock()
+	b.notify(key, from, to)
+}
+
+func (b *CircuitBreaker) circuit(key string) *circuit {
+	if b.circuits == nil {
+		b.circuits = map[string]*circuit{}
+	}
+	c, ok := b.circuits[key]
+	if !ok {
+		c = &circuit{}
+		b.circuits[key] = c
+	}
+	return c
+}
+
+func (b *CircuitBreaker) open(c *circuit) {
+	openTimeout := b.OpenTimeout
+	if openTimeout <= 0 {
+		openTimeout = DefaultBreakerOpenTimeout
+	}
+	*c = circuit{state: CircuitOpen, until: b.clock().Add(openTimeout)}
+}
+
+func (b *CircuitBreaker) clock() time.Time {
+	if b.now == nil {
+		return time.Now()
+	}
+	return b.now()
+}
+
+func (b *CircuitBreaker) halfOpenProbes() int {
+	if b.HalfOpenProbes <= 0 {
+		return DefaultBreakerHalfOpenProbes
+	}
+	return b.HalfOpenProbes
+}
+
+// add records an outcome in the ring of the last window calls
+func (c *circuit) add(window int, failure bool) {
+	if window <= 0 {
+		window = DefaultBreakerWindow
+	}
+	if len(c.outcomes) != window {
+		c.outcomes, c.next, c.calls, c.failures = make([]bool, window), 0, 0, 0
+	}
+	if c.calls == window {
+		if c.outcomes[c.next] {
+			c.failures--
+		}
+	} else {
+		c.calls++
+	}
+	c.outcomes[c.next] = failure
+	if failure {
+		c.failures++
+	}
+	c.next = (c.next + 1) % window
+}
+
+func (b *CircuitBreaker) notify(key string, from, to CircuitState) {
+	if from != to && b.OnStateChange != nil {
+		b.OnStateChange(key, from, to)
+	}
+}
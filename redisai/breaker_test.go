@@ -0,0 +1,185 @@
+package redisai
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/gomodule/redigo/redis"
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeBreakerClient returns a client whose model executions fail with the errors of failures, in order, then succeed
+func fakeBreakerClient(breaker *CircuitBreaker, failures ...error) (*Client, func() []string) {
+	c, conn := createFakeClient(func(cmd string, args []interface{}) (interface{}, error) {
+		if cmd == "AI.MODELEXECUTE" && len(failures) > 0 {
+			err := failures[0]
+			failures = failures[1:]
+			if err != nil {
+				return nil, err
+			}
+		}
+		return "OK", nil
+	})
+	c.SetCircuitBreaker(breaker)
+	return c, conn.CommandNames
+}
+
+type stateChange struct {
+	key      string
+	from, to CircuitState
+}
+
+func newTestBreaker(now *time.Time, changes *[]stateChange) *CircuitBreaker {
+	breaker := NewCircuitBreaker()
+	breaker.now = func() time.Time { return *now }
+	breaker.OnStateChange = func(key string, from, to CircuitState) {
+		*changes = append(*changes, stateChange{key, from, to})
+	}
+	return breaker
+}
+
+func TestCircuitBreaker_ErrorRate(t *testing.T) {
+	now := time.Unix(1000, 0)
+	var changes []stateChange
+	breaker := newTestBreaker(&now, &changes)
+	breaker.Window, breaker.MinCalls, breaker.FailureRate = 4, 4, 0.5
+	failure := redis.Error("ERR model failed")
+	c, commands := fakeBreakerClient(breaker, nil, failure, nil, failure)
+
+	for i := 0; i < 4; i++ {
+		err := c.ModelExecute("mymodel", []string{"a"}, []string{"b"})
+		assert.Equal(t, i%2 == 1, err != nil)
+	}
+	assert.Equal(t, CircuitOpen, breaker.State("mymodel"))
+	assert.Equal(t, []stateChange{{"mymodel", CircuitClosed, CircuitOpen}}, changes)
+
+	err := c.ModelExecute("mymodel", []string{"a"}, []string{"b"})
+	assert.Equal(t, &CircuitOpenError{Key: "mymodel", State: CircuitOpen, Until: now.Add(DefaultBreakerOpenTimeout)}, err)
+	assert.EqualError(t, err, "redisai: circuit open for mymodel until "+now.Add(DefaultBreakerOpenTimeout).Format(time.RFC3339))
+	assert.Len(t, commands(), 4)
+
+	// other keys and other commands are not affected
+	assert.Nil(t, c.ModelExecute("othermodel", []string{"a"}, []string{"b"}))
+	assert.Nil(t, c.TensorSet("a", TypeFloat32, []int64{1}, []float32{1}))
+	assert.Equal(t, CircuitClosed, breaker.State("othermodel"))
+}
+
+func TestCircuitBreaker_HalfOpen(t *testing.T) {
+	now := time.Unix(1000, 0)
+	var changes []stateChange
+	breaker := newTestBreaker(&now, &changes)
+	timeout := &timeoutError{}
+	c, commands := fakeBreakerClient(breaker, timeout, timeout, timeout, timeout)
+
+	for i := 0; i < DefaultBreakerConsecutiveTimeouts; i++ {
+		assert.Equal(t, timeout, c.ModelExecute("mymodel", []string{"a"}, []string{"b"}))
+	}
+	assert.Equal(t, CircuitOpen, breaker.State("mymodel"))
+
+	// the failed probe opens the circuit again
+	now = now.Add(DefaultBreakerOpenTimeout)
+	assert.Equal(t, CircuitHalfOpen, breaker.State("mymodel"))
+	assert.Equal(t, timeout, c.ModelExecute("mymodel", []string{"a"}, []string{"b"}))
+	assert.Equal(t, CircuitOpen, breaker.State("mymodel"))
+
+	// the successful probe closes it
+	now = now.Add(DefaultBreakerOpenTimeout)
+	assert.Nil(t, c.ModelExecute("mymodel", []string{"a"}, []string{"b"}))
+	assert.Equal(t, CircuitClosed, breaker.State("mymodel"))
+	assert.Len(t, commands(), 5)
+	assert.Equal(t, []stateChange{
+		{"mymodel", CircuitClosed, CircuitOpen},
+		{"mymodel", CircuitOpen, CircuitHalfOpen},
+		{"mymodel", CircuitHalfOpen, CircuitOpen},
+		{"mymodel", CircuitOpen, CircuitHalfOpen},
+		{"mymodel", CircuitHalfOpen, CircuitClosed},
+	}, changes)
+}
+
+func TestCircuitBreaker_HalfOpenProbes(t *testing.T) {
+	now := time.Unix(1000, 0)
+	var changes []stateChange
+	breaker := newTestBreaker(&now, &changes)
+	breaker.HalfOpenProbes = 2
+	breaker.circuits["mymodel"] = &circuit{state: CircuitOpen, until: now}
+
+	assert.Nil(t, breaker.allow("mymodel", true))
+	assert.Nil(t, breaker.allow("mymodel", true))
+	assert.Equal(t, &CircuitOpenError{Key: "mymodel", State: CircuitHalfOpen}, breaker.allow("mymodel", true))
+	breaker.record("mymodel", "OK", nil)
+	assert.Equal(t, CircuitHalfOpen, breaker.State("mymodel"))
+	assert.NotNil(t, breaker.allow("mymodel", false))
+	breaker.record("mymodel", "OK", nil)
+	assert.Equal(t, CircuitClosed, breaker.State("mymodel"))
+}
+
+func TestCircuitBreaker_TimedOut(t *testing.T) {
+	now := time.Unix(1000, 0)
+	var changes []stateChange
+	breaker := newTestBreaker(&now, &changes)
+	c, conn := createFakeClient(func(cmd string, args []interface{}) (interface{}, error) {
+		return "TIMEDOUT", nil
+	})
+	c.SetCircuitBreaker(breaker)
+	for i := 0; i < DefaultBreakerConsecutiveTimeouts; i++ {
+		assert.Nil(t, c.ModelExecuteWithTimeout("mymodel", []string{"a"}, []string{"b"}, 100))
+	}
+	assert.Equal(t, CircuitOpen, breaker.State("mymodel"))
+	assert.IsType(t, &CircuitOpenError{}, c.ModelExecuteWithTimeout("mymodel", []string{"a"}, []string{"b"}, 100))
+	assert.Len(t, conn.Commands(), DefaultBreakerConsecutiveTimeouts)
+}
+
+func TestCircuitBreaker_Zero(t *testing.T) {
+	breaker := &CircuitBreaker{}
+	timeout := &timeoutError{}
+	c, commands := fakeBreakerClient(breaker, timeout, timeout, timeout)
+	for i := 0; i < DefaultBreakerConsecutiveTimeouts; i++ {
+		assert.Equal(t, timeout, c.ModelExecute("mymodel", []string{"a"}, []string{"b"}))
+	}
+	assert.Equal(t, CircuitOpen, breaker.State("mymodel"))
+	err := c.ModelExecute("mymodel", []string{"a"}, []string{"b"})
+	assert.IsType(t, &CircuitOpenError{}, err)
+	assert.WithinDuration(t, time.Now().Add(DefaultBreakerOpenTimeout), err.(*CircuitOpenError).Until, time.Second)
+	assert.Len(t, commands(), DefaultBreakerConsecutiveTimeouts)
+	assert.Equal(t, CircuitClosed, (&CircuitBreaker{}).State("mymodel"))
+}
+
+func TestCircuitBreaker_Reset(t *testing.T) {
+	now := time.Unix(1000, 0)
+	var changes []stateChange
+	breaker := newTestBreaker(&now, &changes)
+	breaker.IsFailure = func(err error) bool { return err != errNotFailure }
+	c, _ := fakeBreakerClient(breaker, errNotFailure, errNotFailure, errNotFailure)
+	for i := 0; i < 3; i++ {
+		assert.NotNil(t, c.ModelExecute("mymodel", []string{"a"}, []string{"b"}))
+	}
+	assert.Equal(t, CircuitClosed, breaker.State("mymodel"))
+
+	breaker.circuits["mymodel"] = &circuit{state: CircuitOpen, until: now.Add(time.Minute)}
+	assert.Nil(t, c.ModelStore("mymodel", BackendTF, DeviceCPU, "", 0, 0, 0, nil, nil, []byte("blob")))
+	assert.Equal(t, CircuitClosed, breaker.State("mymodel"))
+	assert.Equal(t, []stateChange{{"mymodel", CircuitOpen, CircuitClosed}}, changes)
+}
+
+var errNotFailure = errors.New("not a failure")
+
+func TestCircuitBreaker_Key(t *testing.T) {
+	tests := []struct {
+		name string
+		info CommandInfo
+		want string
+	}{
+		{"model-execute", CommandInfo{Name: "AI.MODELEXECUTE", Model: "m"}, "m"},
+		{"script-run", CommandInfo{Name: "AI.SCRIPTRUN", Script: "s"}, "s"},
+		{"dag", CommandInfo{Name: "AI.DAGEXECUTE_RO", Model: "m", Script: "s"}, "m"},
+		{"dag-without-model", CommandInfo{Name: "AI.DAGRUN", Script: "s"}, "s"},
+		{"model-get", CommandInfo{Name: "AI.MODELGET", Model: "m"}, ""},
+		{"tensor", CommandInfo{Name: "AI.TENSORSET"}, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, executionKey(&tt.info))
+		})
+	}
+}
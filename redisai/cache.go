@@ -0,0 +1,339 @@
+package redisai
+
+import (
+	"container/list"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/gomodule/redigo/redis"
+)
+
+// PredictionCacheStore is an interface that represents the storage of cached predictions.
+// Entries are addressed by the model or script key and by the digest of the prediction inputs.
+type PredictionCacheStore interface {
+	// Get returns the cached outputs, and whether they were found
+	Get(modelKey, digest string, outputs []string) (map[string]TensorInterface, bool, error)
+	Set(modelKey, digest string, outputs map[string]TensorInterface) error
+	// Invalidate discards every entry of the model or script key
+	Invalidate(modelKey string) error
+}
+
+// PredictionCache serves the predictions of a Predictor from a PredictionCacheStore when the same inputs
+// were already seen for the same model version.
+//
+// The cache entries are keyed by a SHA-256 digest of the model key, the model tag, the script entry point and
+// every input name, type, shape and content. When Tag is empty the tag is read from the server with
+// AI.MODELGET (or AI.SCRIPTGET for scripts) META on first use.
+//
+// The entries of a model or script are invalidated automatically when it is stored or deleted through the
+// Predictor's Client. Invalidate must be called for changes made by other clients that keep the same tag.
+type PredictionCache struct {
+	Predictor *Predictor
+	Store     PredictionCacheStore
+	// Tag is the model or script version that the cache entries belong to
+	Tag string
+
+	mu        sync.Mutex
+	tag       string
+	tagLoaded bool
+}
+
+// NewPredictionCache returns a PredictionCache in front of predictor, and registers its invalidation on the predictor's Client
+func NewPredictionCache(predictor *Predictor, store PredictionCacheStore) *PredictionCache {
+	pc := &PredictionCache{
+		Predictor: predictor,
+		Store:     store,
+	}
+	predictor.Client.OnModelChange(func(keyName string) {
+		if keyName == predictor.Key {
+			_ = pc.Invalidate()
+		}
+	})
+	return pc
+}
+
+// Predict returns the cached outputs for the inputs if present, and otherwise runs the prediction and caches its outputs
+func (pc *PredictionCache) Predict(inputs map[string]TensorInterface) (map[string]TensorInterface, error) {
+	tag, err := pc.loadTag()
+	if err != nil {
+		return nil, err
+	}
+	digest, err := PredictionDigest(pc.Predictor.Key, tag, pc.Predictor.Function, inputs)
+	if err != nil {
+		return nil, err
+	}
+	outputs, found, err := pc.Store.Get(pc.Predictor.Key, digest, pc.Predictor.Outputs)
+	if err != nil || found {
+		return outputs, err
+	}
+	outputs, err = pc.Predictor.Predict(inputs)
+	if err != nil {
+		return nil, err
+	}
+	err = pc.Store.Set(pc.Predictor.Key, digest, outputs)
+	return outputs, err
+}
+
+// Invalidate discards the cached predictions, and the tag read from the server
+func (pc *PredictionCache) Invalidate() error {
+	pc.mu.Lock()
+	pc.tagLoaded = false
+	pc.mu.Unlock()
+	return pc.Store.Invalidate(pc.Predictor.Key)
+}
+
+func (pc *PredictionCache) loadTag() (string, error) {
+	if len(pc.Tag) > 0 {
+		return pc.Tag, nil
+	}
+	pc.mu.Lock()
+	defer pc.mu.Unlock()
+	if pc.tagLoaded {
+		return pc.tag, nil
+	}
+	var tag string
+	if len(pc.Predictor.Function) > 0 {
+		meta, err := pc.Predictor.Client.ScriptGetMeta(pc.Predictor.Key)
+		if err != nil {
+			return "", err
+		}
+		tag = meta.Tag
+	} else {
+		meta, err := pc.Predictor.Client.ModelGetMeta(pc.Predictor.Key)
+		if err != nil {
+			return "", err
+		}
+		tag = meta.Tag
+	}
+	pc.tag = tag
+	pc.tagLoaded = true
+	return tag, nil
+}
+
+// PredictionDigest returns the hex encoded SHA-256 digest identifying a prediction
+func PredictionDigest(modelKey, tag, function string, inputs map[string]TensorInterface) (string, error) {
+	h := sha256.New()
+	digestWriteString(h, modelKey)
+	digestWriteString(h, tag)
+	digestWriteString(h, function)
+	names := make([]string, 0, len(inputs))
+	for name := range inputs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		tensor := inputs[name]
+		typestr, err := TensorGetTypeStrFromType(tensor.Dtype())
+		if err != nil {
+			return "", err
+		}
+		digestWriteString(h, name)
+		digestWriteString(h, typestr)
+		_ = binary.Write(h, binary.LittleEndian, int64(len(tensor.Shape())))
+		_ = binary.Write(h, binary.LittleEndian, tensor.Shape())
+		switch data := tensor.Data().(type) {
+		case []int:
+			for _, v := range data {
+				_ = binary.Write(h, binary.LittleEndian, int64(v))
+			}
+		case []uint:
+			for _, v := range data {
+				_ = binary.Write(h, binary.LittleEndian, uint64(v))
+			}
+		default:
+			if err := binary.Write(h, binary.LittleEndian, data); err != nil {
+				return "", fmt.Errorf("redisai.PredictionDigest: can't hash input tensor %s: %v", name, err)
+			}
+		}
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+func digestWriteString(h hash.Hash, s string) {
+	_ = binary.Write(h, binary.LittleEndian, int64(len(s)))
+	h.Write([]byte(s))
+}
+
+type lruCacheEntry struct {
+	modelKey string
+	digest   string
+	outputs  map[string]TensorInterface
+	expires  time.Time
+}
+
+// LRUCacheStore is an in-process PredictionCacheStore bounded in number of entries and in entry age.
+// It is safe for concurrent use.
+type LRUCacheStore struct {
+	MaxEntries int
+	// TTL is the maximum age of an entry. Zero means entries don't expire
+	TTL time.Duration
+
+	mu      sync.Mutex
+	entries map[string]*list.Element
+	order   *list.List
+}
+
+// NewLRUCacheStore returns an empty LRUCacheStore
+func NewLRUCacheStore(maxEntries int, ttl time.Duration) *LRUCacheStore {
+	return &LRUCacheStore{
+		MaxEntries: maxEntries,
+		TTL:        ttl,
+		entries:    make(map[string]*list.Element),
+		order:      list.New(),
+	}
+}
+
+func (s *LRUCacheStore) Get(modelKey, digest string, outputs []string) (map[string]TensorInterface, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	element, ok := s.entries[modelKey+"\x00"+digest]
+	if !ok {
+		return nil, false, nil
+	}
+	entry := element.Value.(*lruCacheEntry)
+	if s.TTL > 0 && time.Now().After(entry.expires) {
+		s.remove(element)
+		return nil, false, nil
+	}
+	s.order.MoveToFront(element)
+	return entry.outputs, true, nil
+}
+
+func (s *LRUCacheStore) Set(modelKey, digest string, outputs map[string]TensorInterface) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	entry := &lruCacheEntry{modelKey: modelKey, digest: digest, outputs: outputs, expires: time.Now().Add(s.TTL)}
+	if element, ok := s.entries[modelKey+"\x00"+digest]; ok {
+		element.Value = entry
+		s.order.MoveToFront(element)
+		return nil
+	}
+	s.entries[modelKey+"\x00"+digest] = s.order.PushFront(entry)
+	for s.MaxEntries > 0 && s.order.Len() > s.MaxEntries {
+		s.remove(s.order.Back())
+	}
+	return nil
+}
+
+func (s *LRUCacheStore) Invalidate(modelKey string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for element := s.order.Front(); element != nil; {
+		next := element.Next()
+		if element.Value.(*lruCacheEntry).modelKey == modelKey {
+			s.remove(element)
+		}
+		element = next
+	}
+	return nil
+}
+
+// Len returns the number of entries in the store, including the expired ones not yet evicted
+func (s *LRUCacheStore) Len() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.order.Len()
+}
+
+func (s *LRUCacheStore) remove(element *list.Element) {
+	entry := s.order.Remove(element).(*lruCacheEntry)
+	delete(s.entries, entry.modelKey+"\x00"+entry.digest)
+}
+
+// RedisCacheStore is a PredictionCacheStore keeping the cached outputs as tensors in RedisAI, so that they
+// are shared across clients.
+//
+// The entries of a model are stored under the model's hash tag and expire after TTL. Invalidating a model
+// increments a generation counter stored along with the entries, so that the previous entries are never
+// read again and are left to expire. The counter expires after TTL as well once no entry is added, by which
+// time the entries it numbers expired.
+type RedisCacheStore struct {
+	Client *Client
+	// Prefix is prepended to the keys of the entries, after the hash tag
+	Prefix string
+	TTL    time.Duration
+}
+
+// NewRedisCacheStore returns a RedisCacheStore using client
+func NewRedisCacheStore(client *Client, prefix string, ttl time.Duration) *RedisCacheStore {
+	return &RedisCacheStore{Client: client, Prefix: prefix, TTL: ttl}
+}
+
+func (s *RedisCacheStore) generationKey(modelKey string) string {
+	return fmt.Sprintf("{%s}:%s:generation:%s", HashTag(modelKey), s.Prefix, modelKey)
+}
+
+func (s *RedisCacheStore) entryKey(modelKey string, generation int64, digest, output string) string {
+	return fmt.Sprintf("{%s}:%s:%s:%d:%s:%s", HashTag(modelKey), s.Prefix, modelKey, generation, digest, output)
+}
+
+func (s *RedisCacheStore) generation(modelKey string) (int64, error) {
+	generation, err := redis.Int64(s.Client.DoOrSend("GET", redis.Args{s.generationKey(modelKey)}, nil))
+	if err == redis.ErrNil {
+		return 0, nil
+	}
+	return generation, err
+}
+
+func (s *RedisCacheStore) Get(modelKey, digest string, outputs []string) (map[string]TensorInterface, bool, error) {
+	generation, err := s.generation(modelKey)
+	if err != nil {
+		return nil, false, err
+	}
+	tensors := make(map[string]TensorInterface, len(outputs))
+	for _, output := range outputs {
+		reply, err := s.Client.DoOrSend("AI.TENSORGET", redis.Args{s.entryKey(modelKey, generation, digest, output), TensorContentTypeMeta, TensorContentTypeValues}, nil)
+		if _, ok := err.(redis.Error); ok {
+			// missing tensors are replied with an error
+			return nil, false, nil
+		}
+		tensor, err := predictorParseTensor(reply, err)
+		if err != nil {
+			return nil, false, err
+		}
+		tensors[output] = tensor
+	}
+	return tensors, true, nil
+}
+
+func (s *RedisCacheStore) Set(modelKey, digest string, outputs map[string]TensorInterface) error {
+	generation, err := s.generation(modelKey)
+	if err != nil {
+		return err
+	}
+	// the entries are stored along with their TTL, so that they never outlive it
+	return s.Client.multi(func() error {
+		for output, tensor := range outputs {
+			key := s.entryKey(modelKey, generation, digest, output)
+			if err := s.Client.TensorSetFromTensor(key, tensor); err != nil {
+				return err
+			}
+			if err := s.expire(key); err != nil {
+				return err
+			}
+		}
+		return s.expire(s.generationKey(modelKey))
+	})
+}
+
+func (s *RedisCacheStore) Invalidate(modelKey string) error {
+	return s.Client.multi(func() error {
+		if _, err := s.Client.DoOrSend("INCR", redis.Args{s.generationKey(modelKey)}, nil); err != nil {
+			return err
+		}
+		return s.expire(s.generationKey(modelKey))
+	})
+}
+
+func (s *RedisCacheStore) expire(key string) (err error) {
+	if s.TTL > 0 {
+		_, err = s.Client.DoOrSend("PEXPIRE", redis.Args{key, int64(s.TTL / time.Millisecond)}, nil)
+	}
+	return
+}
@@ -0,0 +1,179 @@
+package redisai
+
+import (
+	"testing"
+	"time"
+
+	"github.com/RedisAI/redisai-go/redisai/implementations"
+	"github.com/gomodule/redigo/redis"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPredictionDigest(t *testing.T) {
+	inputs := func(data interface{}) map[string]TensorInterface {
+		return map[string]TensorInterface{"a": implementations.NewAiTensorWithData("", []int64{2}, data)}
+	}
+	digest, err := PredictionDigest("mymodel", "v1", "", inputs([]float32{1, 2}))
+	assert.Nil(t, err)
+	same, err := PredictionDigest("mymodel", "v1", "", inputs([]float32{1, 2}))
+	assert.Nil(t, err)
+	assert.Equal(t, digest, same)
+
+	for _, other := range []struct {
+		modelKey, tag string
+		data          interface{}
+	}{
+		{"othermodel", "v1", []float32{1, 2}},
+		{"mymodel", "v2", []float32{1, 2}},
+		{"mymodel", "v1", []float32{1, 3}},
+		{"mymodel", "v1", []float64{1, 2}},
+		{"mymodel", "v1", []int{1, 2}},
+	} {
+		otherDigest, err := PredictionDigest(other.modelKey, other.tag, "", inputs(other.data))
+		assert.Nil(t, err)
+		assert.NotEqual(t, digest, otherDigest)
+	}
+	_, err = PredictionDigest("mymodel", "v1", "", inputs([]uint32{1, 2}))
+	assert.NotNil(t, err)
+}
+
+func TestLRUCacheStore(t *testing.T) {
+	outputs := map[string]TensorInterface{"b": implementations.NewAiTensorWithData("", []int64{1}, []float32{1})}
+	s := NewLRUCacheStore(2, time.Minute)
+	assert.Nil(t, s.Set("m1", "d1", outputs))
+	assert.Nil(t, s.Set("m1", "d2", outputs))
+	_, found, _ := s.Get("m1", "d1", nil)
+	assert.True(t, found)
+	// d2 is the least recently used entry
+	assert.Nil(t, s.Set("m2", "d1", outputs))
+	_, found, _ = s.Get("m1", "d2", nil)
+	assert.False(t, found)
+	assert.Equal(t, 2, s.Len())
+
+	assert.Nil(t, s.Invalidate("m1"))
+	_, found, _ = s.Get("m1", "d1", nil)
+	assert.False(t, found)
+	_, found, _ = s.Get("m2", "d1", nil)
+	assert.True(t, found)
+
+	s.TTL = time.Nanosecond
+	assert.Nil(t, s.Set("m3", "d1", outputs))
+	time.Sleep(time.Millisecond)
+	_, found, _ = s.Get("m3", "d1", nil)
+	assert.False(t, found)
+}
+
+func TestPredictionCache_Predict(t *testing.T) {
+	reply := []interface{}{[]byte("dtype"), []byte(TypeInt64), []byte("shape"), []interface{}{int64(1)}, []byte("values"), []interface{}{int64(7)}}
+	c, conn := createFakeClient(func(cmd string, args []interface{}) (interface{}, error) {
+		switch cmd {
+		case "AI.MODELGET":
+			return []interface{}{[]byte("tag"), []byte("v1")}, nil
+		case "AI.DAGEXECUTE":
+			return []interface{}{"OK", "OK", reply}, nil
+		}
+		return "OK", nil
+	})
+	pc := NewPredictionCache(NewModelPredictor(c, "mymodel", []string{"a"}, []string{"b"}), NewLRUCacheStore(10, 0))
+	inputs := map[string]TensorInterface{"a": implementations.NewAiTensorWithData("", []int64{1}, []int64{1})}
+	for i := 0; i < 3; i++ {
+		outputs, err := pc.Predict(inputs)
+		assert.Nil(t, err)
+		assert.Equal(t, []int64{7}, outputs["b"].Data())
+	}
+	assert.Equal(t, []string{"AI.MODELGET", "AI.DAGEXECUTE"}, conn.CommandNames())
+
+	// storing the model through the client invalidates the cache and the tag
+	assert.Nil(t, c.ModelStore("othermodel", BackendTF, DeviceCPU, "", 0, 0, 0, nil, nil, []byte{}))
+	_, err := pc.Predict(inputs)
+	assert.Nil(t, err)
+	assert.Equal(t, 3, len(conn.Commands()))
+	assert.Nil(t, c.ModelDel("mymodel"))
+	_, err = pc.Predict(inputs)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"AI.MODELGET", "AI.DAGEXECUTE", "AI.MODELSTORE", "AI.MODELDEL", "AI.MODELGET", "AI.DAGEXECUTE"}, conn.CommandNames())
+}
+
+func TestPredictionCache_Predict_Script(t *testing.T) {
+	reply := []interface{}{[]byte("dtype"), []byte(TypeInt64), []byte("shape"), []interface{}{int64(1)}, []byte("values"), []interface{}{int64(7)}}
+	c, conn := createFakeClient(func(cmd string, args []interface{}) (interface{}, error) {
+		switch cmd {
+		case "AI.SCRIPTGET":
+			return []interface{}{[]byte("tag"), []byte("v1")}, nil
+		case "AI.DAGEXECUTE":
+			return []interface{}{"OK", "OK", reply}, nil
+		}
+		return "OK", nil
+	})
+	pc := NewPredictionCache(NewScriptPredictor(c, "myscript", "bar", []string{"a"}, []string{"b"}), NewLRUCacheStore(10, 0))
+	inputs := map[string]TensorInterface{"a": implementations.NewAiTensorWithData("", []int64{1}, []int64{1})}
+	_, err := pc.Predict(inputs)
+	assert.Nil(t, err)
+
+	// replacing the script through the client invalidates the cache
+	assert.Nil(t, c.ScriptStore("myscript", DeviceCPU, "def bar(a): return a", []string{"bar"}))
+	_, err = pc.Predict(inputs)
+	assert.Nil(t, err)
+	assert.Nil(t, c.ScriptDel("myscript"))
+	_, err = pc.Predict(inputs)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"AI.SCRIPTGET", "AI.DAGEXECUTE", "AI.SCRIPTSTORE", "AI.SCRIPTGET", "AI.DAGEXECUTE",
+		"AI.SCRIPTDEL", "AI.SCRIPTGET", "AI.DAGEXECUTE"}, conn.CommandNames())
+}
+
+func TestRedisCacheStore(t *testing.T) {
+	reply := []interface{}{[]byte("dtype"), []byte(TypeInt64), []byte("shape"), []interface{}{int64(1)}, []byte("values"), []interface{}{int64(7)}}
+	tensors := map[string]bool{}
+	c, conn := createFakeClient(func(cmd string, args []interface{}) (interface{}, error) {
+		switch cmd {
+		case "GET":
+			return int64(3), nil
+		case "AI.TENSORSET":
+			tensors[args[0].(string)] = true
+		case "AI.TENSORGET":
+			if !tensors[args[0].(string)] {
+				return nil, redis.Error("ERR tensor key is empty")
+			}
+			return reply, nil
+		case "EXEC":
+			return []interface{}{"OK", int64(1), int64(0)}, nil
+		}
+		return "OK", nil
+	})
+	s := NewRedisCacheStore(c, "cache", time.Minute)
+	_, found, err := s.Get("mymodel{1}", "d1", []string{"b"})
+	assert.Nil(t, err)
+	assert.False(t, found)
+	assert.Nil(t, s.Set("mymodel{1}", "d1", map[string]TensorInterface{"b": implementations.NewAiTensorWithData("", []int64{1}, []int64{7})}))
+	outputs, found, err := s.Get("mymodel{1}", "d1", []string{"b"})
+	assert.Nil(t, err)
+	assert.True(t, found)
+	assert.Equal(t, []int64{7}, outputs["b"].Data())
+	assert.Nil(t, s.Invalidate("mymodel{1}"))
+	// the entries and the generation are given their TTL within the transactions
+	assert.Equal(t, []string{"GET", "AI.TENSORGET", "GET", "MULTI", "AI.TENSORSET", "PEXPIRE", "PEXPIRE", "EXEC",
+		"GET", "AI.TENSORGET", "MULTI", "INCR", "PEXPIRE", "EXEC"}, conn.CommandNames())
+	commands := conn.Commands()
+	assert.Equal(t, []interface{}{"PEXPIRE", "{1}:cache:mymodel{1}:3:d1:b", int64(60000)}, commands[5])
+	assert.Equal(t, []interface{}{"PEXPIRE", "{1}:cache:generation:mymodel{1}", int64(60000)}, commands[6])
+	assert.Equal(t, []interface{}{"INCR", "{1}:cache:generation:mymodel{1}"}, commands[11])
+}
+
+func TestRedisCacheStore_Set_Error(t *testing.T) {
+	c, conn := createFakeClient(func(cmd string, args []interface{}) (interface{}, error) {
+		switch cmd {
+		case "GET":
+			return nil, nil
+		case "EXEC":
+			return []interface{}{redis.Error("ERR wrong type"), int64(1), int64(0)}, nil
+		}
+		return "OK", nil
+	})
+	s := NewRedisCacheStore(c, "cache", time.Minute)
+	assert.Equal(t, redis.Error("ERR wrong type"), s.Set("mymodel", "d1", map[string]TensorInterface{"b": implementations.NewAiTensorWithData("", []int64{1}, []int64{7})}))
+
+	// a tensor that can't be encoded discards the transaction
+	assert.NotNil(t, s.Set("mymodel", "d1", map[string]TensorInterface{"b": implementations.NewAiTensorWithData("", []int64{1}, "data")}))
+	names := conn.CommandNames()
+	assert.Equal(t, []string{"GET", "MULTI", "DISCARD"}, names[len(names)-3:])
+}
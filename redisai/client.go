@@ -1,6 +1,7 @@
 package redisai
 
 import (
+	"context"
 	"github.com/gomodule/redigo/redis"
 	"sync/atomic"
 	"time"
@@ -13,6 +14,8 @@ const (
 	BackendTorch = string("TORCH")
 	// BackendONNX represents an ONNX backend
 	BackendONNX = string("ORT")
+	// BackendTFLite represents a TensorFlow Lite backend
+	BackendTFLite = string("TFLITE")
 
 	// DeviceCPU represents a CPU device
 	DeviceCPU = string("CPU")
@@ -63,6 +66,21 @@ type Client struct {
 	PipelineAutoFlushSize uint32
 	PipelinePos           uint32
 	ActiveConn            redis.Conn
+
+	// modelChangeHooks are invoked with the key of every model or script stored or deleted through the client
+	modelChangeHooks []func(keyName string)
+
+	// detectCapabilities enables the choice of the commands from the server capabilities, detected on first use
+	detectCapabilities bool
+	capabilities       *ServerCapabilities
+
+	// hooks are invoked before and after every command, see AddHook
+	hooks   []CommandHook
+	ctx     context.Context
+	breaker *CircuitBreaker
+	// admission admits the executions of the client in its admissionClass, see SetAdmissionControl
+	admission      *AdmissionController
+	admissionClass *admissionClass
 }
 
 // Connect establish an connection to the RedisAI Server.
@@ -164,11 +182,21 @@ func (c *Client) pipeIncr(conn redis.Conn) (err error) {
 }
 
 func (c *Client) DoOrSend(cmdName string, args redis.Args, errIn error) (reply interface{}, err error) {
-	err = errIn
-	if err != nil {
-		return
+	if errIn != nil {
+		// the commands that could not be built are not issued, but still reported to the hooks
+		if len(c.hooks) > 0 {
+			return c.doWithHooks(cmdName, args, errIn)
+		}
+		return nil, errIn
 	}
 	c.ActiveConnNX()
+	if len(c.hooks) > 0 || c.breaker != nil || c.admission != nil {
+		return c.doWithHooks(cmdName, args, nil)
+	}
+	return c.do(cmdName, args)
+}
+
+func (c *Client) do(cmdName string, args redis.Args) (reply interface{}, err error) {
 	if c.PipelineActive {
 		err = c.SendAndIncr(cmdName, args)
 	} else {
@@ -176,3 +204,44 @@ func (c *Client) DoOrSend(cmdName string, args redis.Args, errIn error) (reply i
 	}
 	return reply, err
 }
+
+// multi issues the commands of issue in a MULTI/EXEC transaction, so that they are applied all together or not at
+// all, and returns the first error replied by EXEC. On a pipelined client, the replies are left to Receive
+func (c *Client) multi(issue func() error) error {
+	if _, err := c.DoOrSend("MULTI", nil, nil); err != nil {
+		return err
+	}
+	if err := issue(); err != nil {
+		_, _ = c.DoOrSend("DISCARD", nil, nil)
+		return err
+	}
+	reply, err := c.DoOrSend("EXEC", nil, nil)
+	if c.PipelineActive || err != nil {
+		return err
+	}
+	replies, err := redis.Values(reply, nil)
+	if err != nil {
+		return err
+	}
+	for _, reply := range replies {
+		if replyErr, ok := reply.(redis.Error); ok {
+			return replyErr
+		}
+	}
+	return nil
+}
+
+// OnModelChange registers a function to be invoked with the key of every model or script stored or deleted through
+// the client
+func (c *Client) OnModelChange(hook func(keyName string)) {
+	c.modelChangeHooks = append(c.modelChangeHooks, hook)
+}
+
+func (c *Client) modelChanged(keyName string, err error) {
+	if err != nil {
+		return
+	}
+	for _, hook := range c.modelChangeHooks {
+		hook(keyName)
+	}
+}
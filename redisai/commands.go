@@ -4,6 +4,7 @@ import (
 	"errors"
 	"fmt"
 	"github.com/gomodule/redigo/redis"
+	"io"
 	"strconv"
 )
 
@@ -83,38 +84,42 @@ func (c *Client) TensorGetBlob(name string) (dt string, shape []int64, data []by
 
 // ModelSet sets a RedisAI model from a blob
 func (c *Client) ModelSet(keyName, backend, device string, data []byte, inputs, outputs []string) (err error) {
-	args, _ := modelStoreFlatArgs(keyName, backend, device, "", 0, 0, 0, inputs, outputs, data)
-	_, err = c.DoOrSend("AI.MODELSTORE", args, nil)
+	cmd, args, err := c.modelStoreCommand(keyName, backend, device, "", 0, 0, 0, inputs, outputs, data)
+	_, err = c.DoOrSend(cmd, args, err)
+	c.modelChanged(keyName, err)
 	return
 }
 
 // ModelSet sets a RedisAI model from a structure that implements the ModelInterface
 func (c *Client) ModelSetFromModel(keyName string, model ModelInterface) (err error) {
-	args, err := modelStoreInterfaceArgs(keyName, model)
+	cmd, args, err := c.modelStoreInterfaceCommand(keyName, model)
 	if err != nil {
 		return
 	}
-	_, err = c.DoOrSend("AI.MODELSTORE", args, nil)
+	_, err = c.DoOrSend(cmd, args, nil)
+	c.modelChanged(keyName, err)
 	return
 }
 
 // ModelStore sets a RedisAI model from a blob
 func (c *Client) ModelStore(keyName, backend, device, tag string, batchsize, minbatchsize, minbatchtimeout int64, inputs, outputs []string, data []byte) (err error) {
-	args, err := modelStoreFlatArgs(keyName, backend, device, tag, batchsize, minbatchsize, minbatchtimeout, inputs, outputs, data)
+	cmd, args, err := c.modelStoreCommand(keyName, backend, device, tag, batchsize, minbatchsize, minbatchtimeout, inputs, outputs, data)
 	if err != nil {
 		return
 	}
-	_, err = c.DoOrSend("AI.MODELSTORE", args, nil)
+	_, err = c.DoOrSend(cmd, args, nil)
+	c.modelChanged(keyName, err)
 	return
 }
 
 // ModelStoreFromModel sets a RedisAI model from a structure that implements the ModelInterface
 func (c *Client) ModelStoreFromModel(keyName string, model ModelInterface) (err error) {
-	args, err := modelStoreInterfaceArgs(keyName, model)
+	cmd, args, err := c.modelStoreInterfaceCommand(keyName, model)
 	if err != nil {
 		return
 	}
-	_, err = c.DoOrSend("AI.MODELSTORE", args, nil)
+	_, err = c.DoOrSend(cmd, args, nil)
+	c.modelChanged(keyName, err)
 	return
 }
 
@@ -153,72 +158,152 @@ func (c *Client) ModelGetToModel(keyName string, modelIn ModelInterface) (err er
 	return
 }
 
+// ModelGetMeta gets the metadata of a RedisAI model, without transferring its blob
+func (c *Client) ModelGetMeta(keyName string) (meta ModelMeta, err error) {
+	reply, err := c.DoOrSend("AI.MODELGET", redis.Args{keyName, "META"}, nil)
+	if err != nil || reply == nil {
+		return
+	}
+	return modelGetMetaParseReply(reply)
+}
+
+// ModelGetBlob writes the blob of a RedisAI model to w, and returns the number of bytes written.
+// Only the blob is requested, with AI.MODELGET key BLOB. When the server splits the blob in chunks, each chunk is
+// written to w in turn and released once written, so that the blob is never copied into a single buffer as
+// ModelGet does. The client reads every reply as a whole, so the chunks are written once all of them are received.
+func (c *Client) ModelGetBlob(keyName string, w io.Writer) (n int64, err error) {
+	if c.PipelineActive {
+		return 0, errors.New("redisai.ModelGetBlob: the blob can't be written on a pipelined client")
+	}
+	reply, err := c.DoOrSend("AI.MODELGET", redis.Args{keyName, "BLOB"}, nil)
+	if err != nil {
+		return
+	}
+	chunks, ok := reply.([]interface{})
+	if !ok {
+		chunks = []interface{}{reply}
+	}
+	return writeModelBlobChunks(w, chunks)
+}
+
+// ModelStoreFromModelIfChanged stores the model unless the server already holds the same content at keyName,
+// and reports whether it was uploaded. The content hash of the model is recorded in the companion key
+// ModelHashKey(keyName) when uploaded, and compared with the model's hash on the next calls.
+//
+// The hash is not updated when the model is stored or deleted by other means: force uploads the model
+// regardless of the recorded hash.
+func (c *Client) ModelStoreFromModelIfChanged(keyName string, model ModelInterface, force bool) (stored bool, err error) {
+	if c.PipelineActive {
+		return false, errors.New("redisai.ModelStoreFromModelIfChanged: the recorded hash can't be read on a pipelined client")
+	}
+	hash, err := ModelContentHash(model)
+	if err != nil {
+		return
+	}
+	hashKey := ModelHashKey(keyName)
+	if !force {
+		recorded, errGet := redis.String(c.DoOrSend("GET", redis.Args{hashKey}, nil))
+		if errGet != nil && errGet != redis.ErrNil {
+			return false, errGet
+		}
+		if recorded == hash {
+			var exists bool
+			if exists, err = redis.Bool(c.DoOrSend("EXISTS", redis.Args{keyName}, nil)); err != nil || exists {
+				return
+			}
+		}
+	}
+	if err = c.ModelStoreFromModel(keyName, model); err != nil {
+		return
+	}
+	_, err = c.DoOrSend("SET", redis.Args{hashKey, hash}, nil)
+	return true, err
+}
+
 func (c *Client) ModelDel(keyName string) (err error) {
 	args := modelDelFlatArgs(keyName)
 	_, err = c.DoOrSend("AI.MODELDEL", args, nil)
+	c.modelChanged(keyName, err)
 	return
 }
 
 // ModelRun runs the model present in the keyName, with the input tensor names, and output tensor names
 func (c *Client) ModelRun(name string, inputs, outputs []string) (err error) {
-	args := modelExecuteFlatArgs(name, inputs, outputs, 0)
-	_, err = c.DoOrSend("AI.MODELEXECUTE", args, nil)
+	cmd, args, err := c.modelExecuteCommand(name, inputs, outputs, 0)
+	_, err = c.DoOrSend(cmd, args, err)
 	return
 }
 
 // ModelExecute runs the model present in the keyName, with the input tensor names, and output tensor names
 func (c *Client) ModelExecute(name string, inputs, outputs []string) (err error) {
-	args := modelExecuteFlatArgs(name, inputs, outputs, 0)
-	_, err = c.DoOrSend("AI.MODELEXECUTE", args, nil)
+	cmd, args, err := c.modelExecuteCommand(name, inputs, outputs, 0)
+	_, err = c.DoOrSend(cmd, args, err)
 	return
 }
 
 // ModelExecuteWithTimeout runs the model present in the keyName, with the input tensor names, output tensor names and timeout
 func (c *Client) ModelExecuteWithTimeout(name string, inputs, outputs []string, timeout int64) (err error) {
-	args := modelExecuteFlatArgs(name, inputs, outputs, timeout)
-	_, err = c.DoOrSend("AI.MODELEXECUTE", args, nil)
+	cmd, args, err := c.modelExecuteCommand(name, inputs, outputs, timeout)
+	_, err = c.DoOrSend(cmd, args, err)
 	return
 }
 
-// ScriptSet sets a RedisAI script from a blob
+// ScriptSet sets a RedisAI script from a blob.
+//
+// Deprecated: AI.SCRIPTSET is always issued, since the scripts stored without entry points can't be stored with
+// AI.SCRIPTSTORE. Use ScriptStore, which issues the command provided by the server after DetectCapabilities.
 func (c *Client) ScriptSet(name, device, scriptSource string) (err error) {
 	args := scriptStoreFlatArgs(name, device, "", nil, scriptSource)
 	_, err = c.DoOrSend("AI.SCRIPTSET", args, nil)
+	c.modelChanged(name, err)
 	return
 }
 
-// ScriptSetWithTag sets a RedisAI script from a blob with tag
+// ScriptSetWithTag sets a RedisAI script from a blob with tag.
+//
+// Deprecated: use ScriptStoreWithTag, see ScriptSet.
 func (c *Client) ScriptSetWithTag(name, device, scriptSource, tag string) (err error) {
 	args := scriptStoreFlatArgs(name, device, tag, nil, scriptSource)
 	_, err = c.DoOrSend("AI.SCRIPTSET", args, nil)
+	c.modelChanged(name, err)
 	return
 }
 
-// ScriptSetFromInteface sets a RedisAI script from a structure that implements the ScriptInterface
+// ScriptSetFromInteface sets a RedisAI script from a structure that implements the ScriptInterface.
+// A script with entry points is stored as ScriptStoreFromInterface does, given AI.SCRIPTSET does not accept them.
+//
+// Deprecated: use ScriptStoreFromInterface, see ScriptSet.
 func (c *Client) ScriptSetFromInteface(keyName string, script ScriptInterface) (err error) {
+	if len(script.EntryPoints()) > 0 {
+		return c.ScriptStoreFromInterface(keyName, script)
+	}
 	args := scriptStoreInterfaceArgs(keyName, script)
 	_, err = c.DoOrSend("AI.SCRIPTSET", args, nil)
+	c.modelChanged(keyName, err)
 	return
 }
 
 // ScriptStore store a TorchScript as the value of a key.
 func (c *Client) ScriptStore(name, device, scriptSource string, entryPoints []string) (err error) {
-	args := scriptStoreFlatArgs(name, device, "", entryPoints, scriptSource)
-	_, err = c.DoOrSend("AI.SCRIPTSTORE", args, nil)
+	cmd, args, err := c.scriptStoreCommand(name, device, "", entryPoints, scriptSource)
+	_, err = c.DoOrSend(cmd, args, err)
+	c.modelChanged(name, err)
 	return
 }
 
 // ScriptStoreWithTag store a TorchScript as the value of a key with tag.
 func (c *Client) ScriptStoreWithTag(name, device, scriptSource string, entryPoints []string, tag string) (err error) {
-	args := scriptStoreFlatArgs(name, device, tag, entryPoints, scriptSource)
-	_, err = c.DoOrSend("AI.SCRIPTSTORE", args, nil)
+	cmd, args, err := c.scriptStoreCommand(name, device, tag, entryPoints, scriptSource)
+	_, err = c.DoOrSend(cmd, args, err)
+	c.modelChanged(name, err)
 	return
 }
 
 // ScriptStoreFromInteface store a TorchScript as the value from a structure that implements the ScriptInterface
 func (c *Client) ScriptStoreFromInterface(keyName string, script ScriptInterface) (err error) {
-	args := scriptStoreInterfaceArgs(keyName, script)
-	_, err = c.DoOrSend("AI.SCRIPTSTORE", args, nil)
+	cmd, args, err := c.scriptStoreCommand(keyName, script.Device(), script.Tag(), script.EntryPoints(), script.Source())
+	_, err = c.DoOrSend(cmd, args, err)
+	c.modelChanged(keyName, err)
 	return
 }
 
@@ -251,9 +336,33 @@ func (c *Client) ScriptGetToInterface(name string, scriptIn ScriptInterface) (er
 	return
 }
 
+// ScriptGetMeta gets the metadata of a RedisAI script, without transferring its source
+func (c *Client) ScriptGetMeta(name string) (meta ScriptMeta, err error) {
+	reply, err := c.DoOrSend("AI.SCRIPTGET", redis.Args{name, "META"}, nil)
+	if err != nil || reply == nil {
+		return
+	}
+	return scriptGetMetaParseReply(reply)
+}
+
+// ScriptGetSource gets the source of a RedisAI script, without its metadata
+func (c *Client) ScriptGetSource(name string) (source string, err error) {
+	reply, err := c.DoOrSend("AI.SCRIPTGET", redis.Args{name, "SOURCE"}, nil)
+	if err != nil || reply == nil {
+		return
+	}
+	if _, ok := reply.([]interface{}); ok {
+		_, _, source, _, err = scriptGetParseReply(reply)
+		return
+	}
+	// the source alone is replied as a bulk string
+	return redis.String(reply, nil)
+}
+
 func (c *Client) ScriptDel(name string) (err error) {
 	args := redis.Args{}.Add(name)
 	_, err = c.DoOrSend("AI.SCRIPTDEL", args, nil)
+	c.modelChanged(name, err)
 	return
 }
 
@@ -266,15 +375,15 @@ func (c *Client) ScriptRun(name, fn string, inputs, outputs []string) (err error
 
 // ScriptExecute run an already set script
 func (c *Client) ScriptExecute(name, fn string, keys, inputs, inputArgs, outputs []string) (err error) {
-	args := scriptExecuteFlatArgs(name, fn, keys, inputs, inputArgs, outputs, 0)
-	_, err = c.DoOrSend("AI.SCRIPTEXECUTE", args, nil)
+	cmd, args, err := c.scriptExecuteCommand(name, fn, keys, inputs, inputArgs, outputs, 0)
+	_, err = c.DoOrSend(cmd, args, err)
 	return
 }
 
 // ScriptExecuteWithTimeout run an already set script with timeout limitation
 func (c *Client) ScriptExecuteWithTimeout(name, fn string, keys, inputs, inputArgs, outputs []string, timeout int64) (err error) {
-	args := scriptExecuteFlatArgs(name, fn, keys, inputs, inputArgs, outputs, timeout)
-	_, err = c.DoOrSend("AI.SCRIPTEXECUTE", args, nil)
+	cmd, args, err := c.scriptExecuteCommand(name, fn, keys, inputs, inputArgs, outputs, timeout)
+	_, err = c.DoOrSend(cmd, args, err)
 	return
 }
 
@@ -317,48 +426,72 @@ func (c *Client) ResetStat(key string) (string, error) {
 
 // Direct acyclic graph of operations to run within RedisAI
 func (c *Client) DagRun(loadKeys, persistKeys []string, dagCommandInterface DagCommandInterface) ([]interface{}, error) {
-	commandArgs, err := dagCommandInterface.FlatArgs()
+	cmd, args, err := c.dagCommand(false, false, loadKeys, persistKeys, "", 0, dagCommandInterface)
 	if err != nil {
 		return nil, err
 	}
-	args := AddDagRunArgs(loadKeys, persistKeys, commandArgs)
-	reply, err := c.DoOrSend("AI.DAGRUN", args, nil)
+	reply, err := c.DoOrSend(cmd, args, nil)
 	return dagCommandInterface.ParseReply(reply, err)
 }
 
 // The command is a read-only variant of AI.DAGRUN
 func (c *Client) DagRunRO(loadKeys []string, dagCommandInterface DagCommandInterface) ([]interface{}, error) {
-	commandArgs, err := dagCommandInterface.FlatArgs()
+	cmd, args, err := c.dagCommand(false, true, loadKeys, nil, "", 0, dagCommandInterface)
 	if err != nil {
 		return nil, err
 	}
-	args := AddDagRunArgs(loadKeys, nil, commandArgs)
-	reply, err := c.DoOrSend("AI.DAGRUN_RO", args, nil)
+	reply, err := c.DoOrSend(cmd, args, nil)
 	return dagCommandInterface.ParseReply(reply, err)
 }
 
 // DagExecute Direct acyclic graph of operations to run within RedisAI
 func (c *Client) DagExecute(loadKeys, persistKeys []string, routing string, timeout int64, dagCommandInterface DagCommandInterface) ([]interface{}, error) {
-	commandArgs, err := dagCommandInterface.FlatArgs()
+	cmd, args, err := c.dagCommand(true, false, loadKeys, persistKeys, routing, timeout, dagCommandInterface)
 	if err != nil {
 		return nil, err
 	}
-	args := AddDagExecuteArgs(loadKeys, persistKeys, routing, timeout, commandArgs)
-	reply, err := c.DoOrSend("AI.DAGEXECUTE", args, nil)
+	reply, err := c.DoOrSend(cmd, args, nil)
 	return dagCommandInterface.ParseReply(reply, err)
 }
 
 // DagExecuteRO is the read-only variant of DagExecute
 func (c *Client) DagExecuteRO(loadKeys []string, routing string, timeout int64, dagCommandInterface DagCommandInterface) ([]interface{}, error) {
-	commandArgs, err := dagCommandInterface.FlatArgs()
+	cmd, args, err := c.dagCommand(true, true, loadKeys, nil, routing, timeout, dagCommandInterface)
 	if err != nil {
 		return nil, err
 	}
-	args := AddDagExecuteArgs(loadKeys, nil, routing, timeout, commandArgs)
-	reply, err := c.DoOrSend("AI.DAGEXECUTE_RO", args, nil)
+	reply, err := c.DoOrSend(cmd, args, nil)
 	return dagCommandInterface.ParseReply(reply, err)
 }
 
+// DagExecuteAuto runs the DAG with its LOAD, PERSIST and ROUTING arguments inferred from the DAG's operations.
+// Use Dag.Persist to mark which of the produced tensors should be persisted.
+func (c *Client) DagExecuteAuto(timeout int64, dag *Dag) ([]interface{}, error) {
+	loadKeys, persistKeys, routing, err := dag.Keys()
+	if err != nil {
+		return nil, err
+	}
+	if routing, err = c.inferredRouting(routing); err != nil {
+		return nil, err
+	}
+	return c.DagExecute(loadKeys, persistKeys, routing, timeout, dag)
+}
+
+// DagExecuteROAuto is the read-only variant of DagExecuteAuto. Tensors marked to be persisted are rejected.
+func (c *Client) DagExecuteROAuto(timeout int64, dag *Dag) ([]interface{}, error) {
+	loadKeys, persistKeys, routing, err := dag.Keys()
+	if err != nil {
+		return nil, err
+	}
+	if len(persistKeys) > 0 {
+		return nil, errors.New("redisai.DagExecuteROAuto: read-only DAGs can't persist tensors")
+	}
+	if routing, err = c.inferredRouting(routing); err != nil {
+		return nil, err
+	}
+	return c.DagExecuteRO(loadKeys, routing, timeout, dag)
+}
+
 // AddDagRunArgs for AI.DAGRUN and DAGRUN_RO commands.
 func AddDagRunArgs(loadKeys, persistKeys []string, commandArgs redis.Args) redis.Args {
 	args := redis.Args{}
@@ -395,7 +528,7 @@ func AddDagExecuteArgs(loadKeys, persistKeys []string, routing string, timeout i
 	return args
 }
 
-// Sets the default backends path
+// Sets the default backends path. See ConfigGetBackendsPath to read it back
 func (c *Client) SetBackendsPath(path string) (string, error) {
 	return redis.String(c.DoOrSend("AI.CONFIG", redis.Args{"BACKENDSPATH", path}, nil))
 }
@@ -1396,6 +1396,42 @@ func TestCommand_DagExecute_ScriptExecute(t *testing.T) {
 	assert.Equal(t, values[2], []float32{54})
 }
 
+func TestCommand_DagExecuteAuto(t *testing.T) {
+	c := createTestClient()
+	keyModel := "test:DagExecuteAuto:mymodel:1"
+	data, err := ioutil.ReadFile("./../tests/test_data/graph.pb")
+	if err != nil {
+		t.Errorf("Error preparing for DagExecuteAuto(), while reading the model. error = %v", err)
+		return
+	}
+	err = c.ModelStore(keyModel, BackendTF, DeviceCPU, "", 0, 0, 0, []string{"a", "b"}, []string{"mul"}, data)
+	assert.Nil(t, err)
+	err = c.TensorSet("test:DagExecuteAuto:b:1", TypeFloat32, []int64{1}, []float32{4.4})
+	assert.Nil(t, err)
+
+	// no LOAD nor PERSIST keys, the model key is used for ROUTING
+	dag := NewDag().TensorSet("a", TypeFloat32, []int64{1}, []float32{1.1}).
+		TensorSet("b", TypeFloat32, []int64{1}, []float32{4.4}).
+		ModelExecute(keyModel, []string{"a", "b"}, []string{"mul"}, 0).
+		TensorGet("mul", TensorContentTypeValues)
+	results, err := c.DagExecuteAuto(0, dag.(*Dag))
+	assert.Nil(t, err)
+	assert.Equal(t, 4, len(results))
+
+	// b is loaded from the keyspace and mul is persisted
+	dag = NewDag().TensorSet("a", TypeFloat32, []int64{1}, []float32{1.1}).
+		ModelExecute(keyModel, []string{"a", "test:DagExecuteAuto:b:1"}, []string{"test:DagExecuteAuto:mul:1"}, 0)
+	_, err = c.DagExecuteAuto(0, dag.(*Dag).Persist("test:DagExecuteAuto:mul:1"))
+	assert.Nil(t, err)
+	dt, shape, _, err := c.TensorGetValues("test:DagExecuteAuto:mul:1")
+	assert.Nil(t, err)
+	assert.Equal(t, TypeFloat, dt)
+	assert.Equal(t, []int64{1}, shape)
+
+	_, err = c.DagExecuteROAuto(0, dag.(*Dag))
+	assert.NotNil(t, err)
+}
+
 func TestCommand_DagExecuteRO(t *testing.T) {
 	c := createTestClient()
 	err := c.TensorSet("persisted_tensor", TypeFloat32, []int64{1, 2}, []float32{5, 10})
@@ -0,0 +1,278 @@
+package redisai
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/gomodule/redigo/redis"
+)
+
+// VersionExecuteCommands is the first RedisAI version providing AI.MODELSTORE, AI.MODELEXECUTE, AI.SCRIPTSTORE,
+// AI.SCRIPTEXECUTE and AI.DAGEXECUTE, as reported by MODULE LIST
+const VersionExecuteCommands = 10205
+
+// ServerCapabilities describes the RedisAI module of a server
+type ServerCapabilities struct {
+	// Version is the RedisAI version, as major*10000 + minor*100 + patch
+	Version int64
+}
+
+// ExecuteCommands reports whether the server provides AI.MODELSTORE, AI.MODELEXECUTE, AI.SCRIPTSTORE,
+// AI.SCRIPTEXECUTE and AI.DAGEXECUTE. Older servers only provide AI.MODELSET, AI.MODELRUN, AI.SCRIPTSET,
+// AI.SCRIPTRUN and AI.DAGRUN.
+func (s *ServerCapabilities) ExecuteCommands() bool {
+	return s.Version >= VersionExecuteCommands
+}
+
+// UnsupportedFeatureError is returned when a command relies on a feature the server does not provide
+type UnsupportedFeatureError struct {
+	Feature string
+	// Version is the RedisAI version of the server
+	Version int64
+	// Required is the first RedisAI version providing the feature
+	Required int64
+}
+
+func (e *UnsupportedFeatureError) Error() string {
+	return fmt.Sprintf("redisai: %s requires RedisAI %s, the server runs %s", e.Feature, formatVersion(e.Required), formatVersion(e.Version))
+}
+
+// formatVersion formats a MODULE LIST version as major.minor.patch
+func formatVersion(version int64) string {
+	return fmt.Sprintf("%d.%d.%d", version/10000, version/100%100, version%100)
+}
+
+// DetectCapabilities makes the client detect the RedisAI version of the server before the first command that
+// depends on it, and issue the commands the server provides: AI.MODELSET, AI.MODELRUN, AI.SCRIPTSET,
+// AI.SCRIPTRUN and AI.DAGRUN are issued instead of their successors on servers predating VersionExecuteCommands,
+// and AI.DAGRUN is upgraded to AI.DAGEXECUTE on newer ones.
+//
+// Without detection, the client issues the commands it is asked for.
+func (c *Client) DetectCapabilities() {
+	c.detectCapabilities = true
+}
+
+// SetCapabilities sets the server capabilities instead of detecting them, as DetectCapabilities would
+func (c *Client) SetCapabilities(capabilities *ServerCapabilities) {
+	c.detectCapabilities = true
+	c.capabilities = capabilities
+}
+
+// Capabilities returns the server capabilities, detecting them on first use.
+// The detection reads MODULE LIST on a connection of its own, falling back to INFO MODULES when MODULE LIST is
+// not allowed, so that it can be issued on a pipelined client.
+func (c *Client) Capabilities() (*ServerCapabilities, error) {
+	if c.capabilities != nil {
+		return c.capabilities, nil
+	}
+	conn := c.Pool.Get()
+	defer conn.Close()
+	version, err := moduleListVersion(conn)
+	if err != nil {
+		info, errInfo := redis.String(conn.Do("INFO", "MODULES"))
+		if errInfo != nil {
+			return nil, err
+		}
+		if version, err = infoModulesVersion(info); err != nil {
+			return nil, err
+		}
+	}
+	c.capabilities = &ServerCapabilities{Version: version}
+	return c.capabilities, nil
+}
+
+// moduleListVersion returns the version of the ai module from MODULE LIST
+func moduleListVersion(conn redis.Conn) (int64, error) {
+	modules, err := redis.Values(conn.Do("MODULE", "LIST"))
+	if err != nil {
+		return 0, err
+	}
+	for _, module := range modules {
+		fields, err := redis.Values(module, nil)
+		if err != nil {
+			return 0, err
+		}
+		var name string
+		var version int64
+		for pos := 0; pos+1 < len(fields); pos += 2 {
+			switch field, _ := redis.String(fields[pos], nil); field {
+			case "name":
+				name, _ = redis.String(fields[pos+1], nil)
+			case "ver":
+				version, _ = redis.Int64(fields[pos+1], nil)
+			}
+		}
+		if name == "ai" {
+			return version, nil
+		}
+	}
+	return 0, errors.New("redisai: the RedisAI module is not loaded")
+}
+
+// infoModulesVersion returns the version of the ai module from the "module:name=ai,ver=10205,..." line of INFO MODULES
+func infoModulesVersion(info string) (int64, error) {
+	scanner := bufio.NewScanner(strings.NewReader(info))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if !strings.HasPrefix(line, "module:name=ai,") {
+			continue
+		}
+		for _, field := range strings.Split(line[len("module:"):], ",") {
+			if strings.HasPrefix(field, "ver=") {
+				return strconv.ParseInt(field[len("ver="):], 10, 64)
+			}
+		}
+	}
+	return 0, errors.New("redisai: the RedisAI module is not loaded")
+}
+
+// legacyServer reports whether the server predates VersionExecuteCommands, when detection is enabled
+func (c *Client) legacyServer() (legacy bool, version int64, err error) {
+	if !c.detectCapabilities {
+		return false, 0, nil
+	}
+	capabilities, err := c.Capabilities()
+	if err != nil {
+		return false, 0, err
+	}
+	return !capabilities.ExecuteCommands(), capabilities.Version, nil
+}
+
+// inferredRouting returns the ROUTING inferred from a Dag, which only fulfills a requirement of AI.DAGEXECUTE,
+// or nothing on servers issuing AI.DAGRUN instead
+func (c *Client) inferredRouting(routing string) (string, error) {
+	legacy, _, err := c.legacyServer()
+	if legacy {
+		routing = ""
+	}
+	return routing, err
+}
+
+// modelStoreCommand returns AI.MODELSTORE with its arguments, or AI.MODELSET on servers predating it
+func (c *Client) modelStoreCommand(keyName, backend, device, tag string, batchsize, minbatchsize, minbatchtimeout int64, inputs, outputs []string, blob []byte) (string, redis.Args, error) {
+	legacy, version, err := c.legacyServer()
+	if err != nil || !legacy {
+		args, errArgs := modelStoreFlatArgs(keyName, backend, device, tag, batchsize, minbatchsize, minbatchtimeout, inputs, outputs, blob)
+		if err == nil {
+			err = errArgs
+		}
+		return "AI.MODELSTORE", args, err
+	}
+	if minbatchtimeout > 0 {
+		return "AI.MODELSET", nil, &UnsupportedFeatureError{"MINBATCHTIMEOUT", version, VersionExecuteCommands}
+	}
+	args, err := modelSetFlatArgs(keyName, backend, device, tag, batchsize, minbatchsize, inputs, outputs, blob)
+	return "AI.MODELSET", args, err
+}
+
+func (c *Client) modelStoreInterfaceCommand(keyName string, model ModelInterface) (string, redis.Args, error) {
+	return c.modelStoreCommand(keyName, model.Backend(), model.Device(), model.Tag(), model.BatchSize(), model.MinBatchSize(), model.MinBatchTimeout(), model.Inputs(), model.Outputs(), model.Blob())
+}
+
+// modelExecuteCommand returns AI.MODELEXECUTE with its arguments, or AI.MODELRUN on servers predating it
+func (c *Client) modelExecuteCommand(name string, inputs, outputs []string, timeout int64) (string, redis.Args, error) {
+	legacy, version, err := c.legacyServer()
+	if err != nil || !legacy {
+		return "AI.MODELEXECUTE", modelExecuteFlatArgs(name, inputs, outputs, timeout), err
+	}
+	if timeout > 0 {
+		return "AI.MODELRUN", nil, &UnsupportedFeatureError{"TIMEOUT", version, VersionExecuteCommands}
+	}
+	return "AI.MODELRUN", modelRunFlatArgs(name, inputs, outputs), nil
+}
+
+// scriptStoreCommand returns AI.SCRIPTSTORE with its arguments, or AI.SCRIPTSET on servers predating it
+func (c *Client) scriptStoreCommand(name, device, tag string, entryPoints []string, source string) (string, redis.Args, error) {
+	legacy, version, err := c.legacyServer()
+	if err != nil || !legacy {
+		return "AI.SCRIPTSTORE", scriptStoreFlatArgs(name, device, tag, entryPoints, source), err
+	}
+	if len(entryPoints) > 0 {
+		return "AI.SCRIPTSET", nil, &UnsupportedFeatureError{"ENTRY_POINTS", version, VersionExecuteCommands}
+	}
+	return "AI.SCRIPTSET", scriptStoreFlatArgs(name, device, tag, nil, source), nil
+}
+
+// scriptExecuteCommand returns AI.SCRIPTEXECUTE with its arguments, or AI.SCRIPTRUN on servers predating it
+func (c *Client) scriptExecuteCommand(name, fn string, keys, inputs, inputArgs, outputs []string, timeout int64) (string, redis.Args, error) {
+	legacy, version, err := c.legacyServer()
+	if err != nil || !legacy {
+		return "AI.SCRIPTEXECUTE", scriptExecuteFlatArgs(name, fn, keys, inputs, inputArgs, outputs, timeout), err
+	}
+	if feature := scriptRunUnsupportedFeature(keys, inputArgs, timeout); len(feature) > 0 {
+		return "AI.SCRIPTRUN", nil, &UnsupportedFeatureError{feature, version, VersionExecuteCommands}
+	}
+	return "AI.SCRIPTRUN", scriptRunFlatArgs(name, fn, inputs, outputs), nil
+}
+
+// scriptRunUnsupportedFeature returns the AI.SCRIPTEXECUTE argument that AI.SCRIPTRUN does not provide, if any
+func scriptRunUnsupportedFeature(keys, inputArgs []string, timeout int64) string {
+	switch {
+	case len(keys) > 0:
+		return "KEYS"
+	case len(inputArgs) > 0:
+		return "ARGS"
+	case timeout > 0:
+		return "TIMEOUT"
+	}
+	return ""
+}
+
+// dagCommand returns the DAG command with its arguments: AI.DAGEXECUTE when execute is set and the server provides
+// it, AI.DAGRUN otherwise, upgraded to AI.DAGEXECUTE on servers providing it when the keys allow it.
+// With detection, the model and script operations of a Dag are issued in the form matching the command;
+// without it, they are issued as they were added.
+func (c *Client) dagCommand(execute, readOnly bool, loadKeys, persistKeys []string, routing string, timeout int64, dagCommandInterface DagCommandInterface) (string, redis.Args, error) {
+	legacy, version, err := c.legacyServer()
+	if err != nil {
+		return dagCommandName(execute, readOnly), nil, err
+	}
+	dag, isDag := dagCommandInterface.(*Dag)
+	switch {
+	case execute && legacy && len(routing) > 0:
+		return dagCommandName(execute, readOnly), nil, &UnsupportedFeatureError{"ROUTING", version, VersionExecuteCommands}
+	case execute && legacy && timeout > 0:
+		return dagCommandName(execute, readOnly), nil, &UnsupportedFeatureError{"TIMEOUT", version, VersionExecuteCommands}
+	case execute && legacy:
+		execute = false
+	case !execute && c.detectCapabilities && !legacy && isDag:
+		// AI.DAGEXECUTE requires LOAD, PERSIST or ROUTING, which the DAG provides through its first model or script
+		if len(loadKeys) == 0 && len(persistKeys) == 0 {
+			routing = dag.routing
+		}
+		execute = len(loadKeys) > 0 || len(persistKeys) > 0 || len(routing) > 0
+	}
+
+	var commandArgs redis.Args
+	if isDag && c.detectCapabilities {
+		var feature string
+		if commandArgs, feature = dag.flatArgsFor(execute); len(feature) > 0 {
+			if legacy {
+				return dagCommandName(execute, readOnly), nil, &UnsupportedFeatureError{feature, version, VersionExecuteCommands}
+			}
+			return dagCommandName(execute, readOnly), nil, fmt.Errorf("redisai: %s is not available within AI.DAGRUN", feature)
+		}
+	} else if commandArgs, err = dagCommandInterface.FlatArgs(); err != nil {
+		return dagCommandName(execute, readOnly), nil, err
+	}
+
+	args := AddDagRunArgs(loadKeys, persistKeys, commandArgs)
+	if execute {
+		args = AddDagExecuteArgs(loadKeys, persistKeys, routing, timeout, commandArgs)
+	}
+	return dagCommandName(execute, readOnly), args, nil
+}
+
+func dagCommandName(execute, readOnly bool) string {
+	command := "AI.DAGRUN"
+	if execute {
+		command = "AI.DAGEXECUTE"
+	}
+	if readOnly {
+		command += "_RO"
+	}
+	return command
+}
@@ -0,0 +1,226 @@
+package redisai
+
+import (
+	"testing"
+
+	"github.com/RedisAI/redisai-go/redisai/implementations"
+	"github.com/gomodule/redigo/redis"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestClient_Capabilities(t *testing.T) {
+	moduleList := []interface{}{
+		[]interface{}{[]byte("name"), []byte("search"), []byte("ver"), int64(20400)},
+		[]interface{}{[]byte("name"), []byte("ai"), []byte("ver"), int64(10003)},
+	}
+	tests := []struct {
+		name        string
+		moduleList  interface{}
+		info        string
+		wantVersion int64
+		wantErr     bool
+	}{
+		{"module-list", moduleList, "", 10003, false},
+		{"info-modules", redis.Error("ERR unknown command 'MODULE'"), "# Modules\r\nmodule:name=ai,ver=10205,api=1,filters=0,usedby=[],using=[],options=[]\r\n", 10205, false},
+		{"not-loaded", []interface{}{}, "", 0, true},
+		{"info-not-loaded", redis.Error("ERR unknown command 'MODULE'"), "# Modules\r\n", 0, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, _ := createFakeClient(func(cmd string, args []interface{}) (interface{}, error) {
+				if cmd == "MODULE" {
+					if err, ok := tt.moduleList.(redis.Error); ok {
+						return nil, err
+					}
+					return tt.moduleList, nil
+				}
+				return tt.info, nil
+			})
+			capabilities, err := c.Capabilities()
+			if tt.wantErr {
+				assert.NotNil(t, err)
+				return
+			}
+			assert.Nil(t, err)
+			assert.Equal(t, tt.wantVersion, capabilities.Version)
+			assert.Equal(t, tt.wantVersion >= VersionExecuteCommands, capabilities.ExecuteCommands())
+		})
+	}
+}
+
+func TestClient_DetectCapabilities(t *testing.T) {
+	c, conn := createFakeClient(func(cmd string, args []interface{}) (interface{}, error) {
+		if cmd == "MODULE" {
+			return []interface{}{[]interface{}{[]byte("name"), []byte("ai"), []byte("ver"), int64(10003)}}, nil
+		}
+		return "OK", nil
+	})
+	c.DetectCapabilities()
+	assert.Nil(t, c.ModelRun("m", []string{"a"}, []string{"b"}))
+	assert.Nil(t, c.ModelRun("m", []string{"a"}, []string{"b"}))
+	assert.Equal(t, []string{"MODULE", "AI.MODELRUN", "AI.MODELRUN"}, conn.CommandNames())
+}
+
+func TestClient_LegacyServer(t *testing.T) {
+	dag := NewDag().TensorSet("a", TypeFloat32, []int64{1}, []float32{1}).ModelExecute("m", []string{"a"}, []string{"b"}, 0).TensorGet("b", TensorContentTypeValues)
+	tests := []struct {
+		name     string
+		run      func(c *Client) error
+		wantCmd  string
+		wantArgs []interface{}
+	}{
+		{"modelset", func(c *Client) error {
+			return c.ModelStore("m", BackendTF, DeviceCPU, "v1", 4, 0, 0, []string{"a"}, []string{"b"}, []byte("blob"))
+		}, "AI.MODELSET", []interface{}{"m", BackendTF, DeviceCPU, "TAG", "v1", "BATCHSIZE", int64(4), "INPUTS", "a", "OUTPUTS", "b", "BLOB", []byte("blob")}},
+		{"modelrun", func(c *Client) error {
+			return c.ModelExecute("m", []string{"a"}, []string{"b"})
+		}, "AI.MODELRUN", []interface{}{"m", "INPUTS", "a", "OUTPUTS", "b"}},
+		{"scriptset", func(c *Client) error {
+			return c.ScriptStoreWithTag("s", DeviceCPU, "def f(a): return a", nil, "v1")
+		}, "AI.SCRIPTSET", []interface{}{"s", DeviceCPU, "TAG", "v1", "SOURCE", "def f(a): return a"}},
+		{"scriptrun", func(c *Client) error {
+			return c.ScriptExecute("s", "f", nil, []string{"a"}, nil, []string{"b"})
+		}, "AI.SCRIPTRUN", []interface{}{"s", "f", "INPUTS", "a", "OUTPUTS", "b"}},
+		{"dagrun", func(c *Client) error {
+			_, err := c.DagExecute(nil, []string{"b"}, "", 0, dag)
+			return err
+		}, "AI.DAGRUN", []interface{}{"PERSIST", 1, "b", "|>", "AI.TENSORSET", "a", TypeFloat32, int64(1), "VALUES", float32(1),
+			"|>", "AI.MODELRUN", "m", "INPUTS", "a", "OUTPUTS", "b", "|>", "AI.TENSORGET", "b", TensorContentTypeValues}},
+		{"dagrun-auto", func(c *Client) error {
+			_, err := c.DagExecuteROAuto(0, dag.(*Dag))
+			return err
+		}, "AI.DAGRUN_RO", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, conn := createFakeClient(func(cmd string, args []interface{}) (interface{}, error) {
+				if cmd == "AI.DAGRUN" || cmd == "AI.DAGRUN_RO" {
+					return []interface{}{"OK", "OK", []interface{}{int64(1)}}, nil
+				}
+				return "OK", nil
+			})
+			c.SetCapabilities(&ServerCapabilities{Version: 10003})
+			assert.Nil(t, tt.run(c))
+			assert.Equal(t, []string{tt.wantCmd}, conn.CommandNames())
+			if tt.wantArgs != nil {
+				assert.Equal(t, tt.wantArgs, conn.Commands()[0][1:])
+			}
+		})
+	}
+}
+
+func TestClient_LegacyServer_UnsupportedFeature(t *testing.T) {
+	tests := []struct {
+		name        string
+		run         func(c *Client) error
+		wantFeature string
+	}{
+		{"model-timeout", func(c *Client) error {
+			return c.ModelExecuteWithTimeout("m", []string{"a"}, []string{"b"}, 100)
+		}, "TIMEOUT"},
+		{"model-minbatchtimeout", func(c *Client) error {
+			return c.ModelStore("m", BackendTF, DeviceCPU, "", 4, 2, 100, nil, nil, []byte("blob"))
+		}, "MINBATCHTIMEOUT"},
+		{"script-entry-points", func(c *Client) error {
+			return c.ScriptStore("s", DeviceCPU, "def f(a): return a", []string{"f"})
+		}, "ENTRY_POINTS"},
+		{"script-keys", func(c *Client) error {
+			return c.ScriptExecute("s", "f", []string{"k"}, nil, nil, nil)
+		}, "KEYS"},
+		{"dag-routing", func(c *Client) error {
+			_, err := c.DagExecute(nil, nil, "m", 0, NewDag().ModelRun("m", []string{"a"}, []string{"b"}))
+			return err
+		}, "ROUTING"},
+		{"dag-model-timeout", func(c *Client) error {
+			_, err := c.DagRun(nil, nil, NewDag().ModelExecute("m", []string{"a"}, []string{"b"}, 100))
+			return err
+		}, "TIMEOUT"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, conn := createFakeClient(func(cmd string, args []interface{}) (interface{}, error) {
+				return "OK", nil
+			})
+			c.SetCapabilities(&ServerCapabilities{Version: 10003})
+			err := tt.run(c)
+			assert.Equal(t, &UnsupportedFeatureError{tt.wantFeature, 10003, VersionExecuteCommands}, err)
+			assert.Empty(t, conn.Commands())
+		})
+	}
+	err := &UnsupportedFeatureError{"ROUTING", 10003, VersionExecuteCommands}
+	assert.EqualError(t, err, "redisai: ROUTING requires RedisAI 1.2.5, the server runs 1.0.3")
+}
+
+func TestClient_ScriptSetFromInteface(t *testing.T) {
+	script := implementations.NewScript(DeviceCPU)
+	script.SetSource("def f(a): return a")
+	c, conn := createFakeClient(nil)
+	c.SetCapabilities(&ServerCapabilities{Version: VersionExecuteCommands})
+	assert.Nil(t, c.ScriptSetFromInteface("s", script))
+	// the scripts with entry points are stored as ScriptStoreFromInterface does
+	script.SetEntryPoints([]string{"f"})
+	assert.Nil(t, c.ScriptSetFromInteface("s", script))
+	assert.Equal(t, []string{"AI.SCRIPTSET", "AI.SCRIPTSTORE"}, conn.CommandNames())
+
+	c, conn = createFakeClient(nil)
+	c.SetCapabilities(&ServerCapabilities{Version: 10003})
+	_, ok := c.ScriptSetFromInteface("s", script).(*UnsupportedFeatureError)
+	assert.True(t, ok)
+	assert.Len(t, conn.Commands(), 0)
+}
+
+func TestClient_DagCommand(t *testing.T) {
+	dag := func() DagCommandInterface {
+		return NewDag().TensorSet("a", TypeFloat32, []int64{1}, []float32{1}).ModelRun("m", []string{"a"}, []string{"b"})
+	}
+	tests := []struct {
+		name         string
+		capabilities *ServerCapabilities
+		run          func(c *Client) ([]interface{}, error)
+		want         []interface{}
+	}{
+		{"run-upgraded-routing", &ServerCapabilities{Version: 10205}, func(c *Client) ([]interface{}, error) {
+			return c.DagRun(nil, nil, dag())
+		}, []interface{}{"AI.DAGEXECUTE", "ROUTING", "m", "|>", "AI.TENSORSET", "a", TypeFloat32, int64(1), "VALUES", float32(1),
+			"|>", "AI.MODELEXECUTE", "m", "INPUTS", 1, "a", "OUTPUTS", 1, "b"}},
+		{"run-upgraded-persist", &ServerCapabilities{Version: 10205}, func(c *Client) ([]interface{}, error) {
+			return c.DagRun(nil, []string{"b"}, dag())
+		}, []interface{}{"AI.DAGEXECUTE", "PERSIST", 1, "b", "|>", "AI.TENSORSET", "a", TypeFloat32, int64(1), "VALUES", float32(1),
+			"|>", "AI.MODELEXECUTE", "m", "INPUTS", 1, "a", "OUTPUTS", 1, "b"}},
+		{"run-not-detected", nil, func(c *Client) ([]interface{}, error) {
+			return c.DagRunRO(nil, dag())
+		}, []interface{}{"AI.DAGRUN_RO", "|>", "AI.TENSORSET", "a", TypeFloat32, int64(1), "VALUES", float32(1),
+			"|>", "AI.MODELRUN", "m", "INPUTS", "a", "OUTPUTS", "b"}},
+		{"execute-not-detected", nil, func(c *Client) ([]interface{}, error) {
+			return c.DagExecute(nil, []string{"b"}, "", 0, dag())
+		}, []interface{}{"AI.DAGEXECUTE", "PERSIST", 1, "b", "|>", "AI.TENSORSET", "a", TypeFloat32, int64(1), "VALUES", float32(1),
+			"|>", "AI.MODELRUN", "m", "INPUTS", "a", "OUTPUTS", "b"}},
+		{"execute-model-run-detected", &ServerCapabilities{Version: 10205}, func(c *Client) ([]interface{}, error) {
+			return c.DagExecute(nil, []string{"b"}, "", 0, dag())
+		}, []interface{}{"AI.DAGEXECUTE", "PERSIST", 1, "b", "|>", "AI.TENSORSET", "a", TypeFloat32, int64(1), "VALUES", float32(1),
+			"|>", "AI.MODELEXECUTE", "m", "INPUTS", 1, "a", "OUTPUTS", 1, "b"}},
+		{"run-model-execute-not-detected", nil, func(c *Client) ([]interface{}, error) {
+			return c.DagRun(nil, nil, NewDag().ModelExecute("m", []string{"a"}, []string{"b"}, 0))
+		}, []interface{}{"AI.DAGRUN", "|>", "AI.MODELEXECUTE", "m", "INPUTS", 1, "a", "OUTPUTS", 1, "b"}},
+		{"run-model-execute-legacy", &ServerCapabilities{Version: 10003}, func(c *Client) ([]interface{}, error) {
+			return c.DagRun(nil, nil, NewDag().ModelExecute("m", []string{"a"}, []string{"b"}, 0))
+		}, []interface{}{"AI.DAGRUN", "|>", "AI.MODELRUN", "m", "INPUTS", "a", "OUTPUTS", "b"}},
+		{"execute-downgraded-legacy", &ServerCapabilities{Version: 10003}, func(c *Client) ([]interface{}, error) {
+			return c.DagExecute(nil, []string{"b"}, "", 0, NewDag().ModelExecute("m", []string{"a"}, []string{"b"}, 0))
+		}, []interface{}{"AI.DAGRUN", "PERSIST", 1, "b", "|>", "AI.MODELRUN", "m", "INPUTS", "a", "OUTPUTS", "b"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, conn := createFakeClient(func(cmd string, args []interface{}) (interface{}, error) {
+				return []interface{}{"OK", "OK"}, nil
+			})
+			if tt.capabilities != nil {
+				c.SetCapabilities(tt.capabilities)
+			}
+			_, err := tt.run(c)
+			assert.Nil(t, err)
+			assert.Len(t, conn.Commands(), 1)
+			assert.Equal(t, tt.want, conn.Commands()[0])
+		})
+	}
+}
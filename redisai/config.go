@@ -0,0 +1,152 @@
+package redisai
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/gomodule/redigo/redis"
+)
+
+const (
+	// ConfigBackendsPath is the AI.CONFIG parameter holding the directory of the backend libraries
+	ConfigBackendsPath = string("BACKENDSPATH")
+	// ConfigModelChunkSize is the AI.CONFIG parameter holding the size in bytes of the chunks of the model blobs replies
+	ConfigModelChunkSize = string("MODEL_CHUNK_SIZE")
+)
+
+const (
+	// LoadBackendTF identifies the TensorFlow backend library in AI.CONFIG LOADBACKEND
+	LoadBackendTF = string("TF")
+	// LoadBackendTFLite identifies the TensorFlow Lite backend library in AI.CONFIG LOADBACKEND
+	LoadBackendTFLite = string("TFLITE")
+	// LoadBackendTorch identifies the Torch backend library in AI.CONFIG LOADBACKEND
+	LoadBackendTorch = string("TORCH")
+	// LoadBackendONNX identifies the ONNX Runtime backend library in AI.CONFIG LOADBACKEND
+	LoadBackendONNX = string("ONNX")
+)
+
+// backendLibraries maps the backend identifiers to their default library, relative to the backends path
+var backendLibraries = map[string]string{
+	LoadBackendTF:     "redisai_tensorflow/redisai_tensorflow.so",
+	LoadBackendTFLite: "redisai_tflite/redisai_tflite.so",
+	LoadBackendTorch:  "redisai_torch/redisai_torch.so",
+	LoadBackendONNX:   "redisai_onnxruntime/redisai_onnxruntime.so",
+}
+
+// backendInfoNames maps the lower-cased backend names of INFO MODULES to the backend identifiers
+var backendInfoNames = map[string]string{
+	"tensorflow":     LoadBackendTF,
+	"tflite":         LoadBackendTFLite,
+	"tensorflowlite": LoadBackendTFLite,
+	"torch":          LoadBackendTorch,
+	"onnxruntime":    LoadBackendONNX,
+}
+
+// BackendInfo describes a backend loaded by the server
+type BackendInfo struct {
+	// Name is one of LoadBackendTF, LoadBackendTFLite, LoadBackendTorch or LoadBackendONNX
+	Name    string `json:"name"`
+	Version string `json:"version"`
+}
+
+// ConfigGetBackendsPath returns the directory the backend libraries are loaded from
+func (c *Client) ConfigGetBackendsPath() (string, error) {
+	return redis.String(c.DoOrSend("AI.CONFIG", redis.Args{"GET", ConfigBackendsPath}, nil))
+}
+
+// ConfigGetModelChunkSize returns the size in bytes of the chunks the model blobs are split into by AI.MODELGET
+func (c *Client) ConfigGetModelChunkSize() (int64, error) {
+	return redis.Int64(c.DoOrSend("AI.CONFIG", redis.Args{"GET", ConfigModelChunkSize}, nil))
+}
+
+// ConfigSetModelChunkSize sets the size in bytes of the chunks the model blobs are split into by AI.MODELGET
+func (c *Client) ConfigSetModelChunkSize(size int64) (err error) {
+	_, err = c.DoOrSend("AI.CONFIG", redis.Args{ConfigModelChunkSize, size}, nil)
+	return
+}
+
+// LoadedBackends returns the backends loaded by the server, sorted by name, from INFO MODULES
+func (c *Client) LoadedBackends() ([]BackendInfo, error) {
+	info, err := c.infoModules()
+	if err != nil {
+		return nil, err
+	}
+	var backends []BackendInfo
+	for field, value := range info {
+		if !strings.HasPrefix(field, "ai_") || !strings.HasSuffix(field, "_version") {
+			continue
+		}
+		name, ok := backendInfoNames[strings.ToLower(strings.TrimSuffix(strings.TrimPrefix(field, "ai_"), "_version"))]
+		if ok {
+			backends = append(backends, BackendInfo{Name: name, Version: value})
+		}
+	}
+	sort.Slice(backends, func(i, j int) bool { return backends[i].Name < backends[j].Name })
+	return backends, nil
+}
+
+// EnsureBackend loads the backend library from its default location in the backends path, unless the server
+// already loaded it, and reports whether it was loaded by this call.
+// The backend is one of LoadBackendTF, LoadBackendTFLite, LoadBackendTorch or LoadBackendONNX; BackendONNX is
+// accepted for LoadBackendONNX.
+func (c *Client) EnsureBackend(backend string) (loaded bool, err error) {
+	return c.EnsureBackendAt(backend, "")
+}
+
+// EnsureBackendAt is EnsureBackend with the location of the library. An empty location means the default one
+func (c *Client) EnsureBackendAt(backend, location string) (loaded bool, err error) {
+	if c.PipelineActive {
+		return false, errors.New("redisai.EnsureBackend: the loaded backends can't be read on a pipelined client")
+	}
+	backend = strings.ToUpper(backend)
+	if backend == BackendONNX {
+		backend = LoadBackendONNX
+	}
+	library, ok := backendLibraries[backend]
+	if !ok {
+		return false, fmt.Errorf("redisai.EnsureBackend: unknown backend %s", backend)
+	}
+	if len(location) == 0 {
+		location = library
+	}
+	backends, err := c.LoadedBackends()
+	if err != nil {
+		return false, err
+	}
+	for _, info := range backends {
+		if info.Name == backend {
+			return false, nil
+		}
+	}
+	if err = c.LoadBackend(backend, location); err != nil {
+		if _, ok := err.(redis.Error); ok && strings.Contains(strings.ToLower(err.Error()), "already loaded") {
+			// loaded concurrently, or by a server whose INFO does not list the backend
+			return false, nil
+		}
+		return false, err
+	}
+	return true, nil
+}
+
+// infoModules returns the fields of INFO MODULES
+func (c *Client) infoModules() (map[string]string, error) {
+	reply, err := redis.String(c.DoOrSend("INFO", redis.Args{"MODULES"}, nil))
+	if err != nil {
+		return nil, err
+	}
+	info := make(map[string]string)
+	scanner := bufio.NewScanner(strings.NewReader(reply))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if len(line) == 0 || line[0] == '#' {
+			continue
+		}
+		if pos := strings.IndexByte(line, ':'); pos > 0 {
+			info[line[:pos]] = line[pos+1:]
+		}
+	}
+	return info, scanner.Err()
+}
@@ -0,0 +1,80 @@
+package redisai
+
+import (
+	"testing"
+
+	"github.com/gomodule/redigo/redis"
+	"github.com/stretchr/testify/assert"
+)
+
+const testInfoModules = "# Modules\r\nmodule:name=ai,ver=10205,api=1,filters=0,usedby=[],using=[],options=[]\r\n\r\n" +
+	"# ai_versions\r\nai_RedisAI_version:10205\r\nai_low_level_API_version:1\r\nai_rdb_version:4\r\n\r\n" +
+	"# ai_backends_info\r\nai_TensorFlow_version:2.8.0\r\nai_TensorFlow_memory:1024\r\nai_onnxruntime_version:1.11.1\r\n"
+
+func TestClient_Config(t *testing.T) {
+	c, conn := createFakeClient(func(cmd string, args []interface{}) (interface{}, error) {
+		switch args[len(args)-1] {
+		case ConfigBackendsPath:
+			return []byte("/usr/lib/redis/modules/backends"), nil
+		case ConfigModelChunkSize:
+			return int64(536870912), nil
+		}
+		return "OK", nil
+	})
+	path, err := c.ConfigGetBackendsPath()
+	assert.Nil(t, err)
+	assert.Equal(t, "/usr/lib/redis/modules/backends", path)
+	size, err := c.ConfigGetModelChunkSize()
+	assert.Nil(t, err)
+	assert.Equal(t, int64(536870912), size)
+	assert.Nil(t, c.ConfigSetModelChunkSize(1024))
+	assert.Equal(t, [][]interface{}{
+		{"AI.CONFIG", "GET", "BACKENDSPATH"},
+		{"AI.CONFIG", "GET", "MODEL_CHUNK_SIZE"},
+		{"AI.CONFIG", "MODEL_CHUNK_SIZE", int64(1024)},
+	}, conn.Commands())
+}
+
+func TestClient_LoadedBackends(t *testing.T) {
+	c, _ := createFakeClient(func(cmd string, args []interface{}) (interface{}, error) {
+		return []byte(testInfoModules), nil
+	})
+	backends, err := c.LoadedBackends()
+	assert.Nil(t, err)
+	assert.Equal(t, []BackendInfo{{LoadBackendONNX, "1.11.1"}, {LoadBackendTF, "2.8.0"}}, backends)
+}
+
+func TestClient_EnsureBackend(t *testing.T) {
+	tests := []struct {
+		name       string
+		backend    string
+		loadErr    error
+		wantLoaded bool
+		wantErr    bool
+		wantCmds   []string
+	}{
+		{"loaded", BackendTF, nil, false, false, []string{"INFO"}},
+		{"model-backend-name", BackendONNX, nil, false, false, []string{"INFO"}},
+		{"not-loaded", "torch", nil, true, false, []string{"INFO", "AI.CONFIG"}},
+		{"already-loaded", LoadBackendTorch, redis.Error("ERR error loading backend: backend already loaded"), false, false, []string{"INFO", "AI.CONFIG"}},
+		{"load-error", LoadBackendTorch, redis.Error("ERR error loading backend"), false, true, []string{"INFO", "AI.CONFIG"}},
+		{"unknown", "CAFFE", nil, false, true, []string{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, conn := createFakeClient(func(cmd string, args []interface{}) (interface{}, error) {
+				if cmd == "INFO" {
+					return []byte(testInfoModules), nil
+				}
+				return "OK", tt.loadErr
+			})
+			loaded, err := c.EnsureBackend(tt.backend)
+			assert.Equal(t, tt.wantErr, err != nil)
+			assert.Equal(t, tt.wantLoaded, loaded)
+			assert.Equal(t, tt.wantCmds, conn.CommandNames())
+			if tt.wantLoaded {
+				assert.Equal(t, []interface{}{"AI.CONFIG", "LOADBACKEND", "TORCH", "redisai_torch/redisai_torch.so"}, conn.Commands()[1])
+			}
+		})
+	}
+}
@@ -1,6 +1,10 @@
 package redisai
 
-import "github.com/gomodule/redigo/redis"
+import (
+	"fmt"
+
+	"github.com/gomodule/redigo/redis"
+)
 
 // DagCommandInterface is an interface that represents the skeleton of DAG supported commands
 // needed to map it to a RedisAI DAGRUN and DAGURN_RO commands
@@ -16,12 +20,87 @@ type DagCommandInterface interface {
 
 type Dag struct {
 	commands []redis.Args
+
+	// loadKeys holds the tensors consumed by the DAG's operations that are not produced within the DAG
+	loadKeys []string
+	// produced holds the tensors produced within the DAG
+	produced map[string]bool
+	// persistKeys holds the produced tensors that were marked to be persisted
+	persistKeys []string
+	// routing holds the key of the first model or script referenced by the DAG
+	routing string
+	// variants holds, by command position, the forms of the model and script commands
+	variants map[int]dagVariant
+}
+
+// dagVariant holds the forms of a model or script command within AI.DAGRUN and AI.DAGEXECUTE
+type dagVariant struct {
+	// run is the AI.MODELRUN or AI.SCRIPTRUN form, nil when the command relies on a feature they lack
+	run redis.Args
+	// feature is the feature lacking from the run form
+	feature string
+	// execute is the AI.MODELEXECUTE or AI.SCRIPTEXECUTE form
+	execute redis.Args
 }
 
 func NewDag() *Dag {
 	return &Dag{
 		commands: make([]redis.Args, 0),
+		produced: make(map[string]bool),
+	}
+}
+
+// track records the tensors consumed and produced by an operation, and the model or script it runs
+func (d *Dag) track(inputs, outputs []string, runKey string) {
+	if d.produced == nil {
+		d.produced = make(map[string]bool)
+	}
+	for _, input := range inputs {
+		if !d.produced[input] && !containsString(d.loadKeys, input) {
+			d.loadKeys = append(d.loadKeys, input)
+		}
+	}
+	for _, output := range outputs {
+		d.produced[output] = true
+	}
+	if len(d.routing) == 0 {
+		d.routing = runKey
+	}
+}
+
+// Persist marks the tensors produced within the DAG that should be stored in the keyspace once the DAG completes
+func (d *Dag) Persist(outputs ...string) *Dag {
+	for _, output := range outputs {
+		if !containsString(d.persistKeys, output) {
+			d.persistKeys = append(d.persistKeys, output)
+		}
+	}
+	return d
+}
+
+// Keys infers the LOAD, PERSIST and ROUTING arguments of the DAG from its operations.
+// The load keys are the tensors consumed by the DAG that are not produced by any previous operation.
+// The returned slices are copies, owned by the caller.
+// The routing key is only returned when there are no load or persist keys, given RedisAI requires it
+// in that case, and is the key of the first model or script executed by the DAG, or the first key
+// accessed by the script when it is given some.
+func (d *Dag) Keys() (loadKeys, persistKeys []string, routing string, err error) {
+	for _, persistKey := range d.persistKeys {
+		if !d.produced[persistKey] {
+			err = fmt.Errorf("redisai.Dag.Keys: tensor %s is marked to be persisted but is not produced within the DAG", persistKey)
+			return
+		}
 	}
+	if len(d.loadKeys) > 0 {
+		loadKeys = append([]string{}, d.loadKeys...)
+	}
+	if len(d.persistKeys) > 0 {
+		persistKeys = append([]string{}, d.persistKeys...)
+	}
+	if len(loadKeys) == 0 && len(persistKeys) == 0 {
+		routing = d.routing
+	}
+	return
 }
 
 // TensorSet add TENSORSET command to DagCommandInterface
@@ -32,42 +111,98 @@ func (d *Dag) TensorSet(keyName, dt string, dims []int64, data interface{}) DagC
 		args = args.AddFlat(setFlatArgs)
 	}
 	d.commands = append(d.commands, args)
+	d.track(nil, []string{keyName}, "")
 	return d
 }
 
 // TensorGet add TENSORGET command to DagCommandInterface
 func (d *Dag) TensorGet(name, format string) DagCommandInterface {
 	d.commands = append(d.commands, redis.Args{"AI.TENSORGET", name, format})
+	d.track([]string{name}, nil, "")
 	return d
 }
 
-// ModelRun add MODELRUN command to DagCommandInterface
+// ModelRun add MODELRUN command to DagCommandInterface.
+// With capability detection, the command is issued as AI.MODELEXECUTE within AI.DAGEXECUTE.
 func (d *Dag) ModelRun(name string, inputs, outputs []string) DagCommandInterface {
 	args := redis.Args{"AI.MODELRUN"}
 	runFlatArgs := modelRunFlatArgs(name, inputs, outputs)
 	args = args.AddFlat(runFlatArgs)
+	d.addVariant(dagVariant{run: args, execute: redis.Args{"AI.MODELEXECUTE"}.AddFlat(modelExecuteFlatArgs(name, inputs, outputs, 0))})
 	d.commands = append(d.commands, args)
+	d.track(inputs, outputs, name)
 	return d
 }
 
-// ModelExecute add MODELEXECUTE command to DagCommandInterface
+// ModelExecute add MODELEXECUTE command to DagCommandInterface.
+// With capability detection, the command is issued as AI.MODELRUN within AI.DAGRUN, unless it has a timeout.
 func (d *Dag) ModelExecute(name string, inputs, outputs []string, timeout int64) DagCommandInterface {
 	args := redis.Args{"AI.MODELEXECUTE"}
 	runFlatArgs := modelExecuteFlatArgs(name, inputs, outputs, timeout)
 	args = args.AddFlat(runFlatArgs)
+	variant := dagVariant{execute: args}
+	if timeout > 0 {
+		variant.feature = "TIMEOUT"
+	} else {
+		variant.run = redis.Args{"AI.MODELRUN"}.AddFlat(modelRunFlatArgs(name, inputs, outputs))
+	}
+	d.addVariant(variant)
 	d.commands = append(d.commands, args)
+	d.track(inputs, outputs, name)
 	return d
 }
 
-// ScriptExecute add SCRIPTEXECUTE command to DagCommandInterface
+// ScriptExecute add SCRIPTEXECUTE command to DagCommandInterface.
+// With capability detection, the command is issued as AI.SCRIPTRUN within AI.DAGRUN, unless it has keys,
+// arguments or a timeout.
 func (d *Dag) ScriptExecute(name, fn string, inputKeys, inputTensors, inputArgs, outputs []string, timeout int64) DagCommandInterface {
 	args := redis.Args{"AI.SCRIPTEXECUTE"}
 	runFlatArgs := scriptExecuteFlatArgs(name, fn, inputKeys, inputTensors, inputArgs, outputs, timeout)
 	args = args.AddFlat(runFlatArgs)
+	variant := dagVariant{execute: args, feature: scriptRunUnsupportedFeature(inputKeys, inputArgs, timeout)}
+	if len(variant.feature) == 0 {
+		variant.run = redis.Args{"AI.SCRIPTRUN"}.AddFlat(scriptRunFlatArgs(name, fn, inputTensors, outputs))
+	}
+	d.addVariant(variant)
 	d.commands = append(d.commands, args)
+	// the keys accessed by the script may be of any type, or missing, so they are not loaded. They route the DAG
+	// to their shard instead
+	routingKey := name
+	if len(inputKeys) > 0 {
+		routingKey = inputKeys[0]
+	}
+	d.track(inputTensors, outputs, routingKey)
 	return d
 }
 
+// addVariant records the forms of the command about to be appended
+func (d *Dag) addVariant(variant dagVariant) {
+	if d.variants == nil {
+		d.variants = make(map[int]dagVariant)
+	}
+	d.variants[len(d.commands)] = variant
+}
+
+// flatArgsFor returns the arguments of the DAG with its model and script commands in the form matching
+// AI.DAGEXECUTE, or AI.DAGRUN, along with the feature of a command lacking from AI.DAGRUN, if any
+func (d *Dag) flatArgsFor(execute bool) (args redis.Args, feature string) {
+	for pos, command := range d.commands {
+		if variant, ok := d.variants[pos]; ok {
+			switch {
+			case execute:
+				command = variant.execute
+			case variant.run == nil:
+				return nil, variant.feature
+			default:
+				command = variant.run
+			}
+		}
+		args = args.Add("|>")
+		args = args.AddFlat(command)
+	}
+	return args, ""
+}
+
 func (d *Dag) FlatArgs() (redis.Args, error) {
 	args := redis.Args{}
 	for _, command := range d.commands {
@@ -80,3 +215,12 @@ func (d *Dag) FlatArgs() (redis.Args, error) {
 func (d *Dag) ParseReply(reply interface{}, err error) ([]interface{}, error) {
 	return redis.Values(reply, err)
 }
+
+func containsString(slice []string, value string) bool {
+	for _, v := range slice {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,74 @@
+package redisai
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestDag_Keys(t *testing.T) {
+	tests := []struct {
+		name            string
+		dag             *Dag
+		wantLoadKeys    []string
+		wantPersistKeys []string
+		wantRouting     string
+		wantErr         bool
+	}{
+		{"t_routing_from_model",
+			NewDag().TensorSet("a", TypeFloat32, []int64{1}, []float32{1.1}).TensorSet("b", TypeFloat32, []int64{1}, []float32{4.4}).ModelExecute("mymodel{1}", []string{"a", "b"}, []string{"mul"}, 0).TensorGet("mul", TensorContentTypeValues).(*Dag),
+			nil, nil, "mymodel{1}", false},
+		{"t_routing_from_script",
+			NewDag().TensorSet("a", TypeFloat32, []int64{1}, []float32{1.1}).ScriptExecute("myscript{1}", "bar", nil, []string{"a"}, nil, []string{"c"}, 0).(*Dag),
+			nil, nil, "myscript{1}", false},
+		{"t_routing_from_script_keys",
+			NewDag().TensorSet("a", TypeFloat32, []int64{1}, []float32{1.1}).ScriptExecute("myscript", "bar", []string{"key{1}"}, []string{"a"}, nil, []string{"c"}, 0).(*Dag),
+			nil, nil, "key{1}", false},
+		{"t_load_keys",
+			NewDag().TensorSet("a", TypeFloat32, []int64{1}, []float32{1.1}).ModelExecute("mymodel", []string{"a", "persisted", "persisted"}, []string{"mul"}, 0).TensorGet("other", TensorContentTypeValues).(*Dag),
+			[]string{"persisted", "other"}, nil, "", false},
+		{"t_load_keys_model_run",
+			NewDag().ModelRun("mymodel", []string{"a", "b"}, []string{"mul"}).TensorGet("mul", TensorContentTypeBlob).(*Dag),
+			[]string{"a", "b"}, nil, "", false},
+		{"t_persist",
+			NewDag().TensorSet("a", TypeFloat32, []int64{1}, []float32{1.1}).ModelExecute("mymodel", []string{"a"}, []string{"mul"}, 0).(*Dag).Persist("mul", "mul"),
+			nil, []string{"mul"}, "", false},
+		{"t_persist_not_produced",
+			NewDag().TensorSet("a", TypeFloat32, []int64{1}, []float32{1.1}).(*Dag).Persist("b"),
+			nil, nil, "", true},
+		{"t_zero_value", &Dag{}, nil, nil, "", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gotLoadKeys, gotPersistKeys, gotRouting, err := tt.dag.Keys()
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Keys() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if tt.wantErr {
+				return
+			}
+			if !reflect.DeepEqual(gotLoadKeys, tt.wantLoadKeys) {
+				t.Errorf("Keys() gotLoadKeys = %v, want %v", gotLoadKeys, tt.wantLoadKeys)
+			}
+			if !reflect.DeepEqual(gotPersistKeys, tt.wantPersistKeys) {
+				t.Errorf("Keys() gotPersistKeys = %v, want %v", gotPersistKeys, tt.wantPersistKeys)
+			}
+			if gotRouting != tt.wantRouting {
+				t.Errorf("Keys() gotRouting = %v, want %v", gotRouting, tt.wantRouting)
+			}
+		})
+	}
+}
+
+func TestDag_Keys_Copies(t *testing.T) {
+	dag := NewDag().ModelExecute("mymodel", []string{"a"}, []string{"b"}, 0).(*Dag).Persist("b")
+	loadKeys, persistKeys, _, err := dag.Keys()
+	if err != nil {
+		t.Fatal(err)
+	}
+	loadKeys[0], persistKeys[0] = "modified", "modified"
+	loadKeys, persistKeys, _, _ = dag.Keys()
+	if !reflect.DeepEqual(loadKeys, []string{"a"}) || !reflect.DeepEqual(persistKeys, []string{"b"}) {
+		t.Errorf("Keys() = %v, %v, want the DAG state unmodified", loadKeys, persistKeys)
+	}
+}
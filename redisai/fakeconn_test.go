@@ -0,0 +1,10 @@
+package redisai
+
+import (
+	"github.com/RedisAI/redisai-go/redisai/internal/redistest"
+)
+
+func createFakeClient(handler redistest.Handler) (*Client, *redistest.Conn) {
+	pool, conn := redistest.NewPool(handler)
+	return Connect("", pool), conn
+}
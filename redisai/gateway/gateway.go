@@ -0,0 +1,231 @@
+// Package gateway exposes RedisAI model and script execution over HTTP with JSON payloads.
+//
+// The Handler serves the following routes:
+//
+//	POST /v1/models/{key}/execute   runs the model stored at key
+//	POST /v1/scripts/{key}/execute  runs an entry point of the script stored at key
+//	GET  /v1/models/{key}           returns the model metadata, without its blob
+//	GET  /healthz                   replies with 200 when the RedisAI server answers to PING
+//
+// Executions are issued as a single AI.DAGEXECUTE without PERSIST through a redisai.Predictor,
+// so no tensor is written to the keyspace. The request bodies larger than the Handler's MaxBodyBytes are
+// rejected with 413 Request Entity Too Large.
+package gateway
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/RedisAI/redisai-go/redisai"
+	"github.com/RedisAI/redisai-go/redisai/implementations"
+	"github.com/RedisAI/redisai-go/redisai/internal/httpjson"
+	"github.com/gomodule/redigo/redis"
+)
+
+// DefaultMaxBodyBytes is the default limit of the request body size
+const DefaultMaxBodyBytes = httpjson.DefaultMaxBodyBytes
+
+// Tensor is the JSON representation of a tensor.
+// The content is given either as Values or as a base64, little-endian encoded Blob.
+type Tensor struct {
+	Name   string        `json:"name"`
+	Dtype  string        `json:"dtype"`
+	Shape  []int64       `json:"shape"`
+	Values []json.Number `json:"values,omitempty"`
+	Blob   string        `json:"blob,omitempty"`
+}
+
+// ExecuteRequest is the body of the execute routes
+type ExecuteRequest struct {
+	// Function is the script entry point. It is ignored for models
+	Function string   `json:"function,omitempty"`
+	Inputs   []Tensor `json:"inputs"`
+	Outputs  []string `json:"outputs"`
+	// Timeout is the execution timeout in milliseconds
+	Timeout int64 `json:"timeout,omitempty"`
+	// Format of the output tensors, either "values" (default) or "blob"
+	Format string `json:"format,omitempty"`
+}
+
+// ExecuteResponse is the reply of the execute routes
+type ExecuteResponse struct {
+	Outputs []Tensor `json:"outputs"`
+}
+
+// ErrorResponse is the reply of every route on failure
+type ErrorResponse = httpjson.ErrorResponse
+
+// Handler is an http.Handler serving the gateway routes. It is safe for concurrent use,
+// each request using its own redisai.Client on top of the shared pool.
+type Handler struct {
+	Pool *redis.Pool
+	// MaxBodyBytes is the size limit of the request bodies. Zero or negative means no limit
+	MaxBodyBytes int64
+	mux          *http.ServeMux
+}
+
+// NewHandler returns a Handler issuing its commands through connections of pool, and accepting request bodies
+// of up to DefaultMaxBodyBytes
+func NewHandler(pool *redis.Pool) *Handler {
+	h := &Handler{Pool: pool, MaxBodyBytes: DefaultMaxBodyBytes, mux: http.NewServeMux()}
+	h.mux.HandleFunc("/v1/models/", h.serveModel)
+	h.mux.HandleFunc("/v1/scripts/", h.serveScript)
+	h.mux.HandleFunc("/healthz", h.serveHealth)
+	return h
+}
+
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	h.mux.ServeHTTP(w, r)
+}
+
+func (h *Handler) serveModel(w http.ResponseWriter, r *http.Request) {
+	key := strings.TrimPrefix(r.URL.Path, "/v1/models/")
+	if strings.HasSuffix(key, "/execute") {
+		h.serveExecute(w, r, strings.TrimSuffix(key, "/execute"), false)
+		return
+	}
+	if r.Method != http.MethodGet {
+		httpjson.WriteError(w, http.StatusMethodNotAllowed, fmt.Errorf("method %s not allowed", r.Method))
+		return
+	}
+	if !validKey(key) {
+		httpjson.WriteError(w, http.StatusNotFound, fmt.Errorf("unknown route %s", r.URL.Path))
+		return
+	}
+	client := redisai.Connect("", h.Pool)
+	defer client.Close()
+	meta, err := client.ModelGetMeta(key)
+	if err != nil {
+		httpjson.WriteError(w, httpjson.StatusFromError(err), err)
+		return
+	}
+	httpjson.WriteJSON(w, http.StatusOK, meta)
+}
+
+func (h *Handler) serveScript(w http.ResponseWriter, r *http.Request) {
+	key := strings.TrimPrefix(r.URL.Path, "/v1/scripts/")
+	if !strings.HasSuffix(key, "/execute") {
+		httpjson.WriteError(w, http.StatusNotFound, fmt.Errorf("unknown route %s", r.URL.Path))
+		return
+	}
+	h.serveExecute(w, r, strings.TrimSuffix(key, "/execute"), true)
+}
+
+func (h *Handler) serveExecute(w http.ResponseWriter, r *http.Request, key string, script bool) {
+	if r.Method != http.MethodPost {
+		httpjson.WriteError(w, http.StatusMethodNotAllowed, fmt.Errorf("method %s not allowed", r.Method))
+		return
+	}
+	if !validKey(key) {
+		httpjson.WriteError(w, http.StatusNotFound, fmt.Errorf("unknown route %s", r.URL.Path))
+		return
+	}
+	var request ExecuteRequest
+	if !httpjson.DecodeBody(w, r, h.MaxBodyBytes, &request) {
+		return
+	}
+	if script && len(request.Function) == 0 {
+		httpjson.WriteError(w, http.StatusBadRequest, fmt.Errorf("missing script function"))
+		return
+	}
+	inputNames := make([]string, len(request.Inputs))
+	inputs := make(map[string]redisai.TensorInterface, len(request.Inputs))
+	for pos, input := range request.Inputs {
+		tensor, err := input.ToTensor()
+		if err != nil {
+			httpjson.WriteError(w, http.StatusBadRequest, err)
+			return
+		}
+		if _, ok := inputs[input.Name]; ok {
+			httpjson.WriteError(w, http.StatusBadRequest, fmt.Errorf("duplicate input tensor %s", input.Name))
+			return
+		}
+		inputNames[pos] = input.Name
+		inputs[input.Name] = tensor
+	}
+
+	client := redisai.Connect("", h.Pool)
+	defer client.Close()
+	predictor := redisai.NewModelPredictor(client, key, inputNames, request.Outputs)
+	if script {
+		predictor = redisai.NewScriptPredictor(client, key, request.Function, inputNames, request.Outputs)
+	}
+	predictor.Timeout = request.Timeout
+	outputs, err := predictor.Predict(inputs)
+	if err != nil {
+		httpjson.WriteError(w, httpjson.StatusFromError(err), err)
+		return
+	}
+	response := ExecuteResponse{Outputs: make([]Tensor, len(request.Outputs))}
+	for pos, name := range request.Outputs {
+		if response.Outputs[pos], err = FromTensor(name, outputs[name], request.Format == "blob"); err != nil {
+			httpjson.WriteError(w, http.StatusInternalServerError, err)
+			return
+		}
+	}
+	httpjson.WriteJSON(w, http.StatusOK, response)
+}
+
+// validKey reports whether key is a model or script key served by the routes: a non-empty path segment
+func validKey(key string) bool {
+	return len(key) > 0 && !strings.Contains(key, "/")
+}
+
+func (h *Handler) serveHealth(w http.ResponseWriter, r *http.Request) {
+	conn := h.Pool.Get()
+	defer conn.Close()
+	if _, err := conn.Do("PING"); err != nil {
+		httpjson.WriteError(w, http.StatusServiceUnavailable, err)
+		return
+	}
+	httpjson.WriteJSON(w, http.StatusOK, map[string]string{"status": "ok"})
+}
+
+// ToTensor decodes the JSON tensor into a redisai.TensorInterface
+func (t Tensor) ToTensor() (redisai.TensorInterface, error) {
+	var data interface{}
+	var err error
+	if len(t.Blob) > 0 {
+		var blob []byte
+		if blob, err = base64.StdEncoding.DecodeString(t.Blob); err != nil {
+			return nil, fmt.Errorf("tensor %s: invalid blob: %v", t.Name, err)
+		}
+		data, err = redisai.TensorDataFromBlob(t.Dtype, blob)
+	} else {
+		values := make([]string, len(t.Values))
+		for pos, value := range t.Values {
+			values[pos] = value.String()
+		}
+		data, err = redisai.TensorDataFromStrings(t.Dtype, values)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("tensor %s: %v", t.Name, err)
+	}
+	return implementations.NewAiTensorWithData(t.Dtype, t.Shape, data), nil
+}
+
+// FromTensor encodes the tensor in its JSON representation, either as values or as a base64 blob
+func FromTensor(name string, tensor redisai.TensorInterface, blob bool) (t Tensor, err error) {
+	t.Name = name
+	t.Shape = tensor.Shape()
+	if t.Dtype, err = redisai.TensorGetTypeStrFromType(tensor.Dtype()); err != nil {
+		return
+	}
+	if blob {
+		var data []byte
+		if data, err = redisai.TensorDataToBlob(tensor.Data()); err != nil {
+			return
+		}
+		t.Blob = base64.StdEncoding.EncodeToString(data)
+		return
+	}
+	var values []byte
+	if values, err = json.Marshal(httpjson.TensorValues(tensor.Data())); err != nil {
+		return
+	}
+	err = json.Unmarshal(values, &t.Values)
+	return
+}
@@ -0,0 +1,144 @@
+package gateway
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/RedisAI/redisai-go/redisai"
+	"github.com/RedisAI/redisai-go/redisai/internal/redistest"
+	"github.com/gomodule/redigo/redis"
+	"github.com/stretchr/testify/assert"
+)
+
+func tensorReply(dtype string, shape []interface{}, values []interface{}) []interface{} {
+	return []interface{}{[]byte("dtype"), []byte(dtype), []byte("shape"), shape, []byte("values"), values}
+}
+
+func serve(h http.Handler, method, path, body string) (*httptest.ResponseRecorder, map[string]interface{}) {
+	recorder := httptest.NewRecorder()
+	h.ServeHTTP(recorder, httptest.NewRequest(method, path, strings.NewReader(body)))
+	var decoded map[string]interface{}
+	_ = json.Unmarshal(recorder.Body.Bytes(), &decoded)
+	return recorder, decoded
+}
+
+// dagReply replies OK to every DAG operation but the last one, replied with tensor
+func dagReply(args []interface{}, tensor interface{}) []interface{} {
+	var reply []interface{}
+	for _, arg := range args {
+		if arg == "|>" {
+			reply = append(reply, "OK")
+		}
+	}
+	reply[len(reply)-1] = tensor
+	return reply
+}
+
+func TestHandler_Execute(t *testing.T) {
+	pool, conn := redistest.NewPool(func(cmd string, args []interface{}) (interface{}, error) {
+		return dagReply(args, tensorReply(redisai.TypeFloat, []interface{}{int64(2)}, []interface{}{[]byte("1.5"), []byte("2")})), nil
+	})
+	h := NewHandler(pool)
+
+	recorder, body := serve(h, http.MethodPost, "/v1/models/mymodel{1}/execute",
+		`{"inputs":[{"name":"a","dtype":"FLOAT","shape":[2],"values":[1,2]},{"name":"b","dtype":"FLOAT","shape":[2],"blob":"AADAPwAAAEA="}],"outputs":["c"]}`)
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	assert.Equal(t, []interface{}{map[string]interface{}{"name": "c", "dtype": "FLOAT", "shape": []interface{}{2.0}, "values": []interface{}{1.5, 2.0}}}, body["outputs"])
+	command := conn.Commands()[0]
+	assert.Equal(t, []interface{}{"AI.DAGEXECUTE", "ROUTING", "mymodel{1}"}, command[:3])
+
+	recorder, body = serve(h, http.MethodPost, "/v1/models/mymodel/execute", `{"inputs":[{"name":"a","dtype":"FLOAT","shape":[2],"values":[1,2]}],"outputs":["c"],"format":"blob"}`)
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	assert.Equal(t, "AADAPwAAAEA=", body["outputs"].([]interface{})[0].(map[string]interface{})["blob"])
+
+	recorder, _ = serve(h, http.MethodPost, "/v1/scripts/myscript/execute", `{"function":"bar","inputs":[{"name":"a","dtype":"FLOAT","shape":[1],"values":[1]}],"outputs":["c"]}`)
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	assert.Contains(t, conn.Commands()[2], "AI.SCRIPTEXECUTE")
+}
+
+func TestHandler_Execute_Errors(t *testing.T) {
+	pool, _ := redistest.NewPool(func(cmd string, args []interface{}) (interface{}, error) {
+		return nil, redis.Error("ERR model key is empty")
+	})
+	h := NewHandler(pool)
+	tests := []struct {
+		name       string
+		method     string
+		path       string
+		body       string
+		wantStatus int
+	}{
+		{"method", http.MethodGet, "/v1/models/mymodel/execute", "", http.StatusMethodNotAllowed},
+		{"body", http.MethodPost, "/v1/models/mymodel/execute", "{", http.StatusBadRequest},
+		{"dtype", http.MethodPost, "/v1/models/mymodel/execute", `{"inputs":[{"name":"a","dtype":"BOOL","shape":[1],"values":[1]}]}`, http.StatusBadRequest},
+		{"duplicate", http.MethodPost, "/v1/models/mymodel/execute", `{"inputs":[{"name":"a","dtype":"FLOAT","shape":[1],"values":[1]},{"name":"a","dtype":"FLOAT","shape":[1],"values":[1]}]}`, http.StatusBadRequest},
+		{"function", http.MethodPost, "/v1/scripts/myscript/execute", `{"inputs":[]}`, http.StatusBadRequest},
+		{"not-found", http.MethodPost, "/v1/models/mymodel/execute", `{"inputs":[{"name":"a","dtype":"FLOAT","shape":[1],"values":[1]}],"outputs":["b"]}`, http.StatusNotFound},
+		{"route", http.MethodGet, "/v1/scripts/myscript", "", http.StatusNotFound},
+		{"key", http.MethodPost, "/v1/models/my/model/execute", `{"inputs":[],"outputs":["b"]}`, http.StatusNotFound},
+		{"hash-tag", http.MethodPost, "/v1/models/my}model/execute", `{"inputs":[],"outputs":["b"]}`, http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			recorder, body := serve(h, tt.method, tt.path, tt.body)
+			assert.Equal(t, tt.wantStatus, recorder.Code)
+			assert.NotEmpty(t, body["error"])
+		})
+	}
+}
+
+func TestHandler_Execute_MaxBodyBytes(t *testing.T) {
+	pool, conn := redistest.NewPool(func(cmd string, args []interface{}) (interface{}, error) {
+		return dagReply(args, tensorReply(redisai.TypeFloat, []interface{}{int64(1)}, []interface{}{[]byte("1")})), nil
+	})
+	h := NewHandler(pool)
+	body := `{"inputs":[{"name":"a","dtype":"FLOAT","shape":[1],"values":[1]}],"outputs":["c"]}`
+	h.MaxBodyBytes = int64(len(body))
+	recorder, _ := serve(h, http.MethodPost, "/v1/models/mymodel/execute", body)
+	assert.Equal(t, http.StatusOK, recorder.Code)
+
+	h.MaxBodyBytes--
+	recorder, decoded := serve(h, http.MethodPost, "/v1/models/mymodel/execute", body)
+	assert.Equal(t, http.StatusRequestEntityTooLarge, recorder.Code)
+	assert.NotEmpty(t, decoded["error"])
+
+	// without Content-Length
+	recorder = httptest.NewRecorder()
+	request := httptest.NewRequest(http.MethodPost, "/v1/models/mymodel/execute", strings.NewReader(body))
+	request.ContentLength = -1
+	h.ServeHTTP(recorder, request)
+	assert.Equal(t, http.StatusRequestEntityTooLarge, recorder.Code)
+	assert.Len(t, conn.Commands(), 1)
+}
+
+func TestHandler_Model(t *testing.T) {
+	pool, conn := redistest.NewPool(func(cmd string, args []interface{}) (interface{}, error) {
+		return []interface{}{[]byte("backend"), []byte("TF"), []byte("device"), []byte("CPU"), []byte("tag"), []byte(""), []byte("batchsize"), int64(0),
+			[]byte("minbatchsize"), int64(0), []byte("inputs"), []interface{}{[]byte("a")}, []byte("outputs"), []interface{}{[]byte("b")}, []byte("minbatchtimeout"), int64(0)}, nil
+	})
+	recorder, body := serve(NewHandler(pool), http.MethodGet, "/v1/models/mymodel", "")
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	assert.Equal(t, map[string]interface{}{"backend": "TF", "device": "CPU", "tag": "", "batchsize": 0.0, "minbatchsize": 0.0,
+		"minbatchtimeout": 0.0, "inputs": []interface{}{"a"}, "outputs": []interface{}{"b"}}, body)
+	assert.Equal(t, []interface{}{"AI.MODELGET", "mymodel", "META"}, conn.Commands()[0])
+}
+
+func TestHandler_Health(t *testing.T) {
+	healthy := true
+	pool, _ := redistest.NewPool(func(cmd string, args []interface{}) (interface{}, error) {
+		if !healthy {
+			return nil, errors.New("connection refused")
+		}
+		return "PONG", nil
+	})
+	h := NewHandler(pool)
+	recorder, _ := serve(h, http.MethodGet, "/healthz", "")
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	healthy = false
+	recorder, _ = serve(h, http.MethodGet, "/healthz", "")
+	assert.Equal(t, http.StatusServiceUnavailable, recorder.Code)
+}
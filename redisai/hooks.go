@@ -0,0 +1,224 @@
+package redisai
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gomodule/redigo/redis"
+)
+
+// CommandInfo describes a command issued by the client, as passed to the command hooks
+type CommandInfo struct {
+	// Name is the command name, such as AI.MODELEXECUTE
+	Name string
+	// Key is the key the command is routed to: the tensor, model or script key, or the ROUTING, LOAD or PERSIST
+	// key of a DAG. Empty for the commands without keys
+	Key string
+	// Model is the model run or accessed by the command, or the first model run by a DAG
+	Model string
+	// Script is the script run or accessed by the command, or the first script run by a DAG
+	Script string
+	// DagOps is the number of operations of a DAG command, 0 otherwise
+	DagOps int
+	// PayloadSize is the size in bytes of the arguments of the command as sent to the server, where the numbers
+	// are sent in their decimal form
+	PayloadSize int
+	// Pipelined reports whether the command was queued on a pipelined client, in which case Duration only
+	// accounts for the queuing and Err for the queuing errors
+	Pipelined bool
+
+	// Duration is the time taken by the command, once admitted by the admission control. Set before AfterCommand
+	Duration time.Duration
+	// QueueWait is the time the execution waited for its admission, zero for the commands not under admission
+	// control. Set before AfterCommand
+	QueueWait time.Duration
+	// Err is the error of the command. Set before AfterCommand
+	Err error
+
+	args redis.Args
+}
+
+// CommandHook is invoked before and after every command issued by the client.
+//
+// BeforeCommand returns the context passed to AfterCommand, which allows tracers to carry the span of the command.
+// The hooks are invoked synchronously, BeforeCommand in registration order and AfterCommand in reverse order.
+type CommandHook interface {
+	BeforeCommand(ctx context.Context, info *CommandInfo) context.Context
+	AfterCommand(ctx context.Context, info *CommandInfo)
+}
+
+// AddHook registers a hook invoked before and after every command issued by the client
+func (c *Client) AddHook(hook CommandHook) {
+	c.hooks = append(c.hooks, hook)
+}
+
+// RunWithContext runs fn with ctx as the context of the commands issued through c, which is passed to the command
+// hooks so that their spans are children of the ctx ones, and to the admission control. The context of c is restored
+// once fn returns, and c keeps its connection, pipeline, hooks and capabilities, since no copy of it is made.
+func (c *Client) RunWithContext(ctx context.Context, fn func() error) error {
+	previous := c.ctx
+	c.ctx = ctx
+	defer func() {
+		c.ctx = previous
+	}()
+	return fn()
+}
+
+// DoOrSendContext is DoOrSend on behalf of ctx, see RunWithContext
+func (c *Client) DoOrSendContext(ctx context.Context, cmdName string, args redis.Args, errIn error) (reply interface{}, err error) {
+	err = c.RunWithContext(ctx, func() error {
+		reply, err = c.DoOrSend(cmdName, args, errIn)
+		return err
+	})
+	return reply, err
+}
+
+// Context returns the context of the commands issued by the client, context.Background() outside of RunWithContext
+func (c *Client) Context() context.Context {
+	if c.ctx == nil {
+		return context.Background()
+	}
+	return c.ctx
+}
+
+// doWithHooks issues the command through the command hooks, and the executions through the admission control and
+// circuit breaker if any. A command that could not be built, as reported by errIn, is only reported to the hooks
+func (c *Client) doWithHooks(cmdName string, args redis.Args, errIn error) (reply interface{}, err error) {
+	info := newCommandInfo(cmdName, args)
+	info.Pipelined = c.PipelineActive
+	contexts := make([]context.Context, len(c.hooks))
+	ctx := c.Context()
+	for pos, hook := range c.hooks {
+		ctx = hook.BeforeCommand(ctx, info)
+		contexts[pos] = ctx
+	}
+	switch key := executionKey(info); {
+	case errIn != nil:
+		err = errIn
+	case len(key) > 0:
+		reply, err = c.execute(info, key, cmdName, args)
+	default:
+		start := time.Now()
+		reply, err = c.do(cmdName, args)
+		info.Duration = time.Since(start)
+	}
+	info.Err = err
+	for pos := len(c.hooks) - 1; pos >= 0; pos-- {
+		c.hooks[pos].AfterCommand(contexts[pos], info)
+	}
+	return reply, err
+}
+
+// execute issues the execution of the model or script key, once admitted by the admission control and let through
+// by the circuit breaker, and sets the QueueWait and Duration of info
+func (c *Client) execute(info *CommandInfo, key, cmdName string, args redis.Args) (reply interface{}, err error) {
+	if c.admission != nil {
+		start := time.Now()
+		release, err := c.admission.acquire(c.Context(), key, c.admissionClass)
+		info.QueueWait = time.Since(start)
+		if err != nil {
+			return nil, err
+		}
+		defer release()
+	}
+	if c.breaker != nil {
+		if err = c.breaker.allow(key, !c.PipelineActive); err != nil {
+			return nil, err
+		}
+	}
+	start := time.Now()
+	reply, err = c.do(cmdName, args)
+	info.Duration = time.Since(start)
+	if c.breaker != nil && !c.PipelineActive {
+		c.breaker.record(key, reply, err)
+	}
+	return reply, err
+}
+
+// newCommandInfo describes a command from its arguments
+func newCommandInfo(cmdName string, args redis.Args) *CommandInfo {
+	info := &CommandInfo{Name: cmdName, args: args}
+	for _, arg := range args {
+		info.PayloadSize += argSize(arg)
+	}
+	first := ""
+	if len(args) > 0 {
+		first, _ = args[0].(string)
+	}
+	name := strings.ToUpper(cmdName)
+	switch {
+	case strings.HasPrefix(name, "AI.DAG"):
+		commandInfoDag(info, args)
+	case strings.HasPrefix(name, "AI.MODEL"):
+		info.Key, info.Model = first, first
+	case strings.HasPrefix(name, "AI.SCRIPT"):
+		info.Key, info.Script = first, first
+	case strings.HasPrefix(name, "AI.TENSOR"):
+		info.Key = first
+	}
+	return info
+}
+
+// argSize returns the size of an argument as written by redigo, which formats the other types than strings,
+// blobs, integers, floats and booleans with fmt
+func argSize(arg interface{}) int {
+	switch value := arg.(type) {
+	case string:
+		return len(value)
+	case []byte:
+		return len(value)
+	case int:
+		return len(strconv.FormatInt(int64(value), 10))
+	case int64:
+		return len(strconv.FormatInt(value, 10))
+	case float64:
+		return len(strconv.FormatFloat(value, 'g', -1, 64))
+	case bool:
+		return 1
+	case nil:
+		return 0
+	case redis.Argument:
+		return len(fmt.Sprint(value.RedisArg()))
+	}
+	return len(fmt.Sprint(arg))
+}
+
+// commandInfoDag sets the key, operations count and first model and script of a DAG command
+func commandInfoDag(info *CommandInfo, args redis.Args) {
+	for pos := 0; pos < len(args); pos++ {
+		arg, _ := args[pos].(string)
+		if arg == "|>" {
+			info.DagOps++
+			if pos+2 >= len(args) {
+				continue
+			}
+			op, _ := args[pos+1].(string)
+			name, _ := args[pos+2].(string)
+			switch op {
+			case "AI.MODELRUN", "AI.MODELEXECUTE":
+				if len(info.Model) == 0 {
+					info.Model = name
+				}
+			case "AI.SCRIPTRUN", "AI.SCRIPTEXECUTE":
+				if len(info.Script) == 0 {
+					info.Script = name
+				}
+			}
+			continue
+		}
+		if info.DagOps > 0 || len(info.Key) > 0 || pos+1 >= len(args) {
+			continue
+		}
+		switch arg {
+		case "ROUTING":
+			info.Key, _ = args[pos+1].(string)
+		case "LOAD", "PERSIST":
+			if pos+2 < len(args) {
+				info.Key, _ = args[pos+2].(string)
+			}
+		}
+	}
+}
@@ -0,0 +1,130 @@
+package redisai
+
+import (
+	"context"
+	"testing"
+
+	"github.com/gomodule/redigo/redis"
+	"github.com/stretchr/testify/assert"
+)
+
+type recordingHook struct {
+	name   string
+	events *[]string
+	infos  []CommandInfo
+}
+
+type hookKey struct{}
+
+func (h *recordingHook) BeforeCommand(ctx context.Context, info *CommandInfo) context.Context {
+	*h.events = append(*h.events, "before "+h.name)
+	return context.WithValue(ctx, hookKey{}, h.name)
+}
+
+func (h *recordingHook) AfterCommand(ctx context.Context, info *CommandInfo) {
+	*h.events = append(*h.events, "after "+ctx.Value(hookKey{}).(string))
+	h.infos = append(h.infos, *info)
+}
+
+func TestClient_AddHook(t *testing.T) {
+	c, _ := createFakeClient(func(cmd string, args []interface{}) (interface{}, error) {
+		if cmd == "AI.MODELEXECUTE" {
+			return nil, redis.Error("ERR model key is empty")
+		}
+		return "OK", nil
+	})
+	var events []string
+	first, second := &recordingHook{name: "first", events: &events}, &recordingHook{name: "second", events: &events}
+	c.AddHook(first)
+	c.AddHook(second)
+
+	assert.Nil(t, c.TensorSet("a", TypeFloat32, []int64{2}, []byte("blob")))
+	assert.NotNil(t, c.ModelExecute("mymodel", []string{"a"}, []string{"b"}))
+	assert.Equal(t, []string{"before first", "before second", "after second", "after first",
+		"before first", "before second", "after second", "after first"}, events)
+	assert.Equal(t, first.infos, second.infos)
+	assert.Equal(t, CommandInfo{Name: "AI.TENSORSET", Key: "a", PayloadSize: len("a") + len(TypeFloat32) + len("2") + len("BLOB") + len("blob")}, withoutDuration(first.infos[0]))
+	assert.Equal(t, "mymodel", first.infos[1].Model)
+	assert.Equal(t, redis.Error("ERR model key is empty"), first.infos[1].Err)
+
+	ctx := context.WithValue(context.Background(), hookKey{}, "request")
+	assert.Nil(t, c.RunWithContext(ctx, func() error {
+		assert.Equal(t, ctx, c.Context())
+		return nil
+	}))
+	assert.Equal(t, context.Background(), c.Context())
+}
+
+func TestClient_RunWithContext(t *testing.T) {
+	c, conn := createFakeClient(func(cmd string, args []interface{}) (interface{}, error) {
+		return "OK", nil
+	})
+	var events []string
+	hook := &recordingHook{name: "hook", events: &events}
+	ctx := context.WithValue(context.Background(), hookKey{}, "request")
+
+	// the commands issued within RunWithContext share the pipeline and hooks of the client
+	c.Pipeline(0)
+	assert.Nil(t, c.RunWithContext(ctx, func() error {
+		c.AddHook(hook)
+		return c.TensorSet("a", TypeFloat32, []int64{1}, []byte("blob"))
+	}))
+	assert.Equal(t, uint32(1), c.PipelinePos)
+	_, err := c.DoOrSendContext(ctx, "PING", nil, nil)
+	assert.Nil(t, err)
+	assert.Equal(t, uint32(2), c.PipelinePos)
+	assert.Nil(t, c.DisablePipeline())
+	assert.Equal(t, []string{"AI.TENSORSET", "PING"}, conn.CommandNames())
+	assert.Equal(t, []string{"before hook", "after hook", "before hook", "after hook"}, events)
+	assert.Equal(t, context.Background(), c.Context())
+}
+
+func TestClient_AddHook_Pipelined(t *testing.T) {
+	c, conn := createFakeClient(func(cmd string, args []interface{}) (interface{}, error) {
+		return "OK", nil
+	})
+	var events []string
+	hook := &recordingHook{name: "hook", events: &events}
+	c.AddHook(hook)
+	c.Pipeline(0)
+	assert.Nil(t, c.ModelRun("mymodel", []string{"a"}, []string{"b"}))
+	assert.Nil(t, c.Flush())
+	assert.True(t, hook.infos[0].Pipelined)
+	assert.Equal(t, []string{"AI.MODELEXECUTE"}, conn.CommandNames())
+}
+
+func TestNewCommandInfo(t *testing.T) {
+	dag := NewDag().TensorSet("a", TypeFloat32, []int64{1}, []float32{1}).
+		ScriptExecute("myscript", "f", nil, []string{"a"}, nil, []string{"b"}, 0).
+		ModelExecute("mymodel", []string{"b"}, []string{"c"}, 0).TensorGet("c", TensorContentTypeValues)
+	dagArgs, _ := dag.FlatArgs()
+	tests := []struct {
+		name string
+		cmd  string
+		args redis.Args
+		want CommandInfo
+	}{
+		{"model", "AI.MODELGET", modelGetFlatArgs("mymodel"), CommandInfo{Name: "AI.MODELGET", Key: "mymodel", Model: "mymodel", PayloadSize: 15}},
+		{"script", "AI.SCRIPTDEL", redis.Args{"myscript"}, CommandInfo{Name: "AI.SCRIPTDEL", Key: "myscript", Script: "myscript", PayloadSize: 8}},
+		{"dag-routing", "AI.DAGEXECUTE", AddDagExecuteArgs(nil, nil, "mymodel", 0, dagArgs), CommandInfo{Name: "AI.DAGEXECUTE", Key: "mymodel", Model: "mymodel", Script: "myscript", DagOps: 4}},
+		{"dag-load", "AI.DAGRUN", AddDagRunArgs([]string{"x"}, []string{"c"}, dagArgs), CommandInfo{Name: "AI.DAGRUN", Key: "x", Model: "mymodel", Script: "myscript", DagOps: 4}},
+		{"no-key", "INFO", redis.Args{"MODULES"}, CommandInfo{Name: "INFO", PayloadSize: 7}},
+		{"numbers", "AI.TENSORSET", redis.Args{"t", TypeFloat, int64(2), "VALUES", 1.5, float32(0.25), int32(-7), 10, true}, CommandInfo{Name: "AI.TENSORSET", Key: "t", PayloadSize: 1 + 5 + 1 + 6 + 3 + 4 + 2 + 2 + 1}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := *newCommandInfo(tt.cmd, tt.args)
+			got.args = nil
+			if tt.want.DagOps > 0 {
+				got.PayloadSize = 0
+			}
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func withoutDuration(info CommandInfo) CommandInfo {
+	info.Duration = 0
+	info.args = nil
+	return info
+}
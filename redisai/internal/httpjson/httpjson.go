@@ -0,0 +1,124 @@
+// Package httpjson provides the JSON request decoding and replies shared by the HTTP handlers of the gateway and kserve packages
+package httpjson
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/RedisAI/redisai-go/redisai"
+	"github.com/gomodule/redigo/redis"
+)
+
+// DefaultMaxBodyBytes is the default limit of the request body size
+const DefaultMaxBodyBytes = 32 << 20
+
+var errBodyTooLarge = errors.New("request body too large")
+
+// ErrorResponse is the reply of the handlers on failure
+type ErrorResponse struct {
+	Error string `json:"error"`
+}
+
+// StatusFromError maps RedisAI reply errors and the predictions rejected for their inputs to client errors, and
+// every other error to a bad gateway
+func StatusFromError(err error) int {
+	if _, ok := err.(*redisai.PredictorInputError); ok {
+		return http.StatusBadRequest
+	}
+	if _, ok := err.(redis.Error); ok {
+		message := strings.ToLower(err.Error())
+		if strings.Contains(message, "empty") || strings.Contains(message, "not exist") || strings.Contains(message, "not found") {
+			return http.StatusNotFound
+		}
+		return http.StatusBadRequest
+	}
+	return http.StatusBadGateway
+}
+
+// WriteError replies with status and an ErrorResponse holding err
+func WriteError(w http.ResponseWriter, status int, err error) {
+	WriteJSON(w, status, ErrorResponse{Error: err.Error()})
+}
+
+// WriteJSON replies with status and body encoded in JSON, or with an internal server error when body can't be encoded
+func WriteJSON(w http.ResponseWriter, status int, body interface{}) {
+	var buf bytes.Buffer
+	if err := json.NewEncoder(&buf).Encode(body); err != nil {
+		status = http.StatusInternalServerError
+		buf.Reset()
+		_ = json.NewEncoder(&buf).Encode(ErrorResponse{Error: err.Error()})
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_, _ = w.Write(buf.Bytes())
+}
+
+// DecodeBody decodes the JSON body of r into v, with the numbers of interface{} values decoded as json.Number.
+// Bodies larger than maxBytes are rejected with 413 Request Entity Too Large, and the invalid ones with 400 Bad Request.
+// Zero or negative maxBytes means no limit. DecodeBody reports whether v was decoded, the error being replied otherwise.
+func DecodeBody(w http.ResponseWriter, r *http.Request, maxBytes int64, v interface{}) bool {
+	errTooLarge := fmt.Errorf("request body exceeds %d bytes", maxBytes)
+	body := &limitedReader{r: r.Body, remaining: maxBytes + 1}
+	if maxBytes <= 0 {
+		body.remaining = -1
+	} else if r.ContentLength > maxBytes {
+		WriteError(w, http.StatusRequestEntityTooLarge, errTooLarge)
+		return false
+	}
+	decoder := json.NewDecoder(body)
+	decoder.UseNumber()
+	err := decoder.Decode(v)
+	if body.exceeded {
+		WriteError(w, http.StatusRequestEntityTooLarge, errTooLarge)
+		return false
+	}
+	if err != nil {
+		WriteError(w, http.StatusBadRequest, fmt.Errorf("invalid request body: %v", err))
+		return false
+	}
+	return true
+}
+
+// limitedReader fails once more than its limit, remaining-1 bytes initially, is read, and records it in exceeded.
+// A negative remaining means no limit
+type limitedReader struct {
+	r         io.Reader
+	remaining int64
+	exceeded  bool
+}
+
+func (l *limitedReader) Read(p []byte) (n int, err error) {
+	if l.remaining < 0 {
+		return l.r.Read(p)
+	}
+	if l.remaining == 0 {
+		return 0, errBodyTooLarge
+	}
+	if int64(len(p)) > l.remaining {
+		p = p[:l.remaining]
+	}
+	n, err = l.r.Read(p)
+	if l.remaining -= int64(n); l.remaining == 0 {
+		l.exceeded, err = true, errBodyTooLarge
+	}
+	return
+}
+
+// TensorValues returns the tensor data to encode as JSON values: []uint8 would otherwise be marshaled as a base64
+// string, and is converted to []int
+func TensorValues(data interface{}) interface{} {
+	uint8s, ok := data.([]uint8)
+	if !ok {
+		return data
+	}
+	ints := make([]int, len(uint8s))
+	for pos, v := range uint8s {
+		ints[pos] = int(v)
+	}
+	return ints
+}
@@ -0,0 +1,82 @@
+package httpjson
+
+import (
+	"encoding/json"
+	"errors"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/RedisAI/redisai-go/redisai"
+	"github.com/gomodule/redigo/redis"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStatusFromError(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want int
+	}{
+		{"not-found", redis.Error("ERR model key is empty"), http.StatusNotFound},
+		{"bad-request", redis.Error("ERR wrong number of inputs"), http.StatusBadRequest},
+		{"predictor-input", &redisai.PredictorInputError{Message: "missing input tensor a"}, http.StatusBadRequest},
+		{"connection", errors.New("connection refused"), http.StatusBadGateway},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, StatusFromError(tt.err))
+		})
+	}
+}
+
+func TestWriteJSON(t *testing.T) {
+	recorder := httptest.NewRecorder()
+	WriteError(recorder, http.StatusNotFound, errors.New("unknown route"))
+	assert.Equal(t, http.StatusNotFound, recorder.Code)
+	assert.Equal(t, "application/json", recorder.Header().Get("Content-Type"))
+	assert.Equal(t, "{\"error\":\"unknown route\"}\n", recorder.Body.String())
+
+	recorder = httptest.NewRecorder()
+	WriteJSON(recorder, http.StatusOK, math.Inf(1))
+	assert.Equal(t, http.StatusInternalServerError, recorder.Code)
+}
+
+func TestDecodeBody(t *testing.T) {
+	body := `{"value":1}`
+	tests := []struct {
+		name          string
+		body          string
+		maxBytes      int64
+		contentLength int64
+		wantStatus    int
+	}{
+		{"limit", body, int64(len(body)), int64(len(body)), http.StatusOK},
+		{"no-limit", body, 0, int64(len(body)), http.StatusOK},
+		{"content-length", body, int64(len(body)) - 1, int64(len(body)), http.StatusRequestEntityTooLarge},
+		{"no-content-length", body, int64(len(body)) - 1, -1, http.StatusRequestEntityTooLarge},
+		{"invalid", "{", 0, 1, http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			recorder := httptest.NewRecorder()
+			request := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(tt.body))
+			request.ContentLength = tt.contentLength
+			var v map[string]interface{}
+			ok := DecodeBody(recorder, request, tt.maxBytes, &v)
+			assert.Equal(t, tt.wantStatus == http.StatusOK, ok)
+			if ok {
+				assert.Equal(t, json.Number("1"), v["value"])
+				return
+			}
+			assert.Equal(t, tt.wantStatus, recorder.Code)
+		})
+	}
+}
+
+func TestTensorValues(t *testing.T) {
+	assert.Equal(t, []int{1, 255}, TensorValues([]uint8{1, 255}))
+	assert.Equal(t, []float32{1}, TensorValues([]float32{1}))
+}
@@ -0,0 +1,92 @@
+// Package redistest provides an in-memory redis.Conn to test the client without a RedisAI server
+package redistest
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/gomodule/redigo/redis"
+)
+
+// Handler replies to a command issued on a Conn
+type Handler func(cmd string, args []interface{}) (interface{}, error)
+
+// Conn is an in-memory redis.Conn that records the issued commands and replies through its Handler
+type Conn struct {
+	Handler Handler
+
+	mu       sync.Mutex
+	commands [][]interface{}
+	pending  []interface{}
+}
+
+// NewPool returns a pool whose connections all share the returned Conn
+func NewPool(handler Handler) (*redis.Pool, *Conn) {
+	conn := &Conn{Handler: handler}
+	pool := &redis.Pool{Dial: func() (redis.Conn, error) { return conn, nil }}
+	return pool, conn
+}
+
+func (c *Conn) Close() error { return nil }
+
+func (c *Conn) Err() error { return nil }
+
+func (c *Conn) Do(cmd string, args ...interface{}) (interface{}, error) {
+	if cmd == "" {
+		return nil, nil
+	}
+	c.mu.Lock()
+	c.commands = append(c.commands, append([]interface{}{cmd}, args...))
+	handler := c.Handler
+	c.mu.Unlock()
+	if handler == nil {
+		return "OK", nil
+	}
+	return handler(cmd, args)
+}
+
+func (c *Conn) Send(cmd string, args ...interface{}) error {
+	reply, err := c.Do(cmd, args...)
+	if err != nil {
+		reply = redis.Error(err.Error())
+	}
+	c.mu.Lock()
+	c.pending = append(c.pending, reply)
+	c.mu.Unlock()
+	return nil
+}
+
+func (c *Conn) Flush() error { return nil }
+
+func (c *Conn) Receive() (interface{}, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if len(c.pending) == 0 {
+		return nil, errors.New("redistest: no pending replies")
+	}
+	reply := c.pending[0]
+	c.pending = c.pending[1:]
+	if err, ok := reply.(redis.Error); ok {
+		return nil, err
+	}
+	return reply, nil
+}
+
+// Commands returns the issued commands, each one starting with its name
+func (c *Conn) Commands() [][]interface{} {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([][]interface{}{}, c.commands...)
+}
+
+// CommandNames returns the names of the issued commands
+func (c *Conn) CommandNames() []string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	names := make([]string, len(c.commands))
+	for pos, command := range c.commands {
+		names[pos] = fmt.Sprint(command[0])
+	}
+	return names
+}
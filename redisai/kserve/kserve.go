@@ -0,0 +1,344 @@
+// Package kserve serves RedisAI models through the Open Inference (KServe V2) REST protocol.
+//
+// The Handler serves the following routes:
+//
+//	GET  /v2/health/live
+//	GET  /v2/health/ready
+//	GET  /v2/models/{name}[/versions/{version}]
+//	GET  /v2/models/{name}[/versions/{version}]/ready
+//	POST /v2/models/{name}[/versions/{version}]/infer
+//
+// Model names and versions are mapped to RedisAI model keys by the Handler's ModelKey function.
+// Inferences are issued as a single AI.DAGEXECUTE without PERSIST through a redisai.Predictor.
+//
+// The V2 datatypes are mapped to the RedisAI tensor types of the same width (FP32 to FLOAT, INT64 to INT64, ...).
+// RedisAI has no variable length tensor type, so a BYTES input is only accepted with a single element,
+// which is sent as a UINT8 tensor holding its bytes. The request bodies larger than the Handler's MaxBodyBytes
+// are rejected with 413 Request Entity Too Large.
+package kserve
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/RedisAI/redisai-go/redisai"
+	"github.com/RedisAI/redisai-go/redisai/implementations"
+	"github.com/RedisAI/redisai-go/redisai/internal/httpjson"
+	"github.com/gomodule/redigo/redis"
+)
+
+// DefaultMaxBodyBytes is the default limit of the request body size
+const DefaultMaxBodyBytes = httpjson.DefaultMaxBodyBytes
+
+var datatypesToRedisAI = map[string]string{
+	"FP32":   redisai.TypeFloat,
+	"FP64":   redisai.TypeDouble,
+	"INT8":   redisai.TypeInt8,
+	"INT16":  redisai.TypeInt16,
+	"INT32":  redisai.TypeInt32,
+	"INT64":  redisai.TypeInt64,
+	"UINT8":  redisai.TypeUint8,
+	"UINT16": redisai.TypeUint16,
+}
+
+var redisAIToDatatypes = map[string]string{
+	redisai.TypeFloat:  "FP32",
+	redisai.TypeDouble: "FP64",
+	redisai.TypeInt8:   "INT8",
+	redisai.TypeInt16:  "INT16",
+	redisai.TypeInt32:  "INT32",
+	redisai.TypeInt64:  "INT64",
+	redisai.TypeUint8:  "UINT8",
+	redisai.TypeUint16: "UINT16",
+}
+
+var backendPlatforms = map[string]string{
+	redisai.BackendTF:     "tensorflow_graphdef",
+	redisai.BackendTorch:  "pytorch_torchscript",
+	redisai.BackendONNX:   "onnxruntime_onnx",
+	redisai.BackendTFLite: "tensorflow_lite",
+}
+
+// Tensor is a V2 protocol tensor
+type Tensor struct {
+	Name       string                 `json:"name"`
+	Shape      []int64                `json:"shape"`
+	Datatype   string                 `json:"datatype"`
+	Parameters map[string]interface{} `json:"parameters,omitempty"`
+	Data       interface{}            `json:"data"`
+}
+
+// RequestOutput is a V2 protocol requested output
+type RequestOutput struct {
+	Name       string                 `json:"name"`
+	Parameters map[string]interface{} `json:"parameters,omitempty"`
+}
+
+// InferenceRequest is the body of the infer route
+type InferenceRequest struct {
+	ID         string                 `json:"id,omitempty"`
+	Parameters map[string]interface{} `json:"parameters,omitempty"`
+	Inputs     []Tensor               `json:"inputs"`
+	Outputs    []RequestOutput        `json:"outputs,omitempty"`
+}
+
+// InferenceResponse is the reply of the infer route
+type InferenceResponse struct {
+	ModelName    string   `json:"model_name"`
+	ModelVersion string   `json:"model_version,omitempty"`
+	ID           string   `json:"id,omitempty"`
+	Outputs      []Tensor `json:"outputs"`
+}
+
+// TensorMetadata describes a model input or output. RedisAI doesn't record the tensors types and shapes,
+// so the datatype is left empty and the shape is reported as a single variable dimension.
+type TensorMetadata struct {
+	Name     string  `json:"name"`
+	Datatype string  `json:"datatype"`
+	Shape    []int64 `json:"shape"`
+}
+
+// ModelMetadata is the reply of the model metadata route
+type ModelMetadata struct {
+	Name     string           `json:"name"`
+	Versions []string         `json:"versions,omitempty"`
+	Platform string           `json:"platform"`
+	Inputs   []TensorMetadata `json:"inputs"`
+	Outputs  []TensorMetadata `json:"outputs"`
+}
+
+// ErrorResponse is the reply of every route on failure
+type ErrorResponse = httpjson.ErrorResponse
+
+// Handler is an http.Handler serving the V2 protocol routes. It is safe for concurrent use,
+// each request using its own redisai.Client on top of the shared pool.
+type Handler struct {
+	Pool *redis.Pool
+	// ModelKey maps a model name and version, possibly empty, to the RedisAI key of the model
+	ModelKey func(name, version string) string
+	// MaxBodyBytes is the size limit of the request bodies. Zero or negative means no limit
+	MaxBodyBytes int64
+}
+
+// NewHandler returns a Handler using the model name as model key, and the version, when given, as a key suffix.
+// It accepts request bodies of up to DefaultMaxBodyBytes
+func NewHandler(pool *redis.Pool) *Handler {
+	return &Handler{
+		Pool:         pool,
+		MaxBodyBytes: DefaultMaxBodyBytes,
+		ModelKey: func(name, version string) string {
+			if len(version) > 0 {
+				return name + ":" + version
+			}
+			return name
+		},
+	}
+}
+
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	switch {
+	case r.URL.Path == "/v2/health/live":
+		httpjson.WriteJSON(w, http.StatusOK, map[string]bool{"live": true})
+	case r.URL.Path == "/v2/health/ready":
+		h.serveServerReady(w, r)
+	case strings.HasPrefix(r.URL.Path, "/v2/models/"):
+		h.serveModel(w, r)
+	default:
+		httpjson.WriteError(w, http.StatusNotFound, fmt.Errorf("unknown route %s", r.URL.Path))
+	}
+}
+
+// parseModelPath splits /v2/models/{name}[/versions/{version}][/{action}]
+func parseModelPath(path string) (name, version, action string, ok bool) {
+	parts := strings.Split(strings.TrimPrefix(path, "/v2/models/"), "/")
+	if len(parts[0]) == 0 {
+		return
+	}
+	name, parts = parts[0], parts[1:]
+	if len(parts) >= 2 && parts[0] == "versions" {
+		version, parts = parts[1], parts[2:]
+	}
+	switch len(parts) {
+	case 0:
+		ok = true
+	case 1:
+		action = parts[0]
+		ok = action == "ready" || action == "infer"
+	}
+	return
+}
+
+func (h *Handler) serveModel(w http.ResponseWriter, r *http.Request) {
+	name, version, action, ok := parseModelPath(r.URL.Path)
+	if !ok {
+		httpjson.WriteError(w, http.StatusNotFound, fmt.Errorf("unknown route %s", r.URL.Path))
+		return
+	}
+	wantMethod := http.MethodGet
+	if action == "infer" {
+		wantMethod = http.MethodPost
+	}
+	if r.Method != wantMethod {
+		httpjson.WriteError(w, http.StatusMethodNotAllowed, fmt.Errorf("method %s not allowed", r.Method))
+		return
+	}
+	client := redisai.Connect("", h.Pool)
+	defer client.Close()
+	key := h.ModelKey(name, version)
+	switch action {
+	case "infer":
+		h.serveInfer(w, r, client, name, version, key)
+	case "ready":
+		if _, err := client.ModelGetMeta(key); err != nil {
+			httpjson.WriteError(w, httpjson.StatusFromError(err), err)
+			return
+		}
+		httpjson.WriteJSON(w, http.StatusOK, map[string]bool{"ready": true})
+	default:
+		meta, err := client.ModelGetMeta(key)
+		if err != nil {
+			httpjson.WriteError(w, httpjson.StatusFromError(err), err)
+			return
+		}
+		metadata := ModelMetadata{
+			Name:     name,
+			Platform: backendPlatforms[meta.Backend],
+			Inputs:   tensorsMetadata(meta.Inputs),
+			Outputs:  tensorsMetadata(meta.Outputs),
+		}
+		if len(version) > 0 {
+			metadata.Versions = []string{version}
+		}
+		httpjson.WriteJSON(w, http.StatusOK, metadata)
+	}
+}
+
+func (h *Handler) serveServerReady(w http.ResponseWriter, r *http.Request) {
+	conn := h.Pool.Get()
+	defer conn.Close()
+	if _, err := conn.Do("PING"); err != nil {
+		httpjson.WriteError(w, http.StatusServiceUnavailable, err)
+		return
+	}
+	httpjson.WriteJSON(w, http.StatusOK, map[string]bool{"ready": true})
+}
+
+func (h *Handler) serveInfer(w http.ResponseWriter, r *http.Request, client *redisai.Client, name, version, key string) {
+	var request InferenceRequest
+	if !httpjson.DecodeBody(w, r, h.MaxBodyBytes, &request) {
+		return
+	}
+	inputNames := make([]string, len(request.Inputs))
+	inputs := make(map[string]redisai.TensorInterface, len(request.Inputs))
+	for pos, input := range request.Inputs {
+		tensor, err := input.ToTensor()
+		if err != nil {
+			httpjson.WriteError(w, http.StatusBadRequest, err)
+			return
+		}
+		if _, ok := inputs[input.Name]; ok {
+			httpjson.WriteError(w, http.StatusBadRequest, fmt.Errorf("duplicate input tensor %s", input.Name))
+			return
+		}
+		inputNames[pos] = input.Name
+		inputs[input.Name] = tensor
+	}
+	outputNames := make([]string, len(request.Outputs))
+	for pos, output := range request.Outputs {
+		outputNames[pos] = output.Name
+	}
+	if len(outputNames) == 0 {
+		// the outputs are only recorded for TensorFlow models
+		meta, err := client.ModelGetMeta(key)
+		if err != nil {
+			httpjson.WriteError(w, httpjson.StatusFromError(err), err)
+			return
+		}
+		if outputNames = meta.Outputs; len(outputNames) == 0 {
+			httpjson.WriteError(w, http.StatusBadRequest, fmt.Errorf("the requested outputs must be given for model %s", name))
+			return
+		}
+	}
+
+	outputs, err := redisai.NewModelPredictor(client, key, inputNames, outputNames).Predict(inputs)
+	if err != nil {
+		httpjson.WriteError(w, httpjson.StatusFromError(err), err)
+		return
+	}
+	response := InferenceResponse{ModelName: name, ModelVersion: version, ID: request.ID, Outputs: make([]Tensor, len(outputNames))}
+	for pos, outputName := range outputNames {
+		if response.Outputs[pos], err = FromTensor(outputName, outputs[outputName]); err != nil {
+			httpjson.WriteError(w, http.StatusInternalServerError, err)
+			return
+		}
+	}
+	httpjson.WriteJSON(w, http.StatusOK, response)
+}
+
+// ToTensor converts the V2 tensor into a redisai.TensorInterface
+func (t Tensor) ToTensor() (redisai.TensorInterface, error) {
+	values, err := flattenData(t.Data, nil)
+	if err != nil {
+		return nil, fmt.Errorf("tensor %s: %v", t.Name, err)
+	}
+	if t.Datatype == "BYTES" {
+		if len(values) != 1 {
+			return nil, fmt.Errorf("tensor %s: BYTES tensors are only supported with a single element", t.Name)
+		}
+		blob := []byte(values[0])
+		return implementations.NewAiTensorWithData(redisai.TypeUint8, []int64{int64(len(blob))}, blob), nil
+	}
+	dtype, ok := datatypesToRedisAI[t.Datatype]
+	if !ok {
+		return nil, fmt.Errorf("tensor %s: unsupported datatype %s", t.Name, t.Datatype)
+	}
+	data, err := redisai.TensorDataFromStrings(dtype, values)
+	if err != nil {
+		return nil, fmt.Errorf("tensor %s: %v", t.Name, err)
+	}
+	return implementations.NewAiTensorWithData(dtype, t.Shape, data), nil
+}
+
+// flattenData flattens the, possibly nested, tensor data in row-major order
+func flattenData(data interface{}, values []string) ([]string, error) {
+	switch value := data.(type) {
+	case []interface{}:
+		var err error
+		for _, element := range value {
+			if values, err = flattenData(element, values); err != nil {
+				return nil, err
+			}
+		}
+		return values, nil
+	case json.Number:
+		return append(values, value.String()), nil
+	case string:
+		return append(values, value), nil
+	case bool:
+		return nil, fmt.Errorf("unsupported boolean data")
+	}
+	return nil, fmt.Errorf("unexpected data element %v", data)
+}
+
+// FromTensor converts the redisai.TensorInterface into a V2 tensor with flat data
+func FromTensor(name string, tensor redisai.TensorInterface) (t Tensor, err error) {
+	dtype, err := redisai.TensorGetTypeStrFromType(tensor.Dtype())
+	if err != nil {
+		return
+	}
+	t.Name = name
+	t.Shape = tensor.Shape()
+	t.Datatype = redisAIToDatatypes[dtype]
+	t.Data = httpjson.TensorValues(tensor.Data())
+	return
+}
+
+func tensorsMetadata(names []string) []TensorMetadata {
+	metadata := make([]TensorMetadata, len(names))
+	for pos, name := range names {
+		metadata[pos] = TensorMetadata{Name: name, Shape: []int64{-1}}
+	}
+	return metadata
+}
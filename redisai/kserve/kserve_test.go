@@ -0,0 +1,163 @@
+package kserve
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/RedisAI/redisai-go/redisai"
+	"github.com/RedisAI/redisai-go/redisai/internal/redistest"
+	"github.com/gomodule/redigo/redis"
+	"github.com/stretchr/testify/assert"
+)
+
+func serve(h http.Handler, method, path, body string) (*httptest.ResponseRecorder, map[string]interface{}) {
+	recorder := httptest.NewRecorder()
+	h.ServeHTTP(recorder, httptest.NewRequest(method, path, strings.NewReader(body)))
+	var decoded map[string]interface{}
+	_ = json.Unmarshal(recorder.Body.Bytes(), &decoded)
+	return recorder, decoded
+}
+
+func modelReply() []interface{} {
+	return []interface{}{[]byte("backend"), []byte("TF"), []byte("inputs"), []interface{}{[]byte("a"), []byte("b")}, []byte("outputs"), []interface{}{[]byte("mul")}}
+}
+
+func tensorReply() []interface{} {
+	return []interface{}{[]byte("dtype"), []byte(redisai.TypeFloat), []byte("shape"), []interface{}{int64(1), int64(2)}, []byte("values"), []interface{}{[]byte("1.5"), []byte("2")}}
+}
+
+func TestParseModelPath(t *testing.T) {
+	tests := []struct {
+		path        string
+		wantName    string
+		wantVersion string
+		wantAction  string
+		wantOk      bool
+	}{
+		{"/v2/models/fraud", "fraud", "", "", true},
+		{"/v2/models/fraud/ready", "fraud", "", "ready", true},
+		{"/v2/models/fraud/versions/3/infer", "fraud", "3", "infer", true},
+		{"/v2/models/fraud/versions/3", "fraud", "3", "", true},
+		{"/v2/models/fraud/other", "fraud", "", "other", false},
+		{"/v2/models/", "", "", "", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			name, version, action, ok := parseModelPath(tt.path)
+			assert.Equal(t, tt.wantOk, ok)
+			if ok {
+				assert.Equal(t, []string{tt.wantName, tt.wantVersion, tt.wantAction}, []string{name, version, action})
+			}
+		})
+	}
+}
+
+func TestHandler_Infer(t *testing.T) {
+	pool, conn := redistest.NewPool(func(cmd string, args []interface{}) (interface{}, error) {
+		if cmd == "AI.MODELGET" {
+			return modelReply(), nil
+		}
+		return []interface{}{"OK", "OK", "OK", tensorReply()}, nil
+	})
+	h := NewHandler(pool)
+	body := `{"id":"42","inputs":[{"name":"a","shape":[1,2],"datatype":"FP32","data":[[1,2]]},{"name":"b","shape":[1,2],"datatype":"FP32","data":[1.5,1]}]}`
+	recorder, reply := serve(h, http.MethodPost, "/v2/models/fraud/versions/2/infer", body)
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	assert.Equal(t, "fraud", reply["model_name"])
+	assert.Equal(t, "2", reply["model_version"])
+	assert.Equal(t, "42", reply["id"])
+	assert.Equal(t, []interface{}{map[string]interface{}{"name": "mul", "shape": []interface{}{1.0, 2.0}, "datatype": "FP32", "data": []interface{}{1.5, 2.0}}}, reply["outputs"])
+
+	commands := conn.Commands()
+	assert.Equal(t, []interface{}{"AI.MODELGET", "fraud:2", "META"}, commands[0])
+	assert.Equal(t, []interface{}{"AI.DAGEXECUTE", "ROUTING", "fraud:2"}, commands[1][:3])
+	assert.Contains(t, commands[1], float32(1.5))
+
+	// the requested outputs don't need the model metadata
+	body = `{"inputs":[{"name":"a","shape":[1,2],"datatype":"FP32","data":[1,2]},{"name":"b","shape":[1,2],"datatype":"FP32","data":[1,2]}],"outputs":[{"name":"mul"}]}`
+	recorder, _ = serve(h, http.MethodPost, "/v2/models/fraud/infer", body)
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	assert.Equal(t, []string{"AI.MODELGET", "AI.DAGEXECUTE", "AI.DAGEXECUTE"}, conn.CommandNames())
+}
+
+func TestHandler_Infer_Errors(t *testing.T) {
+	pool, _ := redistest.NewPool(func(cmd string, args []interface{}) (interface{}, error) {
+		if cmd == "AI.MODELGET" {
+			return []interface{}{[]byte("backend"), []byte("ORT")}, nil
+		}
+		return nil, redis.Error("ERR model key is empty")
+	})
+	h := NewHandler(pool)
+	tests := []struct {
+		name       string
+		method     string
+		body       string
+		wantStatus int
+	}{
+		{"method", http.MethodGet, "", http.StatusMethodNotAllowed},
+		{"body", http.MethodPost, "{", http.StatusBadRequest},
+		{"datatype", http.MethodPost, `{"inputs":[{"name":"a","shape":[1],"datatype":"BOOL","data":[true]}]}`, http.StatusBadRequest},
+		{"bytes", http.MethodPost, `{"inputs":[{"name":"a","shape":[2],"datatype":"BYTES","data":["a","b"]}]}`, http.StatusBadRequest},
+		{"outputs", http.MethodPost, `{"inputs":[{"name":"a","shape":[1],"datatype":"INT64","data":[1]}]}`, http.StatusBadRequest},
+		{"not-found", http.MethodPost, `{"inputs":[{"name":"a","shape":[1],"datatype":"INT64","data":[1]}],"outputs":[{"name":"b"}]}`, http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			recorder, reply := serve(h, tt.method, "/v2/models/fraud/infer", tt.body)
+			assert.Equal(t, tt.wantStatus, recorder.Code)
+			assert.NotEmpty(t, reply["error"])
+		})
+	}
+}
+
+func TestHandler_Infer_MaxBodyBytes(t *testing.T) {
+	pool, conn := redistest.NewPool(nil)
+	h := NewHandler(pool)
+	body := `{"inputs":[{"name":"a","shape":[1],"datatype":"INT64","data":[1]}],"outputs":[{"name":"b"}]}`
+	h.MaxBodyBytes = int64(len(body)) - 1
+	recorder, reply := serve(h, http.MethodPost, "/v2/models/fraud/infer", body)
+	assert.Equal(t, http.StatusRequestEntityTooLarge, recorder.Code)
+	assert.NotEmpty(t, reply["error"])
+	assert.Len(t, conn.Commands(), 0)
+}
+
+func TestTensor_ToTensor_Bytes(t *testing.T) {
+	tensor, err := Tensor{Name: "a", Shape: []int64{1}, Datatype: "BYTES", Data: []interface{}{"abc"}}.ToTensor()
+	assert.Nil(t, err)
+	assert.Equal(t, []int64{3}, tensor.Shape())
+	assert.Equal(t, []byte("abc"), tensor.Data())
+}
+
+func TestHandler_Metadata(t *testing.T) {
+	found := true
+	pool, _ := redistest.NewPool(func(cmd string, args []interface{}) (interface{}, error) {
+		if !found {
+			return nil, redis.Error("ERR model key is empty")
+		}
+		if cmd == "PING" {
+			return "PONG", nil
+		}
+		return modelReply(), nil
+	})
+	h := NewHandler(pool)
+	recorder, reply := serve(h, http.MethodGet, "/v2/models/fraud", "")
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	assert.Equal(t, "tensorflow_graphdef", reply["platform"])
+	assert.Equal(t, []interface{}{map[string]interface{}{"name": "mul", "datatype": "", "shape": []interface{}{-1.0}}}, reply["outputs"])
+
+	recorder, _ = serve(h, http.MethodGet, "/v2/models/fraud/ready", "")
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	recorder, _ = serve(h, http.MethodGet, "/v2/health/live", "")
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	recorder, _ = serve(h, http.MethodGet, "/v2/health/ready", "")
+	assert.Equal(t, http.StatusOK, recorder.Code)
+
+	found = false
+	recorder, _ = serve(h, http.MethodGet, "/v2/models/fraud/ready", "")
+	assert.Equal(t, http.StatusNotFound, recorder.Code)
+	recorder, _ = serve(h, http.MethodGet, "/v2/other", "")
+	assert.Equal(t, http.StatusNotFound, recorder.Code)
+}
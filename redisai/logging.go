@@ -0,0 +1,120 @@
+package redisai
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/gomodule/redigo/redis"
+)
+
+// CommandLogger receives the command logs of a client, see SetLogger. The methods follow log/slog: args are
+// alternating keys and values, so that a *slog.Logger is a CommandLogger.
+type CommandLogger interface {
+	DebugContext(ctx context.Context, msg string, args ...interface{})
+	WarnContext(ctx context.Context, msg string, args ...interface{})
+}
+
+// SetLogger makes the client log every command at debug level, with its arguments, duration and error, and the
+// commands slower than slowThreshold at warning level. A zero slowThreshold disables the warnings.
+//
+// The tensor BLOB and VALUES payloads are replaced by a summary of their type, shape, size and SHA-256 prefix,
+// and the model blobs by their size and SHA-256 prefix, so that the logs neither leak nor grow with the data.
+func (c *Client) SetLogger(logger CommandLogger, slowThreshold time.Duration) {
+	c.AddHook(&loggingHook{logger: logger, slowThreshold: slowThreshold})
+}
+
+// loggingHook logs the commands to a CommandLogger
+type loggingHook struct {
+	logger        CommandLogger
+	slowThreshold time.Duration
+}
+
+func (h *loggingHook) BeforeCommand(ctx context.Context, info *CommandInfo) context.Context {
+	return ctx
+}
+
+func (h *loggingHook) AfterCommand(ctx context.Context, info *CommandInfo) {
+	args := []interface{}{"command", info.Name, "args", redactArgs(info.Name, info.args), "duration", info.Duration}
+	if info.Pipelined {
+		args = append(args, "pipelined", true)
+	}
+	if info.Err != nil {
+		args = append(args, "error", info.Err)
+	}
+	h.logger.DebugContext(ctx, "redisai: command", args...)
+	if h.slowThreshold > 0 && info.Duration >= h.slowThreshold {
+		h.logger.WarnContext(ctx, "redisai: slow command", append(args, "threshold", h.slowThreshold)...)
+	}
+}
+
+// redactArgs formats the arguments of a command, with its tensor payloads and blobs replaced by a summary
+func redactArgs(cmdName string, args redis.Args) []string {
+	formatted := make([]string, 0, len(args))
+	command, start := strings.ToUpper(cmdName), 0
+	for pos := 0; pos <= len(args); pos++ {
+		if pos < len(args) && args[pos] != "|>" {
+			continue
+		}
+		formatted = append(formatted, redactCommandArgs(command, args[start:pos])...)
+		if pos < len(args) {
+			formatted = append(formatted, "|>")
+			if pos+1 < len(args) {
+				command = strings.ToUpper(fmt.Sprint(args[pos+1]))
+				formatted = append(formatted, command)
+			}
+			start = pos + 2
+		}
+	}
+	return formatted
+}
+
+// redactCommandArgs formats the arguments of a single command, or DAG operation
+func redactCommandArgs(command string, args redis.Args) []string {
+	formatted := make([]string, 0, len(args))
+	if command != "AI.TENSORSET" || len(args) < 2 {
+		for _, arg := range args {
+			if blob, ok := arg.([]byte); ok {
+				formatted = append(formatted, redactSummary("", nil, fmt.Sprintf("%d bytes", len(blob)), blob))
+				continue
+			}
+			formatted = append(formatted, fmt.Sprint(arg))
+		}
+		return formatted
+	}
+	// AI.TENSORSET key type dims... BLOB data | VALUES values...
+	dtype := fmt.Sprint(args[1])
+	var shape []string
+	for pos, arg := range args {
+		switch {
+		case pos < 2:
+			formatted = append(formatted, fmt.Sprint(arg))
+		case arg == TensorContentTypeBlob && pos+1 < len(args):
+			blob, _ := args[pos+1].([]byte)
+			return append(formatted, TensorContentTypeBlob, redactSummary(dtype, shape, fmt.Sprintf("%d bytes", len(blob)), blob))
+		case arg == TensorContentTypeValues:
+			values := make([]string, 0, len(args)-pos-1)
+			for _, value := range args[pos+1:] {
+				values = append(values, fmt.Sprint(value))
+			}
+			return append(formatted, TensorContentTypeValues, redactSummary(dtype, shape, fmt.Sprintf("%d values", len(values)), []byte(strings.Join(values, " "))))
+		default:
+			shape = append(shape, fmt.Sprint(arg))
+			formatted = append(formatted, shape[len(shape)-1])
+		}
+	}
+	return formatted
+}
+
+// redactSummary formats a payload as <dtype [shape] size sha256:prefix>
+func redactSummary(dtype string, shape []string, size string, content []byte) string {
+	hash := sha256.Sum256(content)
+	summary := "<"
+	if len(dtype) > 0 {
+		summary += dtype + " [" + strings.Join(shape, " ") + "] "
+	}
+	return summary + size + " sha256:" + hex.EncodeToString(hash[:8]) + ">"
+}
@@ -0,0 +1,83 @@
+package redisai
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/gomodule/redigo/redis"
+	"github.com/stretchr/testify/assert"
+)
+
+type logEntry struct {
+	level string
+	msg   string
+	args  []interface{}
+}
+
+type recordingLogger struct {
+	entries []logEntry
+}
+
+func (l *recordingLogger) DebugContext(ctx context.Context, msg string, args ...interface{}) {
+	l.entries = append(l.entries, logEntry{"debug", msg, args})
+}
+
+func (l *recordingLogger) WarnContext(ctx context.Context, msg string, args ...interface{}) {
+	l.entries = append(l.entries, logEntry{"warn", msg, args})
+}
+
+func TestClient_SetLogger(t *testing.T) {
+	c, _ := createFakeClient(func(cmd string, args []interface{}) (interface{}, error) {
+		if cmd == "AI.MODELEXECUTE" {
+			time.Sleep(5 * time.Millisecond)
+			return nil, redis.Error("ERR model key is empty")
+		}
+		return "OK", nil
+	})
+	logger := &recordingLogger{}
+	c.SetLogger(logger, 5*time.Millisecond)
+	assert.Nil(t, c.TensorSet("a", TypeFloat32, []int64{2}, []byte("12345678")))
+	assert.NotNil(t, c.ModelExecute("mymodel", []string{"a"}, []string{"b"}))
+
+	assert.Len(t, logger.entries, 3)
+	assert.Equal(t, "debug", logger.entries[0].level)
+	assert.Equal(t, "redisai: command", logger.entries[0].msg)
+	assert.Equal(t, []interface{}{"command", "AI.TENSORSET", "args", []string{"a", TypeFloat32, "2", "BLOB", "<FLOAT [2] 8 bytes sha256:ef797c8118f02dfb>"}},
+		logger.entries[0].args[:4])
+	assert.Equal(t, "debug", logger.entries[1].level)
+	assert.Contains(t, logger.entries[1].args, redis.Error("ERR model key is empty"))
+	assert.Equal(t, "warn", logger.entries[2].level)
+	assert.Equal(t, "redisai: slow command", logger.entries[2].msg)
+	assert.Equal(t, []interface{}{"threshold", 5 * time.Millisecond}, logger.entries[2].args[len(logger.entries[2].args)-2:])
+}
+
+func TestRedactArgs(t *testing.T) {
+	dag := NewDag().TensorSet("a", TypeFloat32, []int64{1, 2}, []float32{1.5, 2}).
+		TensorSet("b", TypeUint8, []int64{3}, []byte{1, 2, 3}).
+		ModelExecute("mymodel", []string{"a", "b"}, []string{"c"}, 0).TensorGet("c", TensorContentTypeValues)
+	dagArgs, _ := dag.FlatArgs()
+	modelArgs, _ := modelStoreFlatArgs("mymodel", BackendTF, DeviceCPU, "", 0, 0, 0, nil, nil, []byte("graph"))
+	tests := []struct {
+		name string
+		cmd  string
+		args redis.Args
+		want []string
+	}{
+		{"tensor-values", "AI.TENSORSET", redis.Args{"a", TypeInt64, int64(2), "VALUES", int64(1), int64(2)},
+			[]string{"a", TypeInt64, "2", "VALUES", "<INT64 [2] 2 values sha256:f71998fe363b9c29>"}},
+		{"tensor-meta", "AI.TENSORSET", redis.Args{"a", TypeInt64, int64(2)}, []string{"a", TypeInt64, "2"}},
+		{"model-blob", "AI.MODELSTORE", modelArgs, []string{"mymodel", BackendTF, DeviceCPU, "BLOB", "<5 bytes sha256:eef93e1d14482804>"}},
+		{"dag", "AI.DAGEXECUTE", AddDagExecuteArgs(nil, []string{"c"}, "", 0, dagArgs), []string{"PERSIST", "1", "c",
+			"|>", "AI.TENSORSET", "a", TypeFloat32, "1", "2", "VALUES", "<FLOAT [1 2] 2 values sha256:31e16be78da23fed>",
+			"|>", "AI.TENSORSET", "b", TypeUint8, "3", "BLOB", "<UINT8 [3] 3 bytes sha256:039058c6f2c0cb49>",
+			"|>", "AI.MODELEXECUTE", "mymodel", "INPUTS", "2", "a", "b", "OUTPUTS", "1", "c",
+			"|>", "AI.TENSORGET", "c", TensorContentTypeValues}},
+		{"no-args", "INFO", nil, []string{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, redactArgs(tt.cmd, tt.args))
+		})
+	}
+}
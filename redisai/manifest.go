@@ -0,0 +1,368 @@
+package redisai
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/gomodule/redigo/redis"
+)
+
+// DefaultManifestStateKey is the key of the hash in which Sync records the keys it manages, along with the
+// content hash of their model or script
+const DefaultManifestStateKey = "redisai:manifest"
+
+// Sync actions
+const (
+	SyncActionCreate    = "create"
+	SyncActionUpdate    = "update"
+	SyncActionDelete    = "delete"
+	SyncActionUnchanged = "unchanged"
+)
+
+// ManifestModel describes a model to be stored from a file
+type ManifestModel struct {
+	Key             string   `json:"key"`
+	File            string   `json:"file"`
+	Backend         string   `json:"backend"`
+	Device          string   `json:"device"`
+	Tag             string   `json:"tag,omitempty"`
+	BatchSize       int64    `json:"batchsize,omitempty"`
+	MinBatchSize    int64    `json:"minbatchsize,omitempty"`
+	MinBatchTimeout int64    `json:"minbatchtimeout,omitempty"`
+	Inputs          []string `json:"inputs,omitempty"`
+	Outputs         []string `json:"outputs,omitempty"`
+}
+
+// ManifestScript describes a TorchScript to be stored from a file
+type ManifestScript struct {
+	Key         string   `json:"key"`
+	File        string   `json:"file"`
+	Device      string   `json:"device"`
+	Tag         string   `json:"tag,omitempty"`
+	EntryPoints []string `json:"entry_points"`
+}
+
+// Manifest lists the models and scripts a RedisAI server is expected to hold.
+// The files are relative to Dir, unless absolute.
+type Manifest struct {
+	Models  []ManifestModel  `json:"models"`
+	Scripts []ManifestScript `json:"scripts"`
+	Dir     string           `json:"-"`
+}
+
+// LoadManifest reads a JSON manifest, whose files are relative to the manifest directory
+func LoadManifest(path string) (*Manifest, error) {
+	content, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	manifest := &Manifest{}
+	decoder := json.NewDecoder(bytes.NewReader(content))
+	decoder.DisallowUnknownFields()
+	if err = decoder.Decode(manifest); err != nil {
+		return nil, fmt.Errorf("redisai.LoadManifest: invalid manifest %s: %v", path, err)
+	}
+	manifest.Dir = filepath.Dir(path)
+	return manifest, manifest.validate()
+}
+
+func (m *Manifest) validate() error {
+	keys := make(map[string]bool, len(m.Models)+len(m.Scripts))
+	check := func(key, file string) error {
+		if len(key) == 0 || len(file) == 0 {
+			return fmt.Errorf("redisai.Manifest: every entry needs a key and a file")
+		}
+		if keys[key] {
+			return fmt.Errorf("redisai.Manifest: duplicate key %s", key)
+		}
+		keys[key] = true
+		return nil
+	}
+	for _, model := range m.Models {
+		if err := check(model.Key, model.File); err != nil {
+			return err
+		}
+		if len(model.Backend) == 0 || len(model.Device) == 0 {
+			return fmt.Errorf("redisai.Manifest: model %s needs a backend and a device", model.Key)
+		}
+	}
+	for _, script := range m.Scripts {
+		if err := check(script.Key, script.File); err != nil {
+			return err
+		}
+		if len(script.Device) == 0 {
+			return fmt.Errorf("redisai.Manifest: script %s needs a device", script.Key)
+		}
+	}
+	return nil
+}
+
+func (m *Manifest) readFile(file string) ([]byte, error) {
+	if !filepath.IsAbs(file) {
+		file = filepath.Join(m.Dir, file)
+	}
+	return ioutil.ReadFile(file)
+}
+
+// SyncOptions tunes the behavior of Sync
+type SyncOptions struct {
+	// DryRun only computes the plan, without changing the server
+	DryRun bool
+	// Prune deletes the keys previously stored by Sync that the manifest no longer lists.
+	// Keys that were not stored by Sync are never deleted.
+	Prune bool
+	// StateKey is the key of the state hash. DefaultManifestStateKey is used when empty
+	StateKey string
+}
+
+// SyncAction is a change, or the absence of change, of a single key
+type SyncAction struct {
+	Action string `json:"action"`
+	// Kind is either "model" or "script"
+	Kind   string `json:"kind"`
+	Key    string `json:"key"`
+	Reason string `json:"reason,omitempty"`
+}
+
+// SyncPlan is the list of the actions of a Sync, in the manifest order followed by the deletions
+type SyncPlan struct {
+	Actions []SyncAction `json:"actions"`
+}
+
+// Changes returns the actions that change the server
+func (p *SyncPlan) Changes() []SyncAction {
+	changes := make([]SyncAction, 0, len(p.Actions))
+	for _, action := range p.Actions {
+		if action.Action != SyncActionUnchanged {
+			changes = append(changes, action)
+		}
+	}
+	return changes
+}
+
+// String returns the plan as one line per action
+func (p *SyncPlan) String() string {
+	var b strings.Builder
+	for _, action := range p.Actions {
+		fmt.Fprintf(&b, "%-9s %-6s %s", action.Action, action.Kind, action.Key)
+		if len(action.Reason) > 0 {
+			fmt.Fprintf(&b, " (%s)", action.Reason)
+		}
+		b.WriteByte('\n')
+	}
+	return b.String()
+}
+
+// Sync makes the server hold the models and scripts of the manifest, storing only the ones whose metadata or
+// content differ from the server ones, and returns the plan of the applied actions.
+//
+// The content of a key is compared through the SHA-256 hash of the blob or source read from the server, so that
+// the keys overwritten by other means are detected. The hash is recorded by Sync in the state hash.
+func Sync(client *Client, manifest *Manifest, options SyncOptions) (plan *SyncPlan, err error) {
+	if client.PipelineActive {
+		return nil, fmt.Errorf("redisai.Sync: manifests can't be synced on a pipelined client")
+	}
+	if err = manifest.validate(); err != nil {
+		return
+	}
+	stateKey := options.StateKey
+	if len(stateKey) == 0 {
+		stateKey = DefaultManifestStateKey
+	}
+	state, err := redis.StringMap(client.DoOrSend("HGETALL", redis.Args{stateKey}, nil))
+	if err != nil {
+		return
+	}
+	plan = &SyncPlan{}
+	listed := make(map[string]bool, len(manifest.Models)+len(manifest.Scripts))
+	for _, model := range manifest.Models {
+		listed[model.Key] = true
+		var action SyncAction
+		if action, err = syncModel(client, manifest, model, state, stateKey, options.DryRun); err != nil {
+			return
+		}
+		plan.Actions = append(plan.Actions, action)
+	}
+	for _, script := range manifest.Scripts {
+		listed[script.Key] = true
+		var action SyncAction
+		if action, err = syncScript(client, manifest, script, state, stateKey, options.DryRun); err != nil {
+			return
+		}
+		plan.Actions = append(plan.Actions, action)
+	}
+	if !options.Prune {
+		return
+	}
+	unlisted := make([]string, 0, len(state))
+	for key := range state {
+		if !listed[key] {
+			unlisted = append(unlisted, key)
+		}
+	}
+	sort.Strings(unlisted)
+	for _, key := range unlisted {
+		kind := strings.SplitN(state[key], ":", 2)[0]
+		action := SyncAction{Action: SyncActionDelete, Kind: kind, Key: key, Reason: "not listed"}
+		plan.Actions = append(plan.Actions, action)
+		if options.DryRun {
+			continue
+		}
+		if kind == "script" {
+			err = client.ScriptDel(key)
+		} else {
+			err = client.ModelDel(key)
+		}
+		if err != nil {
+			if _, ok := err.(redis.Error); !ok {
+				return
+			}
+			// the key was already deleted by other means
+		}
+		if _, err = client.DoOrSend("HDEL", redis.Args{stateKey, key}, nil); err != nil {
+			return
+		}
+	}
+	return
+}
+
+func syncModel(client *Client, manifest *Manifest, model ManifestModel, state map[string]string, stateKey string, dryRun bool) (action SyncAction, err error) {
+	action = SyncAction{Action: SyncActionUnchanged, Kind: "model", Key: model.Key}
+	blob, err := manifest.readFile(model.File)
+	if err != nil {
+		return
+	}
+	hash := "model:" + manifestHash(blob)
+	exists, err := redis.Bool(client.DoOrSend("EXISTS", redis.Args{model.Key}, nil))
+	if err != nil {
+		return
+	}
+	if !exists {
+		action.Action = SyncActionCreate
+	} else {
+		meta, errMeta := client.ModelGetMeta(model.Key)
+		if errMeta != nil {
+			return action, errMeta
+		}
+		switch {
+		case !strings.EqualFold(meta.Backend, model.Backend):
+			action.Reason = fmt.Sprintf("backend %s -> %s", meta.Backend, model.Backend)
+		case !strings.EqualFold(meta.Device, model.Device):
+			action.Reason = fmt.Sprintf("device %s -> %s", meta.Device, model.Device)
+		case meta.Tag != model.Tag:
+			action.Reason = fmt.Sprintf("tag %q -> %q", meta.Tag, model.Tag)
+		case meta.BatchSize != model.BatchSize || meta.MinBatchSize != model.MinBatchSize || meta.MinBatchTimeout != model.MinBatchTimeout:
+			action.Reason = "batch settings"
+		case len(model.Inputs) > 0 && !manifestEqualStrings(meta.Inputs, model.Inputs):
+			action.Reason = "inputs"
+		case len(model.Outputs) > 0 && !manifestEqualStrings(meta.Outputs, model.Outputs):
+			action.Reason = "outputs"
+		default:
+			var serverHash string
+			if serverHash, err = syncModelServerHash(client, model.Key); err != nil {
+				return
+			}
+			switch {
+			case serverHash != hash:
+				action.Reason = "content"
+			case state[model.Key] != hash && !dryRun:
+				_, err = client.DoOrSend("HSET", redis.Args{stateKey, model.Key, hash}, nil)
+				return
+			}
+		}
+		if len(action.Reason) > 0 {
+			action.Action = SyncActionUpdate
+		}
+	}
+	if action.Action == SyncActionUnchanged || dryRun {
+		return
+	}
+	err = client.ModelStore(model.Key, model.Backend, model.Device, model.Tag, model.BatchSize, model.MinBatchSize, model.MinBatchTimeout, model.Inputs, model.Outputs, blob)
+	if err != nil {
+		return
+	}
+	_, err = client.DoOrSend("HSET", redis.Args{stateKey, model.Key, hash}, nil)
+	return
+}
+
+// syncModelServerHash returns the hash of the model blob held by the server
+func syncModelServerHash(client *Client, key string) (string, error) {
+	h := sha256.New()
+	if _, err := client.ModelGetBlob(key, h); err != nil {
+		return "", err
+	}
+	return "model:" + hex.EncodeToString(h.Sum(nil)), nil
+}
+
+func syncScript(client *Client, manifest *Manifest, script ManifestScript, state map[string]string, stateKey string, dryRun bool) (action SyncAction, err error) {
+	action = SyncAction{Action: SyncActionUnchanged, Kind: "script", Key: script.Key}
+	source, err := manifest.readFile(script.File)
+	if err != nil {
+		return
+	}
+	hash := "script:" + manifestHash(source)
+	exists, err := redis.Bool(client.DoOrSend("EXISTS", redis.Args{script.Key}, nil))
+	if err != nil {
+		return
+	}
+	if !exists {
+		action.Action = SyncActionCreate
+	} else {
+		var reply interface{}
+		if reply, err = client.DoOrSend("AI.SCRIPTGET", scriptGetFlatArgs(script.Key), nil); err != nil {
+			return
+		}
+		device, tag, serverSource, entryPoints, errParse := scriptGetParseReply(reply)
+		if errParse != nil {
+			return action, errParse
+		}
+		switch {
+		case !strings.EqualFold(device, script.Device):
+			action.Reason = fmt.Sprintf("device %s -> %s", device, script.Device)
+		case tag != script.Tag:
+			action.Reason = fmt.Sprintf("tag %q -> %q", tag, script.Tag)
+		case !manifestEqualStrings(entryPoints, script.EntryPoints):
+			action.Reason = "entry points"
+		case "script:"+manifestHash([]byte(serverSource)) != hash:
+			action.Reason = "content"
+		case state[script.Key] != hash && !dryRun:
+			_, err = client.DoOrSend("HSET", redis.Args{stateKey, script.Key, hash}, nil)
+			return
+		}
+		if len(action.Reason) > 0 {
+			action.Action = SyncActionUpdate
+		}
+	}
+	if action.Action == SyncActionUnchanged || dryRun {
+		return
+	}
+	if err = client.ScriptStoreWithTag(script.Key, script.Device, string(source), script.EntryPoints, script.Tag); err != nil {
+		return
+	}
+	_, err = client.DoOrSend("HSET", redis.Args{stateKey, script.Key, hash}, nil)
+	return
+}
+
+func manifestHash(content []byte) string {
+	sum := sha256.Sum256(content)
+	return hex.EncodeToString(sum[:])
+}
+
+func manifestEqualStrings(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for pos := range a {
+		if a[pos] != b[pos] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,163 @@
+package redisai
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/gomodule/redigo/redis"
+	"github.com/stretchr/testify/assert"
+)
+
+func writeTestManifest(t *testing.T, manifest string, files map[string]string) string {
+	dir, err := ioutil.TempDir("", "manifest")
+	assert.Nil(t, err)
+	t.Cleanup(func() { os.RemoveAll(dir) })
+	for name, content := range files {
+		assert.Nil(t, ioutil.WriteFile(filepath.Join(dir, name), []byte(content), 0644))
+	}
+	path := filepath.Join(dir, "manifest.json")
+	assert.Nil(t, ioutil.WriteFile(path, []byte(manifest), 0644))
+	return path
+}
+
+func TestLoadManifest(t *testing.T) {
+	tests := []struct {
+		name     string
+		manifest string
+		wantErr  bool
+	}{
+		{"valid", `{"models":[{"key":"m","file":"m.pb","backend":"TF","device":"CPU"}],"scripts":[{"key":"s","file":"s.py","device":"CPU","entry_points":["f"]}]}`, false},
+		{"unknown-field", `{"models":[{"key":"m","file":"m.pb","backend":"TF","device":"CPU","batch":1}]}`, true},
+		{"duplicate-key", `{"models":[{"key":"m","file":"m.pb","backend":"TF","device":"CPU"}],"scripts":[{"key":"m","file":"s.py","device":"CPU"}]}`, true},
+		{"missing-backend", `{"models":[{"key":"m","file":"m.pb","device":"CPU"}]}`, true},
+		{"missing-file", `{"scripts":[{"key":"s","device":"CPU"}]}`, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := writeTestManifest(t, tt.manifest, nil)
+			manifest, err := LoadManifest(path)
+			assert.Equal(t, tt.wantErr, err != nil)
+			if err == nil {
+				assert.Equal(t, filepath.Dir(path), manifest.Dir)
+			}
+		})
+	}
+}
+
+// fakeSyncServer replies to the commands issued by Sync from an in-memory state
+type fakeSyncServer struct {
+	state   map[string]string
+	models  map[string][]interface{}
+	scripts map[string][]interface{}
+}
+
+func (s *fakeSyncServer) handle(cmd string, args []interface{}) (interface{}, error) {
+	key, _ := redis.String(args[0], nil)
+	switch cmd {
+	case "HGETALL":
+		reply := []interface{}{}
+		for field, value := range s.state {
+			reply = append(reply, []byte(field), []byte(value))
+		}
+		return reply, nil
+	case "EXISTS":
+		if s.models[key] != nil || s.scripts[key] != nil {
+			return int64(1), nil
+		}
+		return int64(0), nil
+	case "AI.MODELGET":
+		if len(args) == 2 && args[1] == "BLOB" {
+			for pos := 0; pos+1 < len(s.models[key]); pos += 2 {
+				if field, _ := redis.String(s.models[key][pos], nil); field == "blob" {
+					return s.models[key][pos+1], nil
+				}
+			}
+			return nil, redis.Error("ERR model key is empty")
+		}
+		return s.models[key], nil
+	case "AI.SCRIPTGET":
+		return s.scripts[key], nil
+	case "AI.MODELDEL", "AI.SCRIPTDEL", "AI.MODELSTORE", "AI.SCRIPTSTORE", "HSET", "HDEL":
+		return "OK", nil
+	}
+	return nil, redis.Error("ERR unknown command " + cmd)
+}
+
+func TestSync(t *testing.T) {
+	path := writeTestManifest(t, `{
+		"models":[
+			{"key":"new","file":"m.pb","backend":"TF","device":"CPU"},
+			{"key":"same","file":"m.pb","backend":"TF","device":"CPU","tag":"v1"},
+			{"key":"retagged","file":"m.pb","backend":"TF","device":"CPU","tag":"v2"},
+			{"key":"unknown-content","file":"m.pb","backend":"TF","device":"CPU","tag":"v1"},
+			{"key":"overwritten","file":"m.pb","backend":"TF","device":"CPU","tag":"v1"}
+		],
+		"scripts":[{"key":"script","file":"s.py","device":"CPU","entry_points":["f"]}]
+	}`, map[string]string{"m.pb": "blob", "s.py": "def f(a): return a"})
+	manifest, err := LoadManifest(path)
+	assert.Nil(t, err)
+
+	modelMeta := func(tag string, blob string) []interface{} {
+		return []interface{}{[]byte("backend"), []byte("TF"), []byte("device"), []byte("CPU"), []byte("tag"), []byte(tag),
+			[]byte("batchsize"), int64(0), []byte("minbatchsize"), int64(0), []byte("minbatchtimeout"), int64(0),
+			[]byte("inputs"), []interface{}{}, []byte("outputs"), []interface{}{}, []byte("blob"), []byte(blob)}
+	}
+	server := &fakeSyncServer{
+		state: map[string]string{
+			"same":        "model:" + manifestHash([]byte("blob")),
+			"retagged":    "model:" + manifestHash([]byte("blob")),
+			"overwritten": "model:" + manifestHash([]byte("blob")),
+			"removed":     "script:" + manifestHash([]byte("old")),
+		},
+		models: map[string][]interface{}{
+			"same":            modelMeta("v1", "blob"),
+			"retagged":        modelMeta("v1", "blob"),
+			"unknown-content": modelMeta("v1", "other blob"),
+			// stored by other means with the same metadata, after Sync recorded its hash
+			"overwritten": modelMeta("v1", "other blob"),
+		},
+		scripts: map[string][]interface{}{
+			"script": {[]byte("device"), []byte("CPU"), []byte("tag"), []byte(""), []byte("source"), []byte("def f(a): return a"), []byte("Entry Points"), []interface{}{[]byte("f")}},
+		},
+	}
+	c, conn := createFakeClient(server.handle)
+
+	plan, err := Sync(c, manifest, SyncOptions{DryRun: true, Prune: true})
+	assert.Nil(t, err)
+	assert.Equal(t, []SyncAction{
+		{Action: SyncActionCreate, Kind: "model", Key: "new"},
+		{Action: SyncActionUnchanged, Kind: "model", Key: "same"},
+		{Action: SyncActionUpdate, Kind: "model", Key: "retagged", Reason: `tag "v1" -> "v2"`},
+		{Action: SyncActionUpdate, Kind: "model", Key: "unknown-content", Reason: "content"},
+		{Action: SyncActionUpdate, Kind: "model", Key: "overwritten", Reason: "content"},
+		{Action: SyncActionUnchanged, Kind: "script", Key: "script"},
+		{Action: SyncActionDelete, Kind: "script", Key: "removed", Reason: "not listed"},
+	}, plan.Actions)
+	assert.Len(t, plan.Changes(), 5)
+	for _, name := range conn.CommandNames() {
+		assert.NotContains(t, []string{"AI.MODELSTORE", "AI.SCRIPTSTORE", "AI.MODELDEL", "AI.SCRIPTDEL", "HSET", "HDEL"}, name)
+	}
+
+	c, conn = createFakeClient(server.handle)
+	_, err = Sync(c, manifest, SyncOptions{Prune: true})
+	assert.Nil(t, err)
+	var changes []string
+	for _, command := range conn.Commands() {
+		switch command[0] {
+		case "AI.MODELSTORE", "AI.SCRIPTSTORE", "AI.MODELDEL", "AI.SCRIPTDEL":
+			changes = append(changes, command[0].(string)+" "+command[1].(string))
+		case "HSET", "HDEL":
+			changes = append(changes, command[0].(string)+" "+command[2].(string))
+		}
+	}
+	assert.Equal(t, []string{
+		"AI.MODELSTORE new", "HSET new",
+		"AI.MODELSTORE retagged", "HSET retagged",
+		"AI.MODELSTORE unknown-content", "HSET unknown-content",
+		"AI.MODELSTORE overwritten", "HSET overwritten",
+		"HSET script",
+		"AI.SCRIPTDEL removed", "HDEL removed",
+	}, changes)
+}
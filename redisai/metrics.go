@@ -0,0 +1,119 @@
+package redisai
+
+import (
+	"context"
+	"io"
+	"net"
+	"strings"
+	"time"
+
+	"github.com/gomodule/redigo/redis"
+)
+
+// Error classes of the command metrics, see ErrorClass
+const (
+	// ErrorClassNetwork is a connection error, such as a refused or closed connection
+	ErrorClassNetwork = "network"
+	// ErrorClassTimeout is a connection read or write timeout
+	ErrorClassTimeout = "timeout"
+	// ErrorClassUnsupported is an UnsupportedFeatureError, returned before the command is sent
+	ErrorClassUnsupported = "unsupported"
+	// ErrorClassCircuitOpen is a CircuitOpenError, returned before the command is sent
+	ErrorClassCircuitOpen = "circuit-open"
+	// ErrorClassShed is an AdmissionError, returned before the command is sent
+	ErrorClassShed = "shed"
+	// ErrorClassClient is any other error raised by the client, such as an invalid argument or reply
+	ErrorClassClient = "client"
+)
+
+// CommandMetric is the measure of a single command, as passed to the MetricsSink
+type CommandMetric struct {
+	Command string
+	// Model is the model key run or accessed by the command, or the first model run by a DAG. Empty otherwise
+	Model string
+	// Duration is the time taken by the command, excluding QueueWait
+	Duration time.Duration
+	// QueueWait is the time the execution waited for its admission, zero for the commands not under admission control
+	QueueWait time.Duration
+	// PayloadBytes is the size in bytes of the arguments of the command, see CommandInfo.PayloadSize
+	PayloadBytes int
+	// ErrorClass is the ErrorClass of the command error, empty on success
+	ErrorClass string
+}
+
+// MetricsSink receives the client-side metrics of a client, see SetMetricsSink.
+// A sink shared by several clients must be safe for concurrent use.
+type MetricsSink interface {
+	// ObserveCommand is invoked after every command
+	ObserveCommand(metric CommandMetric)
+	// ObservePool is invoked after every command with the statistics of the connection pool of the client
+	ObservePool(stats redis.PoolStats)
+}
+
+// SetMetricsSink makes the client report the latency, payload size and error class of every command to sink,
+// along with the statistics of its connection pool. The durations include the network and queuing overhead that
+// AI.INFO does not report, but not the wait for admission, see SetAdmissionControl, which is reported apart; on a
+// pipelined client, they only account for the queuing of the commands.
+// The commands failing before being sent, such as on an UnsupportedFeatureError, are reported with a zero duration.
+func (c *Client) SetMetricsSink(sink MetricsSink) {
+	c.AddHook(&metricsHook{sink: sink, pool: c.Pool})
+}
+
+// metricsHook reports the commands to a MetricsSink
+type metricsHook struct {
+	sink MetricsSink
+	pool *redis.Pool
+}
+
+func (h *metricsHook) BeforeCommand(ctx context.Context, info *CommandInfo) context.Context {
+	return ctx
+}
+
+func (h *metricsHook) AfterCommand(ctx context.Context, info *CommandInfo) {
+	h.sink.ObserveCommand(CommandMetric{
+		Command:      info.Name,
+		Model:        info.Model,
+		Duration:     info.Duration,
+		QueueWait:    info.QueueWait,
+		PayloadBytes: info.PayloadSize,
+		ErrorClass:   ErrorClass(info.Err),
+	})
+	if h.pool != nil {
+		h.sink.ObservePool(h.pool.Stats())
+	}
+}
+
+// ErrorClass classifies a command error for the metrics: the error code of the server errors, such as ERR or
+// WRONGTYPE, or one of ErrorClassNetwork, ErrorClassTimeout, ErrorClassUnsupported, ErrorClassCircuitOpen,
+// ErrorClassShed and ErrorClassClient.
+// It returns an empty string for a nil error.
+func ErrorClass(err error) string {
+	if err == nil {
+		return ""
+	}
+	if redisErr, ok := err.(redis.Error); ok {
+		if fields := strings.Fields(string(redisErr)); len(fields) > 0 {
+			return fields[0]
+		}
+		return "ERR"
+	}
+	if _, ok := err.(*UnsupportedFeatureError); ok {
+		return ErrorClassUnsupported
+	}
+	if _, ok := err.(*CircuitOpenError); ok {
+		return ErrorClassCircuitOpen
+	}
+	if _, ok := err.(*AdmissionError); ok {
+		return ErrorClassShed
+	}
+	if netErr, ok := err.(net.Error); ok {
+		if netErr.Timeout() {
+			return ErrorClassTimeout
+		}
+		return ErrorClassNetwork
+	}
+	if err == io.EOF || err == io.ErrUnexpectedEOF {
+		return ErrorClassNetwork
+	}
+	return ErrorClassClient
+}
@@ -0,0 +1,211 @@
+// Package metrics exposes the client-side metrics of RedisAI clients in the Prometheus text format.
+//
+// A PrometheusSink is passed to redisai.Client.SetMetricsSink, and served as an http.Handler on the metrics path:
+//
+//	sink := metrics.NewPrometheusSink()
+//	client.SetMetricsSink(sink)
+//	http.Handle("/metrics", sink)
+//
+// It exposes the following metrics, without depending on the Prometheus client library:
+//
+//	redisai_client_command_duration_seconds{command}         histogram
+//	redisai_client_model_duration_seconds{model}             histogram, for the commands running or accessing a model
+//	redisai_client_admission_wait_seconds{command}           histogram, for the executions under admission control
+//	redisai_client_command_payload_bytes_total{command}      counter
+//	redisai_client_command_errors_total{command,class}       counter, see redisai.ErrorClass
+//	redisai_client_pool_active_connections                   gauge
+//	redisai_client_pool_idle_connections                     gauge
+//	redisai_client_pool_wait_total                           counter
+//	redisai_client_pool_wait_seconds_total                   counter
+//
+// The pool metrics are the statistics of the connection pool as of the last command.
+package metrics
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/RedisAI/redisai-go/redisai"
+	"github.com/gomodule/redigo/redis"
+)
+
+// DefaultBuckets are the upper bounds in seconds of the latency histograms buckets
+var DefaultBuckets = []float64{0.0005, 0.001, 0.0025, 0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5}
+
+// histogram is a Prometheus histogram, with the cumulative counts of its buckets computed on exposition
+type histogram struct {
+	counts []uint64
+	count  uint64
+	sum    float64
+}
+
+func (h *histogram) observe(buckets []float64, value float64) {
+	if h.counts == nil {
+		h.counts = make([]uint64, len(buckets))
+	}
+	if pos := sort.SearchFloat64s(buckets, value); pos < len(buckets) {
+		h.counts[pos]++
+	}
+	h.count++
+	h.sum += value
+}
+
+// PrometheusSink is a redisai.MetricsSink exposing the metrics in the Prometheus text format.
+// It is safe for concurrent use, and can be shared by several clients.
+type PrometheusSink struct {
+	buckets []float64
+
+	mu              sync.Mutex
+	commandDuration map[string]*histogram
+	modelDuration   map[string]*histogram
+	admissionWait   map[string]*histogram
+	payloadBytes    map[string]uint64
+	errors          map[[2]string]uint64
+	pool            redis.PoolStats
+}
+
+// NewPrometheusSink returns a sink whose latency histograms use DefaultBuckets
+func NewPrometheusSink() *PrometheusSink {
+	return NewPrometheusSinkWithBuckets(DefaultBuckets)
+}
+
+// NewPrometheusSinkWithBuckets returns a sink whose latency histograms use the given upper bounds in seconds
+func NewPrometheusSinkWithBuckets(buckets []float64) *PrometheusSink {
+	sorted := append([]float64(nil), buckets...)
+	sort.Float64s(sorted)
+	return &PrometheusSink{
+		buckets:         sorted,
+		commandDuration: map[string]*histogram{},
+		modelDuration:   map[string]*histogram{},
+		admissionWait:   map[string]*histogram{},
+		payloadBytes:    map[string]uint64{},
+		errors:          map[[2]string]uint64{},
+	}
+}
+
+// ObserveCommand records the latency, admission wait, payload size and error class of a command
+func (s *PrometheusSink) ObserveCommand(metric redisai.CommandMetric) {
+	seconds := metric.Duration.Seconds()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.histogram(s.commandDuration, metric.Command).observe(s.buckets, seconds)
+	if len(metric.Model) > 0 {
+		s.histogram(s.modelDuration, metric.Model).observe(s.buckets, seconds)
+	}
+	if metric.QueueWait > 0 {
+		s.histogram(s.admissionWait, metric.Command).observe(s.buckets, metric.QueueWait.Seconds())
+	}
+	s.payloadBytes[metric.Command] += uint64(metric.PayloadBytes)
+	if len(metric.ErrorClass) > 0 {
+		s.errors[[2]string{metric.Command, metric.ErrorClass}]++
+	}
+}
+
+// ObservePool records the statistics of the connection pool
+func (s *PrometheusSink) ObservePool(stats redis.PoolStats) {
+	s.mu.Lock()
+	s.pool = stats
+	s.mu.Unlock()
+}
+
+func (s *PrometheusSink) histogram(histograms map[string]*histogram, label string) *histogram {
+	h, ok := histograms[label]
+	if !ok {
+		h = &histogram{}
+		histograms[label] = h
+	}
+	return h
+}
+
+// ServeHTTP serves the metrics in the Prometheus text format
+func (s *PrometheusSink) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	_ = s.Write(w)
+}
+
+// Write writes the metrics in the Prometheus text format
+func (s *PrometheusSink) Write(w io.Writer) error {
+	var b strings.Builder
+	s.mu.Lock()
+	s.writeHistograms(&b, "redisai_client_command_duration_seconds", "Latency of the RedisAI commands, as measured by the client.", "command", s.commandDuration)
+	s.writeHistograms(&b, "redisai_client_model_duration_seconds", "Latency of the RedisAI commands running or accessing a model, by model key.", "model", s.modelDuration)
+	s.writeHistograms(&b, "redisai_client_admission_wait_seconds", "Time the RedisAI executions waited for their admission.", "command", s.admissionWait)
+
+	writeHeader(&b, "redisai_client_command_payload_bytes_total", "Size of the arguments of the RedisAI commands.", "counter")
+	for _, command := range sortedKeys(s.payloadBytes) {
+		fmt.Fprintf(&b, "redisai_client_command_payload_bytes_total{command=%s} %d\n", quote(command), s.payloadBytes[command])
+	}
+
+	writeHeader(&b, "redisai_client_command_errors_total", "Errors of the RedisAI commands, by error class.", "counter")
+	errorKeys := make([][2]string, 0, len(s.errors))
+	for key := range s.errors {
+		errorKeys = append(errorKeys, key)
+	}
+	sort.Slice(errorKeys, func(i, j int) bool {
+		return errorKeys[i][0] < errorKeys[j][0] || errorKeys[i][0] == errorKeys[j][0] && errorKeys[i][1] < errorKeys[j][1]
+	})
+	for _, key := range errorKeys {
+		fmt.Fprintf(&b, "redisai_client_command_errors_total{command=%s,class=%s} %d\n", quote(key[0]), quote(key[1]), s.errors[key])
+	}
+
+	writeHeader(&b, "redisai_client_pool_active_connections", "Connections of the pool, idle or in use.", "gauge")
+	fmt.Fprintf(&b, "redisai_client_pool_active_connections %d\n", s.pool.ActiveCount)
+	writeHeader(&b, "redisai_client_pool_idle_connections", "Idle connections of the pool.", "gauge")
+	fmt.Fprintf(&b, "redisai_client_pool_idle_connections %d\n", s.pool.IdleCount)
+	writeHeader(&b, "redisai_client_pool_wait_total", "Connections waited for.", "counter")
+	fmt.Fprintf(&b, "redisai_client_pool_wait_total %d\n", s.pool.WaitCount)
+	writeHeader(&b, "redisai_client_pool_wait_seconds_total", "Time blocked waiting for a connection.", "counter")
+	fmt.Fprintf(&b, "redisai_client_pool_wait_seconds_total %s\n", formatFloat(s.pool.WaitDuration.Seconds()))
+	s.mu.Unlock()
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+func (s *PrometheusSink) writeHistograms(b *strings.Builder, name, help, label string, histograms map[string]*histogram) {
+	writeHeader(b, name, help, "histogram")
+	labels := make([]string, 0, len(histograms))
+	for value := range histograms {
+		labels = append(labels, value)
+	}
+	sort.Strings(labels)
+	for _, value := range labels {
+		h := histograms[value]
+		labelPair := label + "=" + quote(value)
+		var cumulative uint64
+		for pos, bound := range s.buckets {
+			cumulative += h.counts[pos]
+			fmt.Fprintf(b, "%s_bucket{%s,le=%s} %d\n", name, labelPair, quote(formatFloat(bound)), cumulative)
+		}
+		fmt.Fprintf(b, "%s_bucket{%s,le=\"+Inf\"} %d\n", name, labelPair, h.count)
+		fmt.Fprintf(b, "%s_sum{%s} %s\n", name, labelPair, formatFloat(h.sum))
+		fmt.Fprintf(b, "%s_count{%s} %d\n", name, labelPair, h.count)
+	}
+}
+
+func writeHeader(b *strings.Builder, name, help, metricType string) {
+	fmt.Fprintf(b, "# HELP %s %s\n# TYPE %s %s\n", name, help, name, metricType)
+}
+
+func sortedKeys(m map[string]uint64) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func formatFloat(value float64) string {
+	return strconv.FormatFloat(value, 'g', -1, 64)
+}
+
+// quote quotes a label value, escaping the backslashes, double quotes and line feeds
+func quote(value string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(value) + `"`
+}
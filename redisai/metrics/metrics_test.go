@@ -0,0 +1,61 @@
+package metrics
+
+import (
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/RedisAI/redisai-go/redisai"
+	"github.com/RedisAI/redisai-go/redisai/internal/redistest"
+	"github.com/gomodule/redigo/redis"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPrometheusSink(t *testing.T) {
+	sink := NewPrometheusSinkWithBuckets([]float64{0.1, 0.01})
+	sink.ObserveCommand(redisai.CommandMetric{Command: "AI.MODELEXECUTE", Model: "fraud", Duration: 5 * time.Millisecond, PayloadBytes: 10})
+	sink.ObserveCommand(redisai.CommandMetric{Command: "AI.MODELEXECUTE", Model: "fraud", Duration: 50 * time.Millisecond, QueueWait: 20 * time.Millisecond, PayloadBytes: 10, ErrorClass: "ERR"})
+	sink.ObserveCommand(redisai.CommandMetric{Command: "AI.TENSORSET", Duration: time.Second, PayloadBytes: 4})
+	sink.ObservePool(redis.PoolStats{ActiveCount: 2, IdleCount: 1, WaitCount: 3, WaitDuration: 1500 * time.Millisecond})
+
+	recorder := httptest.NewRecorder()
+	sink.ServeHTTP(recorder, httptest.NewRequest("GET", "/metrics", nil))
+	assert.Equal(t, "text/plain; version=0.0.4; charset=utf-8", recorder.Header().Get("Content-Type"))
+	body := recorder.Body.String()
+	for _, line := range []string{
+		"# TYPE redisai_client_command_duration_seconds histogram",
+		`redisai_client_command_duration_seconds_bucket{command="AI.MODELEXECUTE",le="0.01"} 1`,
+		`redisai_client_command_duration_seconds_bucket{command="AI.MODELEXECUTE",le="0.1"} 2`,
+		`redisai_client_command_duration_seconds_bucket{command="AI.MODELEXECUTE",le="+Inf"} 2`,
+		`redisai_client_command_duration_seconds_sum{command="AI.MODELEXECUTE"} 0.055`,
+		`redisai_client_command_duration_seconds_bucket{command="AI.TENSORSET",le="0.1"} 0`,
+		`redisai_client_command_duration_seconds_count{command="AI.TENSORSET"} 1`,
+		`redisai_client_model_duration_seconds_count{model="fraud"} 2`,
+		`redisai_client_admission_wait_seconds_bucket{command="AI.MODELEXECUTE",le="0.01"} 0`,
+		`redisai_client_admission_wait_seconds_count{command="AI.MODELEXECUTE"} 1`,
+		`redisai_client_command_payload_bytes_total{command="AI.MODELEXECUTE"} 20`,
+		`redisai_client_command_errors_total{command="AI.MODELEXECUTE",class="ERR"} 1`,
+		"redisai_client_pool_active_connections 2",
+		"redisai_client_pool_idle_connections 1",
+		"redisai_client_pool_wait_total 3",
+		"redisai_client_pool_wait_seconds_total 1.5",
+	} {
+		assert.Contains(t, strings.Split(body, "\n"), line)
+	}
+	assert.NotContains(t, body, `model=""`)
+}
+
+func TestPrometheusSink_Client(t *testing.T) {
+	pool, _ := redistest.NewPool(func(cmd string, args []interface{}) (interface{}, error) {
+		return "OK", nil
+	})
+	c := redisai.Connect("", pool)
+	sink := NewPrometheusSink()
+	c.SetMetricsSink(sink)
+	assert.Nil(t, c.ModelDel("model\"with\\quotes"))
+	var b strings.Builder
+	assert.Nil(t, sink.Write(&b))
+	assert.Contains(t, b.String(), `redisai_client_model_duration_seconds_count{model="model\"with\\quotes"} 1`)
+	assert.Contains(t, b.String(), "redisai_client_pool_active_connections 1")
+}
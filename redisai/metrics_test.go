@@ -0,0 +1,85 @@
+package redisai
+
+import (
+	"errors"
+	"io"
+	"net"
+	"testing"
+
+	"github.com/gomodule/redigo/redis"
+	"github.com/stretchr/testify/assert"
+)
+
+type recordingSink struct {
+	commands []CommandMetric
+	pools    []redis.PoolStats
+}
+
+func (s *recordingSink) ObserveCommand(metric CommandMetric) { s.commands = append(s.commands, metric) }
+
+func (s *recordingSink) ObservePool(stats redis.PoolStats) { s.pools = append(s.pools, stats) }
+
+func TestClient_SetMetricsSink(t *testing.T) {
+	c, _ := createFakeClient(func(cmd string, args []interface{}) (interface{}, error) {
+		if cmd == "AI.MODELEXECUTE" {
+			return nil, redis.Error("WRONGTYPE Operation against a key holding the wrong kind of value")
+		}
+		return "OK", nil
+	})
+	sink := &recordingSink{}
+	c.SetMetricsSink(sink)
+	assert.Nil(t, c.ModelDel("mymodel"))
+	assert.NotNil(t, c.ModelExecute("mymodel", []string{"a"}, []string{"b"}))
+	assert.Len(t, sink.commands, 2)
+	assert.Len(t, sink.pools, 2)
+	assert.Equal(t, "AI.MODELDEL", sink.commands[0].Command)
+	assert.Equal(t, "mymodel", sink.commands[0].Model)
+	assert.Equal(t, len("mymodel"), sink.commands[0].PayloadBytes)
+	assert.Equal(t, "", sink.commands[0].ErrorClass)
+	assert.Equal(t, "WRONGTYPE", sink.commands[1].ErrorClass)
+}
+
+func TestClient_SetMetricsSink_Unsupported(t *testing.T) {
+	c, conn := createFakeClient(func(cmd string, args []interface{}) (interface{}, error) {
+		return "OK", nil
+	})
+	c.SetCapabilities(&ServerCapabilities{Version: 10003})
+	sink := &recordingSink{}
+	c.SetMetricsSink(sink)
+	assert.NotNil(t, c.ModelExecuteWithTimeout("mymodel", []string{"a"}, []string{"b"}, 100))
+	assert.Empty(t, conn.Commands())
+	assert.Len(t, sink.commands, 1)
+	assert.Equal(t, "AI.MODELRUN", sink.commands[0].Command)
+	assert.Equal(t, ErrorClassUnsupported, sink.commands[0].ErrorClass)
+	assert.Zero(t, sink.commands[0].Duration)
+}
+
+type timeoutError struct{}
+
+func (timeoutError) Error() string   { return "i/o timeout" }
+func (timeoutError) Timeout() bool   { return true }
+func (timeoutError) Temporary() bool { return true }
+
+func TestErrorClass(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want string
+	}{
+		{"nil", nil, ""},
+		{"server", redis.Error("ERR model key is empty"), "ERR"},
+		{"server-empty", redis.Error(""), "ERR"},
+		{"unsupported", &UnsupportedFeatureError{"TIMEOUT", 10003, VersionExecuteCommands}, ErrorClassUnsupported},
+		{"circuit-open", &CircuitOpenError{Key: "mymodel", State: CircuitOpen}, ErrorClassCircuitOpen},
+		{"shed", &AdmissionError{Key: "mymodel", Class: "batch", Reason: ShedQueueFull}, ErrorClassShed},
+		{"timeout", timeoutError{}, ErrorClassTimeout},
+		{"network", &net.OpError{Op: "dial", Net: "tcp", Err: errors.New("connection refused")}, ErrorClassNetwork},
+		{"eof", io.EOF, ErrorClassNetwork},
+		{"client", errors.New("redisai.ModelGet: unexpected reply"), ErrorClassClient},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, ErrorClass(tt.err))
+		})
+	}
+}
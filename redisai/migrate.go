@@ -0,0 +1,310 @@
+package redisai
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/gomodule/redigo/redis"
+)
+
+// Drift states of a key between the source and the destination of a migration
+const (
+	DriftInSync = "in-sync"
+	// DriftMissing is a key of the source that the destination does not hold
+	DriftMissing = "missing"
+	// DriftTag is a key whose tag differs
+	DriftTag = "tag"
+	// DriftMetadata is a key whose backend, device, batching, inputs, outputs, entry points, type or shape differ
+	DriftMetadata = "metadata"
+	// DriftContent is a key whose model blob, script source or tensor value differ
+	DriftContent = "content"
+	// DriftExtra is a key of the destination that the source does not hold. It is never copied nor deleted
+	DriftExtra = "extra"
+)
+
+// Drift is the state of a single key between the source and the destination of a migration
+type Drift struct {
+	Drift string `json:"drift"`
+	// Kind is either "model", "script" or "tensor"
+	Kind   string `json:"kind"`
+	Key    string `json:"key"`
+	Reason string `json:"reason,omitempty"`
+	// Copied reports whether the key was copied to the destination
+	Copied bool `json:"copied,omitempty"`
+}
+
+// DriftReport lists the state of every compared key, by kind then key
+type DriftReport struct {
+	Entries []Drift `json:"entries"`
+}
+
+// Drifted returns the entries of the keys that are not in sync
+func (r *DriftReport) Drifted() []Drift {
+	drifted := make([]Drift, 0, len(r.Entries))
+	for _, entry := range r.Entries {
+		if entry.Drift != DriftInSync {
+			drifted = append(drifted, entry)
+		}
+	}
+	return drifted
+}
+
+// String returns the report as one line per key
+func (r *DriftReport) String() string {
+	var b strings.Builder
+	for _, entry := range r.Entries {
+		fmt.Fprintf(&b, "%-8s %-6s %s", entry.Drift, entry.Kind, entry.Key)
+		if len(entry.Reason) > 0 {
+			fmt.Fprintf(&b, " (%s)", entry.Reason)
+		}
+		if entry.Copied {
+			b.WriteString(" copied")
+		}
+		b.WriteByte('\n')
+	}
+	return b.String()
+}
+
+// MigrateOptions tunes the behavior of Migrate
+type MigrateOptions struct {
+	// Match is a glob-style pattern filtering the keys. Empty means every key
+	Match string
+	// Copy copies the missing and drifted keys to the destination. Only the report is computed otherwise
+	Copy bool
+	// Select, when set, restricts the copied keys to the ones for which it returns true
+	Select func(drift Drift) bool
+	// Tensors compares, and copies, the tensors persisted in the keyspace along with the models and scripts
+	Tensors bool
+	// Dial opens the connections to the other master nodes of a source or destination Redis Cluster, see
+	// ClusterNodes. Nil dials them over TCP
+	Dial func(address string) (redis.Conn, error)
+}
+
+// Migrate compares the models and scripts of the source and destination servers by metadata and content hash,
+// and returns the drift report. With options.Copy, the keys missing or drifted on the destination are copied
+// from the source, overwriting the destination ones; the keys only held by the destination are left untouched.
+//
+// The source and destination may be Redis Clusters: their master nodes are discovered with ClusterNodes, every
+// key is read from the node holding it, and the copies of the keys missing on the destination follow its MOVED
+// redirections.
+func Migrate(source, destination *Client, options MigrateOptions) (*DriftReport, error) {
+	if source.PipelineActive || destination.PipelineActive {
+		return nil, errors.New("redisai.Migrate: servers can't be compared on pipelined clients")
+	}
+	sourceNodes, err := ClusterNodes(source, options.Dial)
+	if err != nil {
+		return nil, fmt.Errorf("redisai.Migrate: source: %v", err)
+	}
+	defer CloseClusterNodes(source, sourceNodes)
+	destinationNodes, err := ClusterNodes(destination, options.Dial)
+	if err != nil {
+		return nil, fmt.Errorf("redisai.Migrate: destination: %v", err)
+	}
+	defer CloseClusterNodes(destination, destinationNodes)
+	redirections := make(map[string]*Client)
+	defer func() {
+		for _, node := range redirections {
+			node.Close()
+			node.Pool.Close()
+		}
+	}()
+	kinds := []migrateKind{
+		{"model", KeyTypeModel, migrateCompareModel, migrateCopyModel},
+		{"script", KeyTypeScript, migrateCompareScript, migrateCopyScript},
+	}
+	if options.Tensors {
+		kinds = append(kinds, migrateKind{"tensor", KeyTypeTensor, migrateCompareTensor, migrateCopyTensor})
+	}
+	report := &DriftReport{}
+	for _, kind := range kinds {
+		sourceKeys, err := scanClusterKeys(sourceNodes, kind.keyType, options.Match)
+		if err != nil {
+			return report, err
+		}
+		destinationKeys, err := scanClusterKeys(destinationNodes, kind.keyType, options.Match)
+		if err != nil {
+			return report, err
+		}
+		keys := make([]string, 0, len(sourceKeys)+len(destinationKeys))
+		for key := range sourceKeys {
+			keys = append(keys, key)
+		}
+		for key := range destinationKeys {
+			if sourceKeys[key] == nil {
+				keys = append(keys, key)
+			}
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			entry := Drift{Drift: DriftInSync, Kind: kind.name, Key: key}
+			switch {
+			case sourceKeys[key] == nil:
+				entry.Drift = DriftExtra
+			case destinationKeys[key] == nil:
+				entry.Drift = DriftMissing
+			default:
+				if entry.Drift, entry.Reason, err = kind.compare(sourceKeys[key], destinationKeys[key], key); err != nil {
+					return report, fmt.Errorf("redisai.Migrate: %s %s: %v", kind.name, key, err)
+				}
+			}
+			if options.Copy && entry.Drift != DriftInSync && entry.Drift != DriftExtra && (options.Select == nil || options.Select(entry)) {
+				target := destinationKeys[key]
+				if target == nil {
+					target = destination
+				}
+				err = kind.copy(sourceKeys[key], target, key)
+				if address, moved := migrateRedirection(err); moved {
+					if redirections[address] == nil {
+						redirections[address] = connectClusterNode(address, options.Dial)
+					}
+					err = kind.copy(sourceKeys[key], redirections[address], key)
+				}
+				if err != nil {
+					return report, fmt.Errorf("redisai.Migrate: copying %s %s: %v", kind.name, key, err)
+				}
+				entry.Copied = true
+			}
+			report.Entries = append(report.Entries, entry)
+		}
+	}
+	return report, nil
+}
+
+// migrateKind compares and copies the keys of a RedisAI type
+type migrateKind struct {
+	name    string
+	keyType string
+	compare func(source, destination *Client, key string) (drift, reason string, err error)
+	copy    func(source, destination *Client, key string) error
+}
+
+// migrateRedirection returns the address of the node a Redis Cluster redirects a key to with a MOVED error
+func migrateRedirection(err error) (address string, moved bool) {
+	if redisErr, ok := err.(redis.Error); ok {
+		if fields := strings.Fields(string(redisErr)); len(fields) == 3 && fields[0] == "MOVED" {
+			return fields[2], true
+		}
+	}
+	return "", false
+}
+
+func migrateCompareModel(source, destination *Client, key string) (drift, reason string, err error) {
+	sourceMeta, err := source.ModelGetMeta(key)
+	if err != nil {
+		return
+	}
+	destinationMeta, err := destination.ModelGetMeta(key)
+	if err != nil {
+		return
+	}
+	switch {
+	case !strings.EqualFold(sourceMeta.Backend, destinationMeta.Backend):
+		return DriftMetadata, fmt.Sprintf("backend %s != %s", sourceMeta.Backend, destinationMeta.Backend), nil
+	case !strings.EqualFold(sourceMeta.Device, destinationMeta.Device):
+		return DriftMetadata, fmt.Sprintf("device %s != %s", sourceMeta.Device, destinationMeta.Device), nil
+	case sourceMeta.BatchSize != destinationMeta.BatchSize || sourceMeta.MinBatchSize != destinationMeta.MinBatchSize || sourceMeta.MinBatchTimeout != destinationMeta.MinBatchTimeout:
+		return DriftMetadata, "batch settings", nil
+	case !manifestEqualStrings(sourceMeta.Inputs, destinationMeta.Inputs):
+		return DriftMetadata, "inputs", nil
+	case !manifestEqualStrings(sourceMeta.Outputs, destinationMeta.Outputs):
+		return DriftMetadata, "outputs", nil
+	case sourceMeta.Tag != destinationMeta.Tag:
+		return DriftTag, fmt.Sprintf("tag %q != %q", sourceMeta.Tag, destinationMeta.Tag), nil
+	}
+	sourceHash, destinationHash := sha256.New(), sha256.New()
+	if _, err = source.ModelGetBlob(key, sourceHash); err != nil {
+		return
+	}
+	if _, err = destination.ModelGetBlob(key, destinationHash); err != nil {
+		return
+	}
+	if !bytes.Equal(sourceHash.Sum(nil), destinationHash.Sum(nil)) {
+		return DriftContent, "blob", nil
+	}
+	return DriftInSync, "", nil
+}
+
+func migrateCopyModel(source, destination *Client, key string) error {
+	meta, err := source.ModelGetMeta(key)
+	if err != nil {
+		return err
+	}
+	var blob bytes.Buffer
+	if _, err = source.ModelGetBlob(key, &blob); err != nil {
+		return err
+	}
+	return destination.ModelStore(key, meta.Backend, meta.Device, meta.Tag, meta.BatchSize, meta.MinBatchSize, meta.MinBatchTimeout, meta.Inputs, meta.Outputs, blob.Bytes())
+}
+
+// migrateGetScript reads a script with its source in a single command
+func migrateGetScript(client *Client, key string) (meta ScriptMeta, source string, err error) {
+	reply, err := client.DoOrSend("AI.SCRIPTGET", scriptGetFlatArgs(key), nil)
+	if err != nil {
+		return
+	}
+	meta.Device, meta.Tag, source, meta.EntryPoints, err = scriptGetParseReply(reply)
+	return
+}
+
+func migrateCompareScript(source, destination *Client, key string) (drift, reason string, err error) {
+	sourceMeta, sourceSource, err := migrateGetScript(source, key)
+	if err != nil {
+		return
+	}
+	destinationMeta, destinationSource, err := migrateGetScript(destination, key)
+	if err != nil {
+		return
+	}
+	switch {
+	case !strings.EqualFold(sourceMeta.Device, destinationMeta.Device):
+		return DriftMetadata, fmt.Sprintf("device %s != %s", sourceMeta.Device, destinationMeta.Device), nil
+	case !manifestEqualStrings(sourceMeta.EntryPoints, destinationMeta.EntryPoints):
+		return DriftMetadata, "entry points", nil
+	case sourceMeta.Tag != destinationMeta.Tag:
+		return DriftTag, fmt.Sprintf("tag %q != %q", sourceMeta.Tag, destinationMeta.Tag), nil
+	case manifestHash([]byte(sourceSource)) != manifestHash([]byte(destinationSource)):
+		return DriftContent, "source", nil
+	}
+	return DriftInSync, "", nil
+}
+
+func migrateCopyScript(source, destination *Client, key string) error {
+	meta, script, err := migrateGetScript(source, key)
+	if err != nil {
+		return err
+	}
+	return destination.ScriptStoreWithTag(key, meta.Device, script, meta.EntryPoints, meta.Tag)
+}
+
+func migrateCompareTensor(source, destination *Client, key string) (drift, reason string, err error) {
+	sourceType, sourceShape, sourceBlob, err := source.TensorGetBlob(key)
+	if err != nil {
+		return
+	}
+	destinationType, destinationShape, destinationBlob, err := destination.TensorGetBlob(key)
+	if err != nil {
+		return
+	}
+	switch {
+	case sourceType != destinationType:
+		return DriftMetadata, fmt.Sprintf("dtype %s != %s", sourceType, destinationType), nil
+	case !reflect.DeepEqual(sourceShape, destinationShape):
+		return DriftMetadata, fmt.Sprintf("shape %v != %v", sourceShape, destinationShape), nil
+	case !bytes.Equal(sourceBlob, destinationBlob):
+		return DriftContent, "values", nil
+	}
+	return DriftInSync, "", nil
+}
+
+func migrateCopyTensor(source, destination *Client, key string) error {
+	dtype, shape, blob, err := source.TensorGetBlob(key)
+	if err != nil {
+		return err
+	}
+	return destination.TensorSet(key, dtype, shape, blob)
+}
@@ -0,0 +1,103 @@
+package redisai
+
+import (
+	"testing"
+
+	"github.com/RedisAI/redisai-go/redisai/internal/redistest"
+	"github.com/gomodule/redigo/redis"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMigrate(t *testing.T) {
+	source, destination := newFakeBackupServer(), newFakeBackupServer()
+	sourceClient, _ := createFakeClient(source.handle)
+	destinationClient, destinationConn := createFakeClient(destination.handle)
+	for _, c := range []*Client{sourceClient, destinationClient} {
+		assert.Nil(t, c.ModelStore("same", BackendTF, DeviceCPU, "v1", 0, 0, 0, []string{"a"}, []string{"b"}, []byte("blob")))
+		assert.Nil(t, c.ScriptStoreWithTag("script", DeviceCPU, "def f(a): return a", []string{"f"}, ""))
+	}
+	assert.Nil(t, sourceClient.ModelStore("missing", BackendTF, DeviceCPU, "", 0, 0, 0, nil, nil, []byte("blob")))
+	assert.Nil(t, sourceClient.ModelStore("retagged", BackendTF, DeviceCPU, "v2", 0, 0, 0, nil, nil, []byte("blob")))
+	assert.Nil(t, destinationClient.ModelStore("retagged", BackendTF, DeviceCPU, "v1", 0, 0, 0, nil, nil, []byte("blob")))
+	assert.Nil(t, sourceClient.ModelStore("retrained", BackendTF, DeviceCPU, "v1", 0, 0, 0, nil, nil, []byte("new blob")))
+	assert.Nil(t, destinationClient.ModelStore("retrained", BackendTF, DeviceCPU, "v1", 0, 0, 0, nil, nil, []byte("old blob")))
+	assert.Nil(t, sourceClient.ModelStore("moved", BackendTF, DeviceGPU, "", 0, 0, 0, nil, nil, []byte("blob")))
+	assert.Nil(t, destinationClient.ModelStore("moved", BackendTF, DeviceCPU, "", 0, 0, 0, nil, nil, []byte("blob")))
+	assert.Nil(t, destinationClient.ModelStore("extra", BackendTF, DeviceCPU, "", 0, 0, 0, nil, nil, []byte("blob")))
+	assert.Nil(t, sourceClient.TensorSet("weights", TypeFloat, []int64{2}, []byte("12345678")))
+
+	stored := len(destinationConn.Commands())
+	report, err := Migrate(sourceClient, destinationClient, MigrateOptions{})
+	assert.Nil(t, err)
+	assert.Equal(t, []Drift{
+		{Drift: DriftExtra, Kind: "model", Key: "extra"},
+		{Drift: DriftMissing, Kind: "model", Key: "missing"},
+		{Drift: DriftMetadata, Kind: "model", Key: "moved", Reason: "device GPU != CPU"},
+		{Drift: DriftTag, Kind: "model", Key: "retagged", Reason: `tag "v2" != "v1"`},
+		{Drift: DriftContent, Kind: "model", Key: "retrained", Reason: "blob"},
+	}, report.Drifted())
+	assert.Len(t, report.Entries, 7)
+	assert.NotContains(t, destinationConn.CommandNames()[stored:], "AI.MODELSTORE")
+
+	report, err = Migrate(sourceClient, destinationClient, MigrateOptions{
+		Copy:    true,
+		Tensors: true,
+		Select:  func(drift Drift) bool { return drift.Key != "moved" },
+	})
+	assert.Nil(t, err)
+	copied := []string{}
+	for _, entry := range report.Entries {
+		if entry.Copied {
+			copied = append(copied, entry.Key)
+		}
+	}
+	assert.Equal(t, []string{"missing", "retagged", "retrained", "weights"}, copied)
+	assert.Equal(t, []byte("new blob"), destination.blobs["retrained"])
+	assert.Equal(t, source.models["retagged"], destination.models["retagged"])
+	assert.Equal(t, source.tensors["weights"], destination.tensors["weights"])
+	assert.NotNil(t, destination.models["extra"])
+
+	report, err = Migrate(sourceClient, destinationClient, MigrateOptions{Tensors: true, Match: "re*"})
+	assert.Nil(t, err)
+	assert.Empty(t, report.Drifted())
+	assert.Len(t, report.Entries, 2)
+}
+
+func TestMigrate_Cluster(t *testing.T) {
+	sourceNodes := "1 10.0.0.1:6379@16379 myself,master - 0 0 1 connected 0-8191\n" +
+		"2 10.0.0.2:6379@16379 master - 0 0 2 connected 8192-16383\n"
+	destinationNodes := "3 10.0.1.1:6379@16379 myself,master - 0 0 1 connected 0-8191\n" +
+		"4 10.0.1.2:6379@16379 master - 0 0 2 connected 8192-16383\n"
+	source, otherSource := newFakeBackupServer(), newFakeBackupServer()
+	destination, otherDestination := newFakeBackupServer(), newFakeBackupServer()
+	sourceClient, _ := createFakeClient(source.handleCluster(sourceNodes))
+	destinationClient, _ := createFakeClient(func(cmd string, args []interface{}) (interface{}, error) {
+		if cmd == "AI.MODELSTORE" && args[0] == "churn" {
+			return nil, redis.Error("MOVED 9000 10.0.1.2:6379")
+		}
+		return destination.handleCluster(destinationNodes)(cmd, args)
+	})
+	conns := map[string]*redistest.Conn{
+		"10.0.0.2:6379": {Handler: otherSource.handleCluster(sourceNodes)},
+		"10.0.1.2:6379": {Handler: otherDestination.handleCluster(destinationNodes)},
+	}
+	dial := func(address string) (redis.Conn, error) { return conns[address], nil }
+	assert.Nil(t, sourceClient.ModelStore("fraud", BackendTF, DeviceCPU, "", 0, 0, 0, nil, nil, []byte("fraud blob")))
+	otherSourceClient := Connect("", &redis.Pool{Dial: func() (redis.Conn, error) { return dial("10.0.0.2:6379") }})
+	assert.Nil(t, otherSourceClient.ModelStore("churn", BackendTF, DeviceCPU, "", 0, 0, 0, nil, nil, []byte("churn blob")))
+
+	report, err := Migrate(sourceClient, destinationClient, MigrateOptions{Copy: true, Dial: dial})
+	assert.Nil(t, err)
+	assert.Equal(t, []Drift{
+		{Drift: DriftMissing, Kind: "model", Key: "churn", Copied: true},
+		{Drift: DriftMissing, Kind: "model", Key: "fraud", Copied: true},
+	}, report.Drifted())
+	assert.Equal(t, []byte("churn blob"), otherDestination.blobs["churn"])
+	assert.Nil(t, destination.blobs["churn"])
+	assert.Equal(t, []byte("fraud blob"), destination.blobs["fraud"])
+
+	report, err = Migrate(sourceClient, destinationClient, MigrateOptions{Dial: dial})
+	assert.Nil(t, err)
+	assert.Empty(t, report.Drifted())
+	assert.Len(t, report.Entries, 2)
+}
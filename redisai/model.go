@@ -1,7 +1,13 @@
 package redisai
 
 import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
 	"errors"
+	"fmt"
+	"io"
 
 	"github.com/gomodule/redigo/redis"
 )
@@ -64,6 +70,55 @@ func modelStoreFlatArgs(keyName, backend, device, tag string, batchsize, minbatc
 	return args, nil
 }
 
+// ModelContentHash returns the hex encoded SHA-256 digest of everything AI.MODELSTORE stores for the model:
+// its blob along with its backend, device, tag, batching settings, inputs and outputs
+func ModelContentHash(model ModelInterface) (string, error) {
+	args, err := modelStoreInterfaceArgs("", model)
+	if err != nil {
+		return "", err
+	}
+	h := sha256.New()
+	for _, arg := range args[1:] {
+		if blob, ok := arg.([]byte); ok {
+			_ = binary.Write(h, binary.LittleEndian, int64(len(blob)))
+			h.Write(blob)
+			continue
+		}
+		digestWriteString(h, fmt.Sprint(arg))
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// ModelHashKey returns the key of the companion string holding the content hash of the model stored at keyName,
+// sharing its hash tag
+func ModelHashKey(keyName string) string {
+	return fmt.Sprintf("{%s}:modelhash:%s", HashTag(keyName), keyName)
+}
+
+// modelSetFlatArgs returns the arguments of AI.MODELSET, whose inputs and outputs are not counted
+func modelSetFlatArgs(keyName, backend, device, tag string, batchsize, minbatchsize int64, inputs, outputs []string, blob []byte) (redis.Args, error) {
+	args := redis.Args{}.Add(keyName, backend, device)
+	if len(tag) > 0 {
+		args = args.Add("TAG", tag)
+	}
+	if batchsize > 0 {
+		args = args.Add("BATCHSIZE", batchsize)
+	}
+	if minbatchsize > 0 {
+		if batchsize <= 0 {
+			return nil, errors.New("`minbatchsize` can't be provided without `batchsize`")
+		}
+		args = args.Add("MINBATCHSIZE", minbatchsize)
+	}
+	if len(inputs) > 0 {
+		args = args.Add("INPUTS").AddFlat(inputs)
+	}
+	if len(outputs) > 0 {
+		args = args.Add("OUTPUTS").AddFlat(outputs)
+	}
+	return args.Add("BLOB", blob), nil
+}
+
 func modelRunFlatArgs(name string, inputTensorNames, outputTensorNames []string) redis.Args {
 	args := redis.Args{name}
 	if len(inputTensorNames) > 0 {
@@ -130,7 +185,7 @@ func modelGetParseReply(reply interface{}) (backend, device, tag string, blob []
 		case "device":
 			device, err = redis.String(replySlice[pos+1], err)
 		case "blob":
-			blob, err = redis.Bytes(replySlice[pos+1], err)
+			blob, err = modelBlobParseReply(replySlice[pos+1], err)
 		case "tag":
 			tag, err = redis.String(replySlice[pos+1], err)
 		case "batchsize":
@@ -160,6 +215,55 @@ func modelGetParseReply(reply interface{}) (backend, device, tag string, blob []
 	return
 }
 
+// modelBlobParseReply returns the model blob, concatenating its chunks when the server replies with an array of chunks
+func modelBlobParseReply(reply interface{}, errIn error) ([]byte, error) {
+	chunks, ok := reply.([]interface{})
+	if !ok {
+		return redis.Bytes(reply, errIn)
+	}
+	var blob bytes.Buffer
+	if _, err := writeModelBlobChunks(&blob, chunks); err != nil {
+		return nil, err
+	}
+	return blob.Bytes(), nil
+}
+
+// writeModelBlobChunks writes the chunks of a model blob to w, and returns the number of bytes written.
+// Every chunk is released from chunks once written.
+func writeModelBlobChunks(w io.Writer, chunks []interface{}) (n int64, err error) {
+	for pos, chunk := range chunks {
+		var data []byte
+		if data, err = redis.Bytes(chunk, nil); err != nil {
+			return
+		}
+		chunks[pos] = nil
+		var written int
+		written, err = w.Write(data)
+		n += int64(written)
+		if err != nil {
+			return
+		}
+	}
+	return
+}
+
+// ModelMeta holds the metadata of a model, without its blob
+type ModelMeta struct {
+	Backend         string   `json:"backend"`
+	Device          string   `json:"device"`
+	Tag             string   `json:"tag"`
+	BatchSize       int64    `json:"batchsize"`
+	MinBatchSize    int64    `json:"minbatchsize"`
+	MinBatchTimeout int64    `json:"minbatchtimeout"`
+	Inputs          []string `json:"inputs"`
+	Outputs         []string `json:"outputs"`
+}
+
+func modelGetMetaParseReply(reply interface{}) (meta ModelMeta, err error) {
+	meta.Backend, meta.Device, meta.Tag, _, meta.BatchSize, meta.MinBatchSize, meta.Inputs, meta.Outputs, meta.MinBatchTimeout, err = modelGetParseReply(reply)
+	return
+}
+
 func modelGetFlatArgs(name string) redis.Args {
 	args := redis.Args{}.Add(name, "META", "BLOB")
 	return args
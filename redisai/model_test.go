@@ -1,6 +1,10 @@
 package redisai
 
 import (
+	"bytes"
+
+	"github.com/RedisAI/redisai-go/redisai/implementations"
+	"github.com/gomodule/redigo/redis"
 	"github.com/stretchr/testify/assert"
 	"reflect"
 	"testing"
@@ -40,7 +44,8 @@ func Test_modelGetParseReply(t *testing.T) {
 		{"positive-inputs", args{[]interface{}{[]byte("inputs"), []interface{}{[]byte("bar"), []byte("foo")}}}, "", "", "", nil, 0, 0, 0, []string{"bar", "foo"}, nil, false},
 		{"negative-wrong-output", args{[]interface{}{[]byte("output"), []interface{}{[]interface{}{[]byte("output")}}}}, "", "", "", nil, 0, 0, 0, nil, nil, true},
 		{"positive-output", args{[]interface{}{[]byte("outputs"), []interface{}{[]byte("output")}}}, "", "", "", nil, 0, 0, 0, nil, []string{"output"}, false},
-		{"negative-wrong-blob", args{[]interface{}{[]byte("blob"), []interface{}{[]byte("dtype"), []byte("1")}}}, "", "", "", nil, 0, 0, 0, nil, nil, true},
+		{"negative-wrong-blob", args{[]interface{}{[]byte("blob"), []interface{}{[]interface{}{[]byte("dtype")}, []byte("1")}}}, "", "", "", nil, 0, 0, 0, nil, nil, true},
+		{"positive-chunked-blob", args{[]interface{}{[]byte("blob"), []interface{}{[]byte("dtype"), []byte("1")}}}, "", "", "", []byte("dtype1"), 0, 0, 0, nil, nil, false},
 		{"positive-blob", args{[]interface{}{[]byte("blob"), []byte("blob")}}, "", "", "", []byte("blob"), 0, 0, 0, nil, nil, false},
 	}
 	for _, tt := range tests {
@@ -96,3 +101,143 @@ func Test_modelGetParseToInterface(t *testing.T) {
 		})
 	}
 }
+
+func TestModelContentHash(t *testing.T) {
+	model := func(tag string, blob string) ModelInterface {
+		m := implementations.NewModel(BackendTF, DeviceCPU)
+		m.SetTag(tag)
+		m.SetBlob([]byte(blob))
+		return m
+	}
+	hash, err := ModelContentHash(model("v1", "blob"))
+	assert.Nil(t, err)
+	same, _ := ModelContentHash(model("v1", "blob"))
+	assert.Equal(t, hash, same)
+	otherTag, _ := ModelContentHash(model("v2", "blob"))
+	assert.NotEqual(t, hash, otherTag)
+	otherBlob, _ := ModelContentHash(model("v1", "blob2"))
+	assert.NotEqual(t, hash, otherBlob)
+	// the tag and the blob boundaries are part of the hash
+	shifted, _ := ModelContentHash(model("v1b", "lob"))
+	assert.NotEqual(t, hash, shifted)
+
+	invalid := model("v1", "blob")
+	invalid.SetMinBatchSize(2)
+	_, err = ModelContentHash(invalid)
+	assert.NotNil(t, err)
+}
+
+func TestClient_ModelStoreFromModelIfChanged(t *testing.T) {
+	model := implementations.NewModel(BackendTF, DeviceCPU)
+	model.SetBlob([]byte("blob"))
+	hash, _ := ModelContentHash(model)
+	tests := []struct {
+		name       string
+		recorded   interface{}
+		exists     int64
+		force      bool
+		wantStored bool
+		wantCmds   []string
+	}{
+		{"no-hash", nil, 0, false, true, []string{"GET", "AI.MODELSTORE", "SET"}},
+		{"other-hash", []byte("other"), 1, false, true, []string{"GET", "AI.MODELSTORE", "SET"}},
+		{"same-hash", []byte(hash), 1, false, false, []string{"GET", "EXISTS"}},
+		{"same-hash-deleted-model", []byte(hash), 0, false, true, []string{"GET", "EXISTS", "AI.MODELSTORE", "SET"}},
+		{"force", []byte(hash), 1, true, true, []string{"AI.MODELSTORE", "SET"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, conn := createFakeClient(func(cmd string, args []interface{}) (interface{}, error) {
+				switch cmd {
+				case "GET":
+					if tt.recorded == nil {
+						return nil, nil
+					}
+					return tt.recorded, nil
+				case "EXISTS":
+					return tt.exists, nil
+				}
+				return "OK", nil
+			})
+			stored, err := c.ModelStoreFromModelIfChanged("mymodel", model, tt.force)
+			assert.Nil(t, err)
+			assert.Equal(t, tt.wantStored, stored)
+			assert.Equal(t, tt.wantCmds, conn.CommandNames())
+			if tt.wantStored {
+				commands := conn.Commands()
+				assert.Equal(t, []interface{}{"SET", "{mymodel}:modelhash:mymodel", hash}, commands[len(commands)-1])
+			}
+		})
+	}
+
+	c, _ := createFakeClient(func(cmd string, args []interface{}) (interface{}, error) {
+		return nil, redis.Error("ERR connection")
+	})
+	_, err := c.ModelStoreFromModelIfChanged("mymodel", model, false)
+	assert.NotNil(t, err)
+}
+
+func TestClient_ModelGetMeta(t *testing.T) {
+	c, conn := createFakeClient(func(cmd string, args []interface{}) (interface{}, error) {
+		return []interface{}{[]byte("backend"), []byte("TF"), []byte("device"), []byte("CPU"), []byte("tag"), []byte("v1"),
+			[]byte("batchsize"), int64(8), []byte("inputs"), []interface{}{[]byte("a"), []byte("b")}, []byte("outputs"), []interface{}{[]byte("mul")}}, nil
+	})
+	meta, err := c.ModelGetMeta("mymodel")
+	assert.Nil(t, err)
+	assert.Equal(t, ModelMeta{Backend: BackendTF, Device: DeviceCPU, Tag: "v1", BatchSize: 8, Inputs: []string{"a", "b"}, Outputs: []string{"mul"}}, meta)
+	assert.Equal(t, []interface{}{"AI.MODELGET", "mymodel", "META"}, conn.Commands()[0])
+
+	c, _ = createFakeClient(func(cmd string, args []interface{}) (interface{}, error) {
+		return nil, redis.Error("ERR model key is empty")
+	})
+	_, err = c.ModelGetMeta("mymodel")
+	assert.NotNil(t, err)
+}
+
+func TestClient_ModelGetBlob(t *testing.T) {
+	tests := []struct {
+		name    string
+		blob    interface{}
+		want    []byte
+		wantErr bool
+	}{
+		{"single", []byte("blob"), []byte("blob"), false},
+		{"chunked", []interface{}{[]byte("bl"), []byte("ob")}, []byte("blob"), false},
+		{"missing", nil, nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, conn := createFakeClient(func(cmd string, args []interface{}) (interface{}, error) {
+				if tt.blob == nil {
+					return nil, redis.Error("ERR model key is empty")
+				}
+				return tt.blob, nil
+			})
+			var buffer bytes.Buffer
+			n, err := c.ModelGetBlob("mymodel", &buffer)
+			if tt.wantErr {
+				assert.NotNil(t, err)
+				return
+			}
+			assert.Nil(t, err)
+			assert.Equal(t, int64(len(tt.want)), n)
+			assert.Equal(t, tt.want, buffer.Bytes())
+			assert.Equal(t, []interface{}{"AI.MODELGET", "mymodel", "BLOB"}, conn.Commands()[0])
+		})
+	}
+
+	c, _ := createFakeClient(nil)
+	c.Pipeline(2)
+	_, err := c.ModelGetBlob("mymodel", &bytes.Buffer{})
+	assert.NotNil(t, err)
+}
+
+func TestClient_ModelGet_ChunkedBlob(t *testing.T) {
+	c, _ := createFakeClient(func(cmd string, args []interface{}) (interface{}, error) {
+		return []interface{}{[]byte("backend"), []byte("TF"), []byte("blob"), []interface{}{[]byte("bl"), []byte("ob")}}, nil
+	})
+	model := implementations.NewEmptyModel()
+	err := c.ModelGetToModel("mymodel", model)
+	assert.Nil(t, err)
+	assert.Equal(t, []byte("blob"), model.Blob())
+}
@@ -0,0 +1,235 @@
+package redisai
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/RedisAI/redisai-go/redisai/implementations"
+	"github.com/gomodule/redigo/redis"
+)
+
+// DefaultPredictorKeyTTL is the TTL given to the temporary tensors a Predictor stores in the keyspace,
+// so that they expire even if the Predictor fails to delete them
+const DefaultPredictorKeyTTL = 60 * time.Second
+
+var predictorKeyCounter uint64
+
+// PredictorInputError reports a prediction rejected by the Predictor before being issued, because of its input
+// tensors or of the model or script key
+type PredictorInputError struct {
+	Message string
+}
+
+func (e *PredictorInputError) Error() string {
+	return "redisai.Predictor.Predict: " + e.Message
+}
+
+// Predictor runs a single prediction on a model or a script, taking care of the temporary tensors
+// needed to feed it and to read back its outputs.
+//
+// By default the prediction is issued as a single AI.DAGEXECUTE without PERSIST, meaning no tensor is ever
+// written to the keyspace. When UseDag is false the input tensors are stored with AI.TENSORSET under unique,
+// hash-tagged key names that expire after KeyTTL, and are deleted along with the outputs once read.
+//
+// A Predictor uses the Client connection, and as the Client it is not safe for concurrent use.
+type Predictor struct {
+	Client *Client
+	// Key is the model or the script key
+	Key string
+	// Function is the script entry point to run. It must be empty for models
+	Function string
+	// Inputs holds the ordered names of the model or script inputs
+	Inputs []string
+	// Outputs holds the ordered names of the model or script outputs
+	Outputs []string
+	// Timeout is the execution timeout in milliseconds. Zero means no timeout
+	Timeout int64
+	UseDag  bool
+	KeyTTL  time.Duration
+}
+
+// NewModelPredictor returns a Predictor running the model stored at modelKey
+func NewModelPredictor(client *Client, modelKey string, inputs, outputs []string) *Predictor {
+	return &Predictor{
+		Client:  client,
+		Key:     modelKey,
+		Inputs:  inputs,
+		Outputs: outputs,
+		UseDag:  true,
+		KeyTTL:  DefaultPredictorKeyTTL,
+	}
+}
+
+// NewScriptPredictor returns a Predictor running the function entry point of the script stored at scriptKey
+func NewScriptPredictor(client *Client, scriptKey, function string, inputs, outputs []string) *Predictor {
+	p := NewModelPredictor(client, scriptKey, inputs, outputs)
+	p.Function = function
+	return p
+}
+
+// Predict runs the model or script with the given input tensors, indexed by input name, and returns the
+// output tensors indexed by output name
+func (p *Predictor) Predict(inputs map[string]TensorInterface) (outputs map[string]TensorInterface, err error) {
+	if p.Client.PipelineActive {
+		return nil, errors.New("redisai.Predictor.Predict: predictions can't be issued on a pipelined client")
+	}
+	for _, name := range p.Inputs {
+		if _, ok := inputs[name]; !ok {
+			return nil, &PredictorInputError{Message: fmt.Sprintf("missing input tensor %s", name)}
+		}
+	}
+	if len(inputs) != len(p.Inputs) {
+		return nil, &PredictorInputError{Message: fmt.Sprintf("expected %d input tensors, got %d", len(p.Inputs), len(inputs))}
+	}
+	prefix, err := hashTagPrefix(p.Key)
+	if err != nil {
+		return nil, err
+	}
+	inputKeys := tempKeys(prefix, p.Inputs)
+	outputKeys := tempKeys(prefix, p.Outputs)
+	if p.UseDag {
+		return p.predictDag(inputs, inputKeys, outputKeys)
+	}
+	return p.predictKeyspace(inputs, inputKeys, outputKeys)
+}
+
+func (p *Predictor) predictDag(inputs map[string]TensorInterface, inputKeys, outputKeys []string) (map[string]TensorInterface, error) {
+	dag := NewDag()
+	for pos, name := range p.Inputs {
+		args, err := tensorSetInterfaceArgs(inputKeys[pos], inputs[name])
+		if err != nil {
+			return nil, &PredictorInputError{Message: fmt.Sprintf("input tensor %s: %v", name, err)}
+		}
+		dag.commands = append(dag.commands, redis.Args{"AI.TENSORSET"}.AddFlat(args))
+		dag.track(nil, []string{inputKeys[pos]}, "")
+	}
+	if len(p.Function) > 0 {
+		dag.ScriptExecute(p.Key, p.Function, nil, inputKeys, nil, outputKeys, 0)
+	} else {
+		dag.ModelExecute(p.Key, inputKeys, outputKeys, 0)
+	}
+	for _, outputKey := range outputKeys {
+		dag.commands = append(dag.commands, redis.Args{"AI.TENSORGET", outputKey, TensorContentTypeMeta, TensorContentTypeValues})
+		dag.track([]string{outputKey}, nil, "")
+	}
+	replies, err := p.Client.DagExecuteAuto(p.Timeout, dag)
+	if err != nil {
+		return nil, err
+	}
+	if len(replies) != len(dag.commands) {
+		return nil, fmt.Errorf("redisai.Predictor.Predict: expected %d DAG replies, got %d", len(dag.commands), len(replies))
+	}
+	outputs := make(map[string]TensorInterface, len(p.Outputs))
+	for pos, reply := range replies[len(replies)-len(outputKeys):] {
+		tensor, err := predictorParseTensor(reply, nil)
+		if err != nil {
+			return nil, err
+		}
+		outputs[p.Outputs[pos]] = tensor
+	}
+	return outputs, nil
+}
+
+func (p *Predictor) predictKeyspace(inputs map[string]TensorInterface, inputKeys, outputKeys []string) (outputs map[string]TensorInterface, err error) {
+	defer func() {
+		keys := append(append([]string{}, inputKeys...), outputKeys...)
+		_, delErr := p.Client.DoOrSend("DEL", redis.Args{}.AddFlat(keys), nil)
+		if err == nil {
+			err = delErr
+		}
+	}()
+	for pos, name := range p.Inputs {
+		// the input is stored along with its TTL, so that it never outlives a failed prediction
+		err = p.Client.multi(func() error {
+			if err := p.Client.TensorSetFromTensor(inputKeys[pos], inputs[name]); err != nil {
+				return err
+			}
+			return p.expire(inputKeys[pos])
+		})
+		if err != nil {
+			return
+		}
+	}
+	if len(p.Function) > 0 {
+		err = p.Client.ScriptExecuteWithTimeout(p.Key, p.Function, nil, inputKeys, nil, outputKeys, p.Timeout)
+	} else {
+		err = p.Client.ModelExecuteWithTimeout(p.Key, inputKeys, outputKeys, p.Timeout)
+	}
+	if err != nil {
+		return
+	}
+	outputs = make(map[string]TensorInterface, len(p.Outputs))
+	for pos, outputKey := range outputKeys {
+		if err = p.expire(outputKey); err != nil {
+			return
+		}
+		var reply interface{}
+		reply, err = p.Client.DoOrSend("AI.TENSORGET", redis.Args{outputKey, TensorContentTypeMeta, TensorContentTypeValues}, nil)
+		var tensor TensorInterface
+		tensor, err = predictorParseTensor(reply, err)
+		if err != nil {
+			return
+		}
+		outputs[p.Outputs[pos]] = tensor
+	}
+	return
+}
+
+func (p *Predictor) expire(key string) (err error) {
+	if p.KeyTTL > 0 {
+		_, err = p.Client.DoOrSend("PEXPIRE", redis.Args{key, int64(p.KeyTTL / time.Millisecond)}, nil)
+	}
+	return
+}
+
+// tempKeys returns unique key names for the given tensor names, starting with the hash tag prefix of the
+// model or script key so that they are placed on the same cluster shard
+func tempKeys(prefix string, names []string) []string {
+	id := atomic.AddUint64(&predictorKeyCounter, 1)
+	nonce := make([]byte, 8)
+	_, _ = rand.Read(nonce)
+	keys := make([]string, len(names))
+	for pos, name := range names {
+		keys[pos] = fmt.Sprintf("%s:predict:%s:%d:%s", prefix, hex.EncodeToString(nonce), id, name)
+	}
+	return keys
+}
+
+// HashTag returns the part of the key used by Redis Cluster to compute its hash slot:
+// the content between the first { and the following }, when non-empty, and otherwise the whole key
+func HashTag(key string) string {
+	start := strings.Index(key, "{")
+	if start >= 0 {
+		end := strings.Index(key[start+1:], "}")
+		if end > 0 {
+			return key[start+1 : start+1+end]
+		}
+	}
+	return key
+}
+
+// hashTagPrefix returns the {tag} prefix giving other keys the hash slot of key. It fails for the keys without
+// a hash tag containing a }, since their slot is computed over the whole key and no hash tag can reproduce it
+func hashTagPrefix(key string) (string, error) {
+	tag := HashTag(key)
+	if strings.Contains(tag, "}") {
+		return "", &PredictorInputError{Message: fmt.Sprintf("no hash tag maps to the slot of key %s, add one to the key", key)}
+	}
+	return "{" + tag + "}", nil
+}
+
+func predictorParseTensor(reply interface{}, errIn error) (tensor TensorInterface, err error) {
+	if replyErr, ok := reply.(redis.Error); ok {
+		return nil, replyErr
+	}
+	dt, shape, data, err := ProcessTensorGetReply(reply, errIn)
+	if err != nil {
+		return nil, err
+	}
+	return implementations.NewAiTensorWithData(dt, shape, data), nil
+}
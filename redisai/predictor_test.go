@@ -0,0 +1,141 @@
+package redisai
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/RedisAI/redisai-go/redisai/implementations"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHashTag(t *testing.T) {
+	tests := []struct {
+		name string
+		key  string
+		want string
+	}{
+		{"no-tag", "mymodel", "mymodel"},
+		{"tag", "mymodel{1}", "1"},
+		{"first-tag", "{a}mymodel{b}", "a"},
+		{"empty-tag", "mymodel{}", "mymodel{}"},
+		{"unclosed-tag", "mymodel{1", "mymodel{1"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, HashTag(tt.key))
+		})
+	}
+}
+
+func TestHashTagPrefix(t *testing.T) {
+	tests := []struct {
+		name    string
+		key     string
+		want    string
+		wantErr bool
+	}{
+		{"no-tag", "mymodel", "{mymodel}", false},
+		{"tag", "mymodel{1}", "{1}", false},
+		{"open-brace", "my{model", "{my{model}", false},
+		{"close-brace", "my}model", "", true},
+		{"empty-tag", "mymodel{}", "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := hashTagPrefix(tt.key)
+			assert.Equal(t, tt.wantErr, err != nil)
+			assert.Equal(t, tt.want, got)
+			if err == nil {
+				assert.Equal(t, HashTag(tt.key), HashTag(got+":predict"))
+			}
+		})
+	}
+}
+
+func TestPredictor_Predict_Dag(t *testing.T) {
+	reply := []interface{}{[]byte("dtype"), []byte(TypeFloat), []byte("shape"), []interface{}{int64(1)}, []byte("values"), []interface{}{[]byte("4.84")}}
+	c, conn := createFakeClient(func(cmd string, args []interface{}) (interface{}, error) {
+		return []interface{}{"OK", "OK", "OK", reply}, nil
+	})
+	p := NewModelPredictor(c, "mymodel{1}", []string{"a", "b"}, []string{"mul"})
+	p.Timeout = 100
+	outputs, err := p.Predict(map[string]TensorInterface{
+		"a": implementations.NewAiTensorWithData(TypeFloat, []int64{1}, []float32{1.1}),
+		"b": implementations.NewAiTensorWithData(TypeFloat, []int64{1}, []float32{4.4}),
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, []int64{1}, outputs["mul"].Shape())
+	assert.Equal(t, []float32{4.84}, outputs["mul"].Data())
+
+	assert.Equal(t, []string{"AI.DAGEXECUTE"}, conn.CommandNames())
+	command := conn.Commands()[0]
+	assert.Equal(t, "ROUTING", command[1])
+	assert.Equal(t, "mymodel{1}", command[2])
+	assert.Equal(t, "TIMEOUT", command[3])
+	for _, arg := range command {
+		if key, ok := arg.(string); ok && strings.Contains(key, ":predict:") {
+			assert.True(t, strings.HasPrefix(key, "{1}:predict:"))
+		}
+		assert.NotEqual(t, "PERSIST", arg)
+	}
+}
+
+func TestPredictor_Predict_Keyspace(t *testing.T) {
+	reply := []interface{}{[]byte("dtype"), []byte(TypeInt64), []byte("shape"), []interface{}{int64(2)}, []byte("values"), []interface{}{int64(1), int64(2)}}
+	c, conn := createFakeClient(func(cmd string, args []interface{}) (interface{}, error) {
+		switch cmd {
+		case "AI.TENSORGET":
+			return reply, nil
+		case "EXEC":
+			return []interface{}{"OK", int64(1)}, nil
+		}
+		return "OK", nil
+	})
+	p := NewScriptPredictor(c, "myscript", "bar", []string{"a"}, []string{"c"})
+	p.UseDag = false
+	outputs, err := p.Predict(map[string]TensorInterface{
+		"a": implementations.NewAiTensorWithData(TypeInt64, []int64{2}, []int64{1, 2}),
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, []int64{1, 2}, outputs["c"].Data())
+	assert.Equal(t, []string{"MULTI", "AI.TENSORSET", "PEXPIRE", "EXEC", "AI.SCRIPTEXECUTE", "PEXPIRE", "AI.TENSORGET", "DEL"}, conn.CommandNames())
+	assert.Equal(t, 3, len(conn.Commands()[7]))
+}
+
+func TestPredictor_Predict_Errors(t *testing.T) {
+	c, conn := createFakeClient(func(cmd string, args []interface{}) (interface{}, error) {
+		switch cmd {
+		case "AI.MODELEXECUTE":
+			return nil, errors.New("ERR model not found")
+		case "EXEC":
+			return []interface{}{"OK", int64(1)}, nil
+		}
+		return "OK", nil
+	})
+	p := NewModelPredictor(c, "mymodel", []string{"a"}, []string{"b"})
+	_, err := p.Predict(map[string]TensorInterface{})
+	assert.NotNil(t, err)
+	_, err = p.Predict(map[string]TensorInterface{
+		"a": implementations.NewAiTensorWithData(TypeInt64, []int64{1}, []int64{1}),
+		"c": implementations.NewAiTensorWithData(TypeInt64, []int64{1}, []int64{1}),
+	})
+	assert.NotNil(t, err)
+	assert.Equal(t, 0, len(conn.Commands()))
+
+	// the temporary tensors are deleted even if the execution fails
+	p.UseDag = false
+	_, err = p.Predict(map[string]TensorInterface{
+		"a": implementations.NewAiTensorWithData(TypeInt64, []int64{1}, []int64{1}),
+	})
+	assert.NotNil(t, err)
+	assert.Equal(t, []string{"MULTI", "AI.TENSORSET", "PEXPIRE", "EXEC", "AI.MODELEXECUTE", "DEL"}, conn.CommandNames())
+
+	// keys whose slot no hash tag can reproduce are refused
+	p.Key = "my}model"
+	_, err = p.Predict(map[string]TensorInterface{
+		"a": implementations.NewAiTensorWithData(TypeInt64, []int64{1}, []int64{1}),
+	})
+	assert.NotNil(t, err)
+	assert.Equal(t, 6, len(conn.Commands()))
+}
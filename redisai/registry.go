@@ -0,0 +1,240 @@
+package redisai
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/gomodule/redigo/redis"
+)
+
+// DefaultRegistryPrefix is the prefix of the keys of a ModelRegistry created with NewModelRegistry
+const DefaultRegistryPrefix = "registry:"
+
+// registryPromoteScript points an alias to a registered version, pushing the version it replaces to the
+// alias history. It replies with the replaced version, or nil.
+const registryPromoteScript = `
+if redis.call('HEXISTS', KEYS[1], ARGV[2]) == 0 then
+	return redis.error_reply('ERR unknown model version ' .. ARGV[2])
+end
+local previous = redis.call('HGET', KEYS[2], ARGV[1])
+if previous == ARGV[2] then
+	return previous
+end
+if previous then
+	redis.call('RPUSH', KEYS[3], previous)
+end
+redis.call('HSET', KEYS[2], ARGV[1], ARGV[2])
+return previous
+`
+
+// registryRollbackScript points an alias back to the latest version of its history that is still registered,
+// and replies with it
+const registryRollbackScript = `
+while true do
+	local previous = redis.call('RPOP', KEYS[3])
+	if not previous then
+		return redis.error_reply('ERR no previous version for alias ' .. ARGV[1])
+	end
+	if redis.call('HEXISTS', KEYS[1], previous) == 1 then
+		redis.call('HSET', KEYS[2], ARGV[1], previous)
+		return previous
+	end
+end
+`
+
+// registryUnregisterScript removes a version from the version index, unless an alias points to it
+const registryUnregisterScript = `
+local aliases = redis.call('HGETALL', KEYS[2])
+for i = 2, #aliases, 2 do
+	if aliases[i] == ARGV[1] then
+		return redis.error_reply('ERR model version ' .. ARGV[1] .. ' is aliased by ' .. aliases[i - 1])
+	end
+end
+return redis.call('HDEL', KEYS[1], ARGV[1])
+`
+
+// ModelVersion is a version of a model registered in a ModelRegistry
+type ModelVersion struct {
+	Version int64
+	// Key is the key the version of the model is stored at
+	Key string
+	Tag string
+}
+
+// ModelRegistry stores the successive versions of models under versioned keys, and maintains aliases
+// resolving to a concrete version, so that promoting or rolling back a model is a single atomic operation.
+//
+// Every key of a model shares the model name as hash tag, so that a model lives on a single cluster node:
+//
+//	<Prefix>{<name>}:version:<version>  the model stored for a version
+//	<Prefix>{<name>}:versions           hash of the registered versions to their tag
+//	<Prefix>{<name>}:counter            last allocated version number
+//	<Prefix>{<name>}:aliases            hash of the aliases to their version
+//	<Prefix>{<name>}:history:<alias>    list of the versions an alias previously pointed to
+//
+// For example the model "fraud" promoted to the alias "prod" is executed with
+// registry.ModelExecute("fraud", "prod", inputs, outputs).
+//
+// A ModelRegistry uses the Client connection, and can't be used while the Client is pipelined.
+type ModelRegistry struct {
+	Client *Client
+	Prefix string
+}
+
+// NewModelRegistry returns a ModelRegistry using client, with DefaultRegistryPrefix as key prefix
+func NewModelRegistry(client *Client) *ModelRegistry {
+	return &ModelRegistry{Client: client, Prefix: DefaultRegistryPrefix}
+}
+
+func (r *ModelRegistry) key(name, suffix string) string {
+	return fmt.Sprintf("%s{%s}:%s", r.Prefix, name, suffix)
+}
+
+// VersionKey returns the key the given version of the model is stored at
+func (r *ModelRegistry) VersionKey(name string, version int64) string {
+	return r.key(name, "version:"+strconv.FormatInt(version, 10))
+}
+
+func (r *ModelRegistry) checkPipeline(method string) error {
+	if r.Client.PipelineActive {
+		return fmt.Errorf("redisai.ModelRegistry.%s: the registry can't be used on a pipelined client", method)
+	}
+	return nil
+}
+
+func (r *ModelRegistry) eval(script string, name, alias string, args ...interface{}) (interface{}, error) {
+	evalArgs := redis.Args{script, 3, r.key(name, "versions"), r.key(name, "aliases"), r.key(name, "history:"+alias)}
+	return r.Client.DoOrSend("EVAL", evalArgs.Add(args...), nil)
+}
+
+// Register stores model as a new version of the model name, and returns its version number.
+// The version is not aliased: it must be promoted to be resolved.
+func (r *ModelRegistry) Register(name string, model ModelInterface) (version int64, err error) {
+	if err = r.checkPipeline("Register"); err != nil {
+		return
+	}
+	version, err = redis.Int64(r.Client.DoOrSend("INCR", redis.Args{r.key(name, "counter")}, nil))
+	if err != nil {
+		return
+	}
+	if err = r.Client.ModelStoreFromModel(r.VersionKey(name, version), model); err != nil {
+		return
+	}
+	_, err = r.Client.DoOrSend("HSET", redis.Args{r.key(name, "versions"), version, model.Tag()}, nil)
+	return
+}
+
+// Versions returns the registered versions of the model name, in increasing version order
+func (r *ModelRegistry) Versions(name string) (versions []ModelVersion, err error) {
+	if err = r.checkPipeline("Versions"); err != nil {
+		return
+	}
+	tags, err := redis.StringMap(r.Client.DoOrSend("HGETALL", redis.Args{r.key(name, "versions")}, nil))
+	if err != nil {
+		return
+	}
+	versions = make([]ModelVersion, 0, len(tags))
+	for field, tag := range tags {
+		version, err := strconv.ParseInt(field, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("redisai.ModelRegistry.Versions: invalid version %s: %v", field, err)
+		}
+		versions = append(versions, ModelVersion{Version: version, Key: r.VersionKey(name, version), Tag: tag})
+	}
+	sort.Slice(versions, func(i, j int) bool { return versions[i].Version < versions[j].Version })
+	return
+}
+
+// Aliases returns the aliases of the model name, with the version they resolve to
+func (r *ModelRegistry) Aliases(name string) (aliases map[string]int64, err error) {
+	if err = r.checkPipeline("Aliases"); err != nil {
+		return
+	}
+	return redis.Int64Map(r.Client.DoOrSend("HGETALL", redis.Args{r.key(name, "aliases")}, nil))
+}
+
+// Promote atomically points alias to the given registered version of the model name.
+// It returns the version the alias previously resolved to, or zero when the alias is new.
+func (r *ModelRegistry) Promote(name, alias string, version int64) (previous int64, err error) {
+	if err = r.checkPipeline("Promote"); err != nil {
+		return
+	}
+	previous, err = redis.Int64(r.eval(registryPromoteScript, name, alias, alias, version))
+	if err == redis.ErrNil {
+		err = nil
+	}
+	return
+}
+
+// Rollback atomically points alias back to the version it resolved to before its last promotion,
+// and returns that version. Versions unregistered since are skipped.
+func (r *ModelRegistry) Rollback(name, alias string) (version int64, err error) {
+	if err = r.checkPipeline("Rollback"); err != nil {
+		return
+	}
+	return redis.Int64(r.eval(registryRollbackScript, name, alias, alias))
+}
+
+// Resolve returns the version alias resolves to, and the key that version is stored at
+func (r *ModelRegistry) Resolve(name, alias string) (version int64, key string, err error) {
+	if err = r.checkPipeline("Resolve"); err != nil {
+		return
+	}
+	version, err = redis.Int64(r.Client.DoOrSend("HGET", redis.Args{r.key(name, "aliases"), alias}, nil))
+	if err == redis.ErrNil {
+		err = fmt.Errorf("redisai.ModelRegistry.Resolve: unknown alias %s of model %s", alias, name)
+	}
+	if err != nil {
+		return
+	}
+	return version, r.VersionKey(name, version), nil
+}
+
+// Unregister deletes the given version of the model name. Versions that an alias resolves to can't be unregistered.
+func (r *ModelRegistry) Unregister(name string, version int64) (err error) {
+	if err = r.checkPipeline("Unregister"); err != nil {
+		return
+	}
+	removed, err := redis.Int64(r.eval(registryUnregisterScript, name, "", version))
+	if err != nil {
+		return
+	}
+	if removed == 0 {
+		return fmt.Errorf("redisai.ModelRegistry.Unregister: unknown version %d of model %s", version, name)
+	}
+	return r.Client.ModelDel(r.VersionKey(name, version))
+}
+
+// ModelExecute runs the version of the model name that alias resolves to
+func (r *ModelRegistry) ModelExecute(name, alias string, inputs, outputs []string) error {
+	return r.ModelExecuteWithTimeout(name, alias, inputs, outputs, 0)
+}
+
+// ModelExecuteWithTimeout runs the version of the model name that alias resolves to, with a timeout in milliseconds
+func (r *ModelRegistry) ModelExecuteWithTimeout(name, alias string, inputs, outputs []string, timeout int64) error {
+	_, key, err := r.Resolve(name, alias)
+	if err != nil {
+		return err
+	}
+	return r.Client.ModelExecuteWithTimeout(key, inputs, outputs, timeout)
+}
+
+// Predictor returns a Predictor running the version of the model name that alias currently resolves to
+func (r *ModelRegistry) Predictor(name, alias string, inputs, outputs []string) (*Predictor, error) {
+	_, key, err := r.Resolve(name, alias)
+	if err != nil {
+		return nil, err
+	}
+	return NewModelPredictor(r.Client, key, inputs, outputs), nil
+}
+
+// ParseModelReference splits a model reference of the form name:alias, such as fraud:prod, at its last colon
+func ParseModelReference(reference string) (name, alias string, err error) {
+	pos := strings.LastIndex(reference, ":")
+	if pos <= 0 || pos == len(reference)-1 {
+		return "", "", fmt.Errorf("redisai.ParseModelReference: invalid model reference %s, expected name:alias", reference)
+	}
+	return reference[:pos], reference[pos+1:], nil
+}
@@ -0,0 +1,138 @@
+package redisai
+
+import (
+	"testing"
+
+	"github.com/RedisAI/redisai-go/redisai/implementations"
+	"github.com/gomodule/redigo/redis"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestModelRegistry_Register(t *testing.T) {
+	c, conn := createFakeClient(func(cmd string, args []interface{}) (interface{}, error) {
+		if cmd == "INCR" {
+			return int64(3), nil
+		}
+		return "OK", nil
+	})
+	r := NewModelRegistry(c)
+	model := implementations.NewModel(BackendTF, DeviceCPU)
+	model.SetTag("v3")
+	model.SetBlob([]byte("blob"))
+	version, err := r.Register("fraud", model)
+	assert.Nil(t, err)
+	assert.Equal(t, int64(3), version)
+	assert.Equal(t, []string{"INCR", "AI.MODELSTORE", "HSET"}, conn.CommandNames())
+	commands := conn.Commands()
+	assert.Equal(t, "registry:{fraud}:counter", commands[0][1])
+	assert.Equal(t, "registry:{fraud}:version:3", commands[1][1])
+	assert.Equal(t, []interface{}{"HSET", "registry:{fraud}:versions", int64(3), "v3"}, commands[2])
+}
+
+func TestModelRegistry_PromoteRollback(t *testing.T) {
+	var reply interface{}
+	var replyErr error
+	c, conn := createFakeClient(func(cmd string, args []interface{}) (interface{}, error) {
+		return reply, replyErr
+	})
+	r := NewModelRegistry(c)
+
+	reply = nil
+	previous, err := r.Promote("fraud", "prod", 1)
+	assert.Nil(t, err)
+	assert.Equal(t, int64(0), previous)
+	command := conn.Commands()[0]
+	assert.Equal(t, []interface{}{3, "registry:{fraud}:versions", "registry:{fraud}:aliases", "registry:{fraud}:history:prod", "prod", int64(1)}, command[2:])
+
+	reply = []byte("1")
+	previous, err = r.Promote("fraud", "prod", 2)
+	assert.Nil(t, err)
+	assert.Equal(t, int64(1), previous)
+
+	reply, replyErr = nil, redis.Error("ERR unknown model version 7")
+	_, err = r.Promote("fraud", "prod", 7)
+	assert.NotNil(t, err)
+
+	reply, replyErr = []byte("1"), nil
+	version, err := r.Rollback("fraud", "prod")
+	assert.Nil(t, err)
+	assert.Equal(t, int64(1), version)
+}
+
+func TestModelRegistry_Resolve(t *testing.T) {
+	c, conn := createFakeClient(func(cmd string, args []interface{}) (interface{}, error) {
+		if cmd == "HGET" && args[1] == "prod" {
+			return []byte("2"), nil
+		}
+		if cmd == "HGET" {
+			return nil, nil
+		}
+		return "OK", nil
+	})
+	r := NewModelRegistry(c)
+	version, key, err := r.Resolve("fraud", "prod")
+	assert.Nil(t, err)
+	assert.Equal(t, int64(2), version)
+	assert.Equal(t, "registry:{fraud}:version:2", key)
+
+	_, _, err = r.Resolve("fraud", "staging")
+	assert.NotNil(t, err)
+
+	assert.Nil(t, r.ModelExecute("fraud", "prod", []string{"a"}, []string{"b"}))
+	commands := conn.Commands()
+	assert.Equal(t, []interface{}{"AI.MODELEXECUTE", "registry:{fraud}:version:2", "INPUTS", 1, "a", "OUTPUTS", 1, "b"}, commands[len(commands)-1])
+
+	c.Pipeline(2)
+	_, _, err = r.Resolve("fraud", "prod")
+	assert.NotNil(t, err)
+}
+
+func TestModelRegistry_Versions(t *testing.T) {
+	c, _ := createFakeClient(func(cmd string, args []interface{}) (interface{}, error) {
+		return []interface{}{[]byte("10"), []byte("b"), []byte("2"), []byte("a")}, nil
+	})
+	versions, err := NewModelRegistry(c).Versions("fraud")
+	assert.Nil(t, err)
+	assert.Equal(t, []ModelVersion{
+		{Version: 2, Key: "registry:{fraud}:version:2", Tag: "a"},
+		{Version: 10, Key: "registry:{fraud}:version:10", Tag: "b"},
+	}, versions)
+}
+
+func TestModelRegistry_Unregister(t *testing.T) {
+	var removed int64
+	c, conn := createFakeClient(func(cmd string, args []interface{}) (interface{}, error) {
+		if cmd == "EVAL" {
+			return removed, nil
+		}
+		return "OK", nil
+	})
+	r := NewModelRegistry(c)
+	assert.NotNil(t, r.Unregister("fraud", 1))
+	removed = 1
+	assert.Nil(t, r.Unregister("fraud", 1))
+	assert.Equal(t, []string{"EVAL", "EVAL", "AI.MODELDEL"}, conn.CommandNames())
+}
+
+func TestParseModelReference(t *testing.T) {
+	tests := []struct {
+		reference string
+		name      string
+		alias     string
+		wantErr   bool
+	}{
+		{"fraud:prod", "fraud", "prod", false},
+		{"team:fraud:prod", "team:fraud", "prod", false},
+		{"fraud", "", "", true},
+		{"fraud:", "", "", true},
+		{":prod", "", "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.reference, func(t *testing.T) {
+			name, alias, err := ParseModelReference(tt.reference)
+			assert.Equal(t, tt.wantErr, err != nil)
+			assert.Equal(t, tt.name, name)
+			assert.Equal(t, tt.alias, alias)
+		})
+	}
+}
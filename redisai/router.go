@@ -0,0 +1,137 @@
+package redisai
+
+import (
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"math/rand"
+	"sync"
+)
+
+// ModelVariant is a model key receiving a share of the traffic of a ModelRouter
+type ModelVariant struct {
+	Name string
+	Key  string
+	// Weight is the share of the traffic relative to the other variants weights
+	Weight float64
+}
+
+// VariantStats holds the counters of a ModelVariant
+type VariantStats struct {
+	Requests int64
+	Errors   int64
+}
+
+// ModelRouter splits the executions between several model keys by weight, for A/B experiments.
+//
+// The variant of an execution is drawn at random, unless a caller ID is given: the variant is then chosen from a
+// hash of the ID, so that the same caller is always routed to the same variant while the variants are unchanged.
+// Every variant must accept the same inputs and produce the same outputs.
+//
+// A ModelRouter uses the Client connection, and as the Client it is not safe for concurrent use; its counters are.
+type ModelRouter struct {
+	Client   *Client
+	Variants []ModelVariant
+	// Timeout is the execution timeout in milliseconds. Zero means no timeout
+	Timeout int64
+	// OnRoute, when set, is invoked after every execution with its variant and error, to feed external metrics
+	OnRoute func(variant string, err error)
+
+	totalWeight float64
+	mu          sync.Mutex
+	stats       map[string]VariantStats
+}
+
+// NewModelRouter returns a ModelRouter splitting the executions between variants
+func NewModelRouter(client *Client, variants []ModelVariant) (*ModelRouter, error) {
+	if len(variants) == 0 {
+		return nil, errors.New("redisai.NewModelRouter: at least one variant is required")
+	}
+	r := &ModelRouter{Client: client, Variants: variants, stats: make(map[string]VariantStats, len(variants))}
+	for _, variant := range variants {
+		if variant.Weight < 0 {
+			return nil, fmt.Errorf("redisai.NewModelRouter: variant %s has a negative weight", variant.Name)
+		}
+		if _, ok := r.stats[variant.Name]; ok {
+			return nil, fmt.Errorf("redisai.NewModelRouter: duplicate variant %s", variant.Name)
+		}
+		r.stats[variant.Name] = VariantStats{}
+		r.totalWeight += variant.Weight
+	}
+	if r.totalWeight <= 0 {
+		return nil, errors.New("redisai.NewModelRouter: the variants weights sum to zero")
+	}
+	return r, nil
+}
+
+// Choose returns the variant of an execution for the caller ID, or a random one when id is empty
+func (r *ModelRouter) Choose(id string) ModelVariant {
+	var point float64
+	if len(id) == 0 {
+		point = rand.Float64()
+	} else {
+		h := fnv.New64a()
+		h.Write([]byte(id))
+		// the 53 high bits of the hash give a uniform float in [0, 1)
+		point = float64(h.Sum64()>>11) / (1 << 53)
+	}
+	point *= r.totalWeight
+	for _, variant := range r.Variants {
+		if point < variant.Weight {
+			return variant
+		}
+		point -= variant.Weight
+	}
+	// rounding errors can leave the point past the last variant with a non-zero weight
+	for pos := len(r.Variants) - 1; pos >= 0; pos-- {
+		if r.Variants[pos].Weight > 0 {
+			return r.Variants[pos]
+		}
+	}
+	return r.Variants[len(r.Variants)-1]
+}
+
+// ModelExecute runs the variant chosen for id with the input tensors keys, and stores its outputs at the output keys.
+// It returns the name of the chosen variant.
+func (r *ModelRouter) ModelExecute(id string, inputs, outputs []string) (variant string, err error) {
+	chosen := r.Choose(id)
+	err = r.Client.ModelExecuteWithTimeout(chosen.Key, inputs, outputs, r.Timeout)
+	r.record(chosen.Name, err)
+	return chosen.Name, err
+}
+
+// Predict runs the variant chosen for id with the input tensors through a Predictor, and returns the name of the
+// chosen variant along with the output tensors
+func (r *ModelRouter) Predict(id string, inputNames, outputNames []string, inputs map[string]TensorInterface) (variant string, outputs map[string]TensorInterface, err error) {
+	chosen := r.Choose(id)
+	predictor := NewModelPredictor(r.Client, chosen.Key, inputNames, outputNames)
+	predictor.Timeout = r.Timeout
+	outputs, err = predictor.Predict(inputs)
+	r.record(chosen.Name, err)
+	return chosen.Name, outputs, err
+}
+
+func (r *ModelRouter) record(variant string, err error) {
+	r.mu.Lock()
+	stats := r.stats[variant]
+	stats.Requests++
+	if err != nil {
+		stats.Errors++
+	}
+	r.stats[variant] = stats
+	r.mu.Unlock()
+	if r.OnRoute != nil {
+		r.OnRoute(variant, err)
+	}
+}
+
+// Stats returns a snapshot of the counters by variant name
+func (r *ModelRouter) Stats() map[string]VariantStats {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	stats := make(map[string]VariantStats, len(r.stats))
+	for name, variant := range r.stats {
+		stats[name] = variant
+	}
+	return stats
+}
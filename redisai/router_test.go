@@ -0,0 +1,78 @@
+package redisai
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/RedisAI/redisai-go/redisai/implementations"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewModelRouter(t *testing.T) {
+	tests := []struct {
+		name     string
+		variants []ModelVariant
+		wantErr  bool
+	}{
+		{"valid", []ModelVariant{{"a", "model:a", 1}, {"b", "model:b", 3}}, false},
+		{"no-variant", nil, true},
+		{"negative-weight", []ModelVariant{{"a", "model:a", -1}, {"b", "model:b", 3}}, true},
+		{"zero-weights", []ModelVariant{{"a", "model:a", 0}}, true},
+		{"duplicate", []ModelVariant{{"a", "model:a", 1}, {"a", "model:b", 1}}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewModelRouter(nil, tt.variants)
+			assert.Equal(t, tt.wantErr, err != nil)
+		})
+	}
+}
+
+func TestModelRouter_Choose(t *testing.T) {
+	r, err := NewModelRouter(nil, []ModelVariant{{"a", "model:a", 1}, {"off", "model:off", 0}, {"b", "model:b", 3}})
+	assert.Nil(t, err)
+	counts := map[string]int{}
+	for i := 0; i < 4000; i++ {
+		id := fmt.Sprintf("user-%d", i)
+		variant := r.Choose(id)
+		// the choice is sticky
+		assert.Equal(t, variant, r.Choose(id))
+		counts[variant.Name]++
+	}
+	assert.Equal(t, 0, counts["off"])
+	assert.InDelta(t, 1000, counts["a"], 150)
+	assert.InDelta(t, 3000, counts["b"], 150)
+
+	counts = map[string]int{}
+	for i := 0; i < 4000; i++ {
+		counts[r.Choose("").Name]++
+	}
+	assert.InDelta(t, 1000, counts["a"], 150)
+}
+
+func TestModelRouter_Execute(t *testing.T) {
+	c, conn := createFakeClient(func(cmd string, args []interface{}) (interface{}, error) {
+		if cmd == "AI.MODELEXECUTE" {
+			return nil, errors.New("failed")
+		}
+		return []interface{}{"OK", "OK", []interface{}{[]byte("dtype"), []byte(TypeFloat), []byte("shape"), []interface{}{int64(1)}, []byte("values"), []interface{}{[]byte("1")}}}, nil
+	})
+	r, err := NewModelRouter(c, []ModelVariant{{"a", "model:a", 1}})
+	assert.Nil(t, err)
+	var routed []string
+	r.OnRoute = func(variant string, err error) { routed = append(routed, fmt.Sprint(variant, " ", err)) }
+
+	variant, outputs, err := r.Predict("user", []string{"x"}, []string{"y"}, map[string]TensorInterface{"x": implementations.NewAiTensorWithData(TypeFloat, []int64{1}, []float32{1})})
+	assert.Nil(t, err)
+	assert.Equal(t, "a", variant)
+	assert.Equal(t, []float32{1}, outputs["y"].Data())
+
+	variant, err = r.ModelExecute("user", []string{"x"}, []string{"y"})
+	assert.NotNil(t, err)
+	assert.Equal(t, "a", variant)
+	assert.Equal(t, "model:a", conn.Commands()[1][1])
+
+	assert.Equal(t, map[string]VariantStats{"a": {Requests: 2, Errors: 1}}, r.Stats())
+	assert.Equal(t, []string{"a <nil>", "a failed"}, routed)
+}
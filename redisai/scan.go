@@ -0,0 +1,368 @@
+package redisai
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/gomodule/redigo/redis"
+)
+
+const (
+	// KeyTypeTensor is the Redis type name of RedisAI tensors
+	KeyTypeTensor = string("AI_TENSOR")
+	// KeyTypeModel is the Redis type name of RedisAI models
+	KeyTypeModel = string("AI__MODEL")
+	// KeyTypeScript is the Redis type name of RedisAI scripts
+	KeyTypeScript = string("AI_SCRIPT")
+)
+
+// KeyInfo describes a RedisAI key found by a KeyScanner. The metadata is only set when requested with WithMeta.
+type KeyInfo struct {
+	Key string
+	// Type is one of KeyTypeTensor, KeyTypeModel or KeyTypeScript
+	Type string
+	// Backend is the backend of a model
+	Backend string
+	// Device is the device of a model or a script
+	Device string
+	// Tag is the tag of a model or a script
+	Tag string
+	// Dtype is the type of a tensor
+	Dtype string
+	// Shape is the shape of a tensor
+	Shape []int64
+}
+
+// KeyScanner iterates over the RedisAI keys of a given type, with SCAN ... TYPE.
+//
+// When the server does not support SCAN with TYPE (before Redis 6), models and scripts are listed with
+// AI._MODELSCAN and AI._SCRIPTSCAN instead, which reply with every key at once.
+//
+// A Redis Cluster is scanned node by node: the scanner is given a Client per master node, as returned by
+// ClusterNodes, and iterates over the keys of each one in turn. ScanModels, ScanScripts and ScanTensors only
+// scan the server of their client. The options must be set before the first call to Next:
+//
+//	scanner := redisai.NewKeyScanner(redisai.KeyTypeModel, client)
+//	scanner.WithMeta = true
+//	for scanner.Next() {
+//		fmt.Println(scanner.KeyInfo())
+//	}
+//	if err := scanner.Err(); err != nil {
+//		...
+//	}
+type KeyScanner struct {
+	Type string
+	// Match is a glob-style pattern filtering the key names. Empty means every key
+	Match string
+	// Count is the SCAN COUNT hint. Zero leaves the server default
+	Count int64
+	// WithMeta fetches the metadata of every key, without model blobs, script sources nor tensor values
+	WithMeta bool
+
+	clients []*Client
+	node    int
+	cursor  int64
+	started bool
+	buffer  []KeyInfo
+	current KeyInfo
+	err     error
+}
+
+// NewKeyScanner returns a KeyScanner over the keys of type keyType, on every node reached by clients
+func NewKeyScanner(keyType string, clients ...*Client) *KeyScanner {
+	return &KeyScanner{Type: keyType, clients: clients}
+}
+
+// ScanModels returns a KeyScanner over the models of the server
+func (c *Client) ScanModels() *KeyScanner {
+	return NewKeyScanner(KeyTypeModel, c)
+}
+
+// ScanScripts returns a KeyScanner over the scripts of the server
+func (c *Client) ScanScripts() *KeyScanner {
+	return NewKeyScanner(KeyTypeScript, c)
+}
+
+// ScanTensors returns a KeyScanner over the tensors of the server
+func (c *Client) ScanTensors() *KeyScanner {
+	return NewKeyScanner(KeyTypeTensor, c)
+}
+
+// ClusterNodes returns a Client per master node of the Redis Cluster client is connected to, discovered with
+// CLUSTER NODES, to scan every node with NewKeyScanner. The node of client is reached through client itself, and
+// the other nodes through a pool of connections opened with dial, or over TCP when dial is nil. When the server
+// replies to CLUSTER NODES with an error, as when cluster support is disabled, client is its only node.
+//
+// The pools of the returned clients other than client should be closed when done with CloseClusterNodes.
+func ClusterNodes(client *Client, dial func(address string) (redis.Conn, error)) ([]*Client, error) {
+	if client.PipelineActive {
+		return nil, errors.New("redisai.ClusterNodes: the nodes can't be discovered on a pipelined client")
+	}
+	reply, err := redis.String(client.DoOrSend("CLUSTER", redis.Args{"NODES"}, nil))
+	if _, ok := err.(redis.Error); ok {
+		return []*Client{client}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var nodes []*Client
+	for _, line := range strings.Split(reply, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 3 {
+			continue
+		}
+		flags := "," + fields[2] + ","
+		if !strings.Contains(flags, ",master,") || strings.Contains(flags, ",fail,") || strings.Contains(flags, ",noaddr,") {
+			continue
+		}
+		if strings.Contains(flags, ",myself,") {
+			nodes = append(nodes, client)
+			continue
+		}
+		address := fields[1]
+		if pos := strings.IndexAny(address, "@,"); pos >= 0 {
+			address = address[:pos]
+		}
+		nodes = append(nodes, connectClusterNode(address, dial))
+	}
+	if len(nodes) == 0 {
+		return nil, errors.New("redisai.ClusterNodes: no master node in the CLUSTER NODES reply")
+	}
+	return nodes, nil
+}
+
+// connectClusterNode returns a Client over a pool of connections to the node at address opened with dial,
+// or over TCP when dial is nil
+func connectClusterNode(address string, dial func(address string) (redis.Conn, error)) *Client {
+	if dial == nil {
+		dial = func(address string) (redis.Conn, error) { return redis.Dial("tcp", address) }
+	}
+	return Connect("", &redis.Pool{
+		MaxIdle: 1,
+		Dial:    func() (redis.Conn, error) { return dial(address) },
+	})
+}
+
+// CloseClusterNodes closes the clients returned by ClusterNodes for client, and their pools, except client itself
+func CloseClusterNodes(client *Client, nodes []*Client) (err error) {
+	for _, node := range nodes {
+		if node == client {
+			continue
+		}
+		if closeErr := node.Close(); closeErr != nil && err == nil {
+			err = closeErr
+		}
+		if closeErr := node.Pool.Close(); closeErr != nil && err == nil {
+			err = closeErr
+		}
+	}
+	return
+}
+
+// scanClusterKeys returns the keys of type keyType matching match, held by every node of nodes, along with the
+// node holding each one. SCAN may list a key twice on a node; a key listed by several nodes keeps the first one.
+func scanClusterKeys(nodes []*Client, keyType, match string) (map[string]*Client, error) {
+	keys := make(map[string]*Client)
+	for _, node := range nodes {
+		scanner := NewKeyScanner(keyType, node)
+		scanner.Match = match
+		for scanner.Next() {
+			if _, ok := keys[scanner.KeyInfo().Key]; !ok {
+				keys[scanner.KeyInfo().Key] = node
+			}
+		}
+		if err := scanner.Err(); err != nil {
+			return nil, err
+		}
+	}
+	return keys, nil
+}
+
+// Next advances to the next key, and reports whether there is one. It returns false at the end of the
+// iteration or on error, which is then returned by Err.
+func (s *KeyScanner) Next() bool {
+	for len(s.buffer) == 0 {
+		if s.err != nil || s.node >= len(s.clients) {
+			return false
+		}
+		if s.started && s.cursor == 0 {
+			s.node++
+			s.started = false
+			continue
+		}
+		s.err = s.fetch(s.clients[s.node])
+	}
+	s.current, s.buffer = s.buffer[0], s.buffer[1:]
+	return true
+}
+
+// KeyInfo returns the current key
+func (s *KeyScanner) KeyInfo() KeyInfo {
+	return s.current
+}
+
+// Err returns the error that stopped the iteration, if any
+func (s *KeyScanner) Err() error {
+	return s.err
+}
+
+// All iterates over every remaining key and returns them
+func (s *KeyScanner) All() ([]KeyInfo, error) {
+	var keys []KeyInfo
+	for s.Next() {
+		keys = append(keys, s.KeyInfo())
+	}
+	return keys, s.Err()
+}
+
+func (s *KeyScanner) fetch(client *Client) error {
+	if client.PipelineActive {
+		return errors.New("redisai.KeyScanner: keys can't be scanned on a pipelined client")
+	}
+	args := redis.Args{s.cursor}
+	if len(s.Match) > 0 {
+		args = args.Add("MATCH", s.Match)
+	}
+	if s.Count > 0 {
+		args = args.Add("COUNT", s.Count)
+	}
+	args = args.Add("TYPE", s.Type)
+	reply, err := redis.Values(client.DoOrSend("SCAN", args, nil))
+	if _, ok := err.(redis.Error); ok && !s.started && s.Type != KeyTypeTensor {
+		return s.fetchAll(client)
+	}
+	if err != nil {
+		return err
+	}
+	var keys []string
+	if _, err = redis.Scan(reply, &s.cursor, &keys); err != nil {
+		return err
+	}
+	s.started = true
+	for _, key := range keys {
+		info := KeyInfo{Key: key, Type: s.Type}
+		if err = s.fetchMeta(client, &info); err != nil {
+			return err
+		}
+		s.buffer = append(s.buffer, info)
+	}
+	return nil
+}
+
+// fetchAll lists the models or scripts with AI._MODELSCAN or AI._SCRIPTSCAN, which reply with [key, tag] pairs
+func (s *KeyScanner) fetchAll(client *Client) error {
+	command := "AI._MODELSCAN"
+	if s.Type == KeyTypeScript {
+		command = "AI._SCRIPTSCAN"
+	}
+	entries, err := redis.Values(client.DoOrSend(command, nil, nil))
+	if err != nil {
+		return err
+	}
+	s.started = true
+	s.cursor = 0
+	for _, entry := range entries {
+		fields, err := redis.Strings(entry, nil)
+		if err != nil || len(fields) == 0 {
+			return fmt.Errorf("redisai.KeyScanner: unexpected %s reply: %v", command, entry)
+		}
+		if len(s.Match) > 0 && !globMatch(s.Match, fields[0]) {
+			continue
+		}
+		info := KeyInfo{Key: fields[0], Type: s.Type}
+		if len(fields) > 1 {
+			info.Tag = fields[1]
+		}
+		if err = s.fetchMeta(client, &info); err != nil {
+			return err
+		}
+		s.buffer = append(s.buffer, info)
+	}
+	return nil
+}
+
+func (s *KeyScanner) fetchMeta(client *Client, info *KeyInfo) (err error) {
+	if !s.WithMeta {
+		return nil
+	}
+	switch info.Type {
+	case KeyTypeModel:
+		var meta ModelMeta
+		meta, err = client.ModelGetMeta(info.Key)
+		info.Backend, info.Device, info.Tag = meta.Backend, meta.Device, meta.Tag
+	case KeyTypeScript:
+		var meta ScriptMeta
+		meta, err = client.ScriptGetMeta(info.Key)
+		info.Device, info.Tag = meta.Device, meta.Tag
+	case KeyTypeTensor:
+		info.Dtype, info.Shape, _, err = ProcessTensorGetReply(client.DoOrSend("AI.TENSORGET", redis.Args{info.Key, TensorContentTypeMeta}, nil))
+	}
+	return
+}
+
+// globMatch reports whether s matches the Redis glob-style pattern, supporting *, ?, [...] classes and \ escapes
+func globMatch(pattern, s string) bool {
+	for len(pattern) > 0 {
+		switch pattern[0] {
+		case '*':
+			for len(pattern) > 0 && pattern[0] == '*' {
+				pattern = pattern[1:]
+			}
+			if len(pattern) == 0 {
+				return true
+			}
+			for pos := 0; pos <= len(s); pos++ {
+				if globMatch(pattern, s[pos:]) {
+					return true
+				}
+			}
+			return false
+		case '?':
+			if len(s) == 0 {
+				return false
+			}
+		case '[':
+			if len(s) == 0 {
+				return false
+			}
+			end := 1
+			negate := end < len(pattern) && pattern[end] == '^'
+			if negate {
+				end++
+			}
+			matched := false
+			for ; end < len(pattern) && pattern[end] != ']'; end++ {
+				if pattern[end] == '\\' && end+1 < len(pattern) {
+					end++
+					matched = matched || pattern[end] == s[0]
+				} else if end+2 < len(pattern) && pattern[end+1] == '-' && pattern[end+2] != ']' {
+					matched = matched || (pattern[end] <= s[0] && s[0] <= pattern[end+2])
+					end += 2
+				} else {
+					matched = matched || pattern[end] == s[0]
+				}
+			}
+			if matched == negate {
+				return false
+			}
+			if end < len(pattern) {
+				pattern = pattern[end:]
+			} else {
+				pattern = pattern[end-1:]
+			}
+		case '\\':
+			if len(pattern) > 1 {
+				pattern = pattern[1:]
+			}
+			fallthrough
+		default:
+			if len(s) == 0 || pattern[0] != s[0] {
+				return false
+			}
+		}
+		pattern = pattern[1:]
+		s = s[1:]
+	}
+	return len(s) == 0
+}
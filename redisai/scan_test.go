@@ -0,0 +1,128 @@
+package redisai
+
+import (
+	"testing"
+
+	"github.com/gomodule/redigo/redis"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGlobMatch(t *testing.T) {
+	tests := []struct {
+		pattern string
+		s       string
+		want    bool
+	}{
+		{"*", "anything", true},
+		{"model:*", "model:fraud", true},
+		{"model:*", "script:fraud", false},
+		{"*:prod", "fraud:v1:prod", true},
+		{"m?del", "model", true},
+		{"m?del", "mdel", false},
+		{"[mn]odel", "node", false},
+		{"[mn]odel", "nodel", true},
+		{"[^m]odel", "model", false},
+		{"[a-c]x", "bx", true},
+		{"[a-c]x", "dx", false},
+		{"\\*", "*", true},
+		{"\\*", "a", false},
+		{"exact", "exact", true},
+		{"exact", "exactly", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.pattern+"/"+tt.s, func(t *testing.T) {
+			assert.Equal(t, tt.want, globMatch(tt.pattern, tt.s))
+		})
+	}
+}
+
+func TestKeyScanner(t *testing.T) {
+	node1, conn1 := createFakeClient(func(cmd string, args []interface{}) (interface{}, error) {
+		switch cmd {
+		case "SCAN":
+			if args[0] == int64(0) {
+				return []interface{}{[]byte("7"), []interface{}{[]byte("m1"), []byte("m2")}}, nil
+			}
+			return []interface{}{[]byte("0"), []interface{}{}}, nil
+		case "AI.MODELGET":
+			return []interface{}{[]byte("backend"), []byte("TF"), []byte("device"), []byte("CPU"), []byte("tag"), []byte(args[0].(string) + ":v1")}, nil
+		}
+		return nil, redis.Error("ERR unexpected")
+	})
+	node2, _ := createFakeClient(func(cmd string, args []interface{}) (interface{}, error) {
+		switch cmd {
+		case "SCAN":
+			return []interface{}{[]byte("0"), []interface{}{[]byte("m3")}}, nil
+		case "AI.MODELGET":
+			return []interface{}{[]byte("backend"), []byte("ORT"), []byte("device"), []byte("GPU"), []byte("tag"), []byte("")}, nil
+		}
+		return nil, redis.Error("ERR unexpected")
+	})
+	scanner := NewKeyScanner(KeyTypeModel, node1, node2)
+	scanner.Match = "m*"
+	scanner.Count = 100
+	scanner.WithMeta = true
+	keys, err := scanner.All()
+	assert.Nil(t, err)
+	assert.Equal(t, []KeyInfo{
+		{Key: "m1", Type: KeyTypeModel, Backend: "TF", Device: "CPU", Tag: "m1:v1"},
+		{Key: "m2", Type: KeyTypeModel, Backend: "TF", Device: "CPU", Tag: "m2:v1"},
+		{Key: "m3", Type: KeyTypeModel, Backend: "ORT", Device: "GPU"},
+	}, keys)
+	assert.Equal(t, []interface{}{"SCAN", int64(0), "MATCH", "m*", "COUNT", int64(100), "TYPE", KeyTypeModel}, conn1.Commands()[0])
+	assert.Equal(t, int64(7), conn1.Commands()[3][1])
+}
+
+func TestKeyScanner_Fallback(t *testing.T) {
+	c, conn := createFakeClient(func(cmd string, args []interface{}) (interface{}, error) {
+		switch cmd {
+		case "AI._SCRIPTSCAN":
+			return []interface{}{
+				[]interface{}{[]byte("s1"), []byte("v1")},
+				[]interface{}{[]byte("other"), []byte("v2")},
+			}, nil
+		}
+		return nil, redis.Error("ERR syntax error")
+	})
+	scanner := c.ScanScripts()
+	scanner.Match = "s*"
+	keys, err := scanner.All()
+	assert.Nil(t, err)
+	assert.Equal(t, []KeyInfo{{Key: "s1", Type: KeyTypeScript, Tag: "v1"}}, keys)
+	assert.Equal(t, []string{"SCAN", "AI._SCRIPTSCAN"}, conn.CommandNames())
+
+	_, err = c.ScanTensors().All()
+	assert.NotNil(t, err)
+}
+
+func TestClusterNodes(t *testing.T) {
+	c, _ := createFakeClient(func(cmd string, args []interface{}) (interface{}, error) {
+		return []byte("07c37dfeb235213a872192d90877d0cd55635b91 127.0.0.1:30004@31004 slave e7d1eecce10fd6bb5eb35b9f99a514335d9ba9ca 0 1426238317239 4 connected\n" +
+			"67ed2db8d677e59ec4a4cefb06858cf2a1a89fa1 127.0.0.1:30002@31002,node2 master - 0 1426238316232 2 connected 5461-10922\n" +
+			"292f8b365bb7edb5e285caf0b7e6ddc7265d2f4f 127.0.0.1:30003@31003 master - 0 1426238318243 3 connected 10923-16383\n" +
+			"6ec23923021cf3ffec47632106199cb7f496ce01 127.0.0.1:30005@31005 master,fail - 1426238316232 0 5 disconnected\n" +
+			"e7d1eecce10fd6bb5eb35b9f99a514335d9ba9ca 127.0.0.1:30001@31001 myself,master - 0 0 1 connected 0-5460\n"), nil
+	})
+	var dialed []string
+	nodes, err := ClusterNodes(c, func(address string) (redis.Conn, error) {
+		dialed = append(dialed, address)
+		return nil, redis.Error("ERR unreachable")
+	})
+	assert.Nil(t, err)
+	assert.Len(t, nodes, 3)
+	assert.Equal(t, c, nodes[2])
+	_, err = nodes[0].DoOrSend("PING", nil, nil)
+	assert.NotNil(t, err)
+	_, err = nodes[1].DoOrSend("PING", nil, nil)
+	assert.NotNil(t, err)
+	assert.Equal(t, []string{"127.0.0.1:30002", "127.0.0.1:30003"}, dialed)
+	CloseClusterNodes(c, nodes)
+
+	standalone, conn := createFakeClient(func(cmd string, args []interface{}) (interface{}, error) {
+		return nil, redis.Error("ERR This instance has cluster support disabled")
+	})
+	nodes, err = ClusterNodes(standalone, nil)
+	assert.Nil(t, err)
+	assert.Equal(t, []*Client{standalone}, nodes)
+	assert.Equal(t, []interface{}{"CLUSTER", "NODES"}, conn.Commands()[0])
+}
@@ -64,6 +64,18 @@ func scriptGetParseReply(reply interface{}) (device, tag, source string, entryPo
 	return
 }
 
+// ScriptMeta holds the metadata of a script, without its source
+type ScriptMeta struct {
+	Device      string   `json:"device"`
+	Tag         string   `json:"tag"`
+	EntryPoints []string `json:"entry_points"`
+}
+
+func scriptGetMetaParseReply(reply interface{}) (meta ScriptMeta, err error) {
+	meta.Device, meta.Tag, _, meta.EntryPoints, err = scriptGetParseReply(reply)
+	return
+}
+
 func scriptGetFlatArgs(name string) redis.Args {
 	args := redis.Args{}.Add(name, "META", "SOURCE")
 	return args
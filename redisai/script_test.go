@@ -1,6 +1,7 @@
 package redisai
 
 import (
+	"github.com/gomodule/redigo/redis"
 	"github.com/stretchr/testify/assert"
 	"testing"
 )
@@ -72,3 +73,40 @@ func Test_scriptGetParseToInterface(t *testing.T) {
 		})
 	}
 }
+
+func TestClient_ScriptGetMeta(t *testing.T) {
+	c, conn := createFakeClient(func(cmd string, args []interface{}) (interface{}, error) {
+		return []interface{}{[]byte("device"), []byte("CPU"), []byte("tag"), []byte("v1"), []byte("Entry Points"), []interface{}{[]byte("bar")}}, nil
+	})
+	meta, err := c.ScriptGetMeta("myscript")
+	assert.Nil(t, err)
+	assert.Equal(t, ScriptMeta{Device: DeviceCPU, Tag: "v1", EntryPoints: []string{"bar"}}, meta)
+	assert.Equal(t, []interface{}{"AI.SCRIPTGET", "myscript", "META"}, conn.Commands()[0])
+}
+
+func TestClient_ScriptGetSource(t *testing.T) {
+	tests := []struct {
+		name  string
+		reply interface{}
+	}{
+		{"bulk", []byte("def bar(a):")},
+		{"array", []interface{}{[]byte("source"), []byte("def bar(a):")}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, conn := createFakeClient(func(cmd string, args []interface{}) (interface{}, error) {
+				return tt.reply, nil
+			})
+			source, err := c.ScriptGetSource("myscript")
+			assert.Nil(t, err)
+			assert.Equal(t, "def bar(a):", source)
+			assert.Equal(t, []interface{}{"AI.SCRIPTGET", "myscript", "SOURCE"}, conn.Commands()[0])
+		})
+	}
+
+	c, _ := createFakeClient(func(cmd string, args []interface{}) (interface{}, error) {
+		return nil, redis.Error("ERR script key is empty")
+	})
+	_, err := c.ScriptGetSource("myscript")
+	assert.NotNil(t, err)
+}
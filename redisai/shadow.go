@@ -0,0 +1,251 @@
+package redisai
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"reflect"
+	"sync"
+
+	"github.com/RedisAI/redisai-go/redisai/implementations"
+)
+
+// DefaultShadowMaxInFlight is the number of concurrent candidate executions of a ShadowPredictor created with NewShadowPredictor
+const DefaultShadowMaxInFlight = 4
+
+// ShadowOutputStats holds the comparison statistics of a single output between the live and the candidate model
+type ShadowOutputStats struct {
+	// Compared is the number of compared predictions
+	Compared int64
+	// Mismatches is the number of predictions whose outputs differ in type or shape, and can't be compared
+	Mismatches int64
+	// MaxAbsDiff is the maximum absolute difference between two output values across every prediction
+	MaxAbsDiff float64
+	// SumMaxAbsDiff is the sum of the per prediction maximum absolute differences
+	SumMaxAbsDiff float64
+	// ArgmaxRows is the number of compared rows, the rows being the slices along the last dimension
+	ArgmaxRows int64
+	// ArgmaxAgreements is the number of rows whose argmax is the same for both models
+	ArgmaxAgreements int64
+}
+
+// MeanMaxAbsDiff returns the average of the per prediction maximum absolute differences
+func (s ShadowOutputStats) MeanMaxAbsDiff() float64 {
+	if s.Compared == 0 {
+		return 0
+	}
+	return s.SumMaxAbsDiff / float64(s.Compared)
+}
+
+// ArgmaxAgreement returns the ratio of rows whose argmax is the same for both models
+func (s ShadowOutputStats) ArgmaxAgreement() float64 {
+	if s.ArgmaxRows == 0 {
+		return 0
+	}
+	return float64(s.ArgmaxAgreements) / float64(s.ArgmaxRows)
+}
+
+// ShadowStats holds the statistics of a ShadowPredictor
+type ShadowStats struct {
+	// Mirrored is the number of predictions mirrored to the candidate model
+	Mirrored int64
+	// Dropped is the number of sampled predictions not mirrored because MaxInFlight executions were running
+	Dropped int64
+	// Errors is the number of failed candidate executions
+	Errors int64
+	// LastError is the error of the last failed candidate execution
+	LastError error
+	// Outputs holds the comparison statistics by output name
+	Outputs map[string]ShadowOutputStats
+}
+
+// ShadowPredictor runs the predictions of a live model, and mirrors a sample of them to a candidate model in
+// the background to compare their outputs, without affecting the live response nor its latency.
+//
+// The candidate executions are issued through their own Client on the live Client pool, at most MaxInFlight
+// at a time: sampled predictions are dropped rather than queued when the limit is reached. They run on copies of
+// the live inputs and outputs, which the caller is free to modify once Predict returns.
+// As the live Predictor, a ShadowPredictor is not safe for concurrent use; its statistics are.
+type ShadowPredictor struct {
+	Live *Predictor
+	// CandidateKey is the key of the candidate model, run with the live inputs and outputs
+	CandidateKey string
+	// SampleRate is the ratio of the predictions mirrored to the candidate, from 0 to 1
+	SampleRate float64
+	// MaxInFlight is the maximum number of concurrent candidate executions. Zero or negative means
+	// DefaultShadowMaxInFlight
+	MaxInFlight int
+
+	mu       sync.Mutex
+	stats    ShadowStats
+	inFlight int
+	wg       sync.WaitGroup
+}
+
+// NewShadowPredictor returns a ShadowPredictor mirroring sampleRate of the predictions of live to the model
+// stored at candidateKey
+func NewShadowPredictor(live *Predictor, candidateKey string, sampleRate float64) *ShadowPredictor {
+	return &ShadowPredictor{
+		Live:         live,
+		CandidateKey: candidateKey,
+		SampleRate:   sampleRate,
+		MaxInFlight:  DefaultShadowMaxInFlight,
+	}
+}
+
+// Predict runs the live prediction and returns its outputs, after starting the candidate one when sampled
+func (s *ShadowPredictor) Predict(inputs map[string]TensorInterface) (map[string]TensorInterface, error) {
+	outputs, err := s.Live.Predict(inputs)
+	if err != nil || s.SampleRate <= 0 || rand.Float64() >= s.SampleRate {
+		return outputs, err
+	}
+	s.mu.Lock()
+	if s.inFlight >= s.maxInFlight() {
+		s.stats.Dropped++
+		s.mu.Unlock()
+		return outputs, err
+	}
+	s.inFlight++
+	s.stats.Mirrored++
+	s.mu.Unlock()
+	candidate := *s.Live
+	candidate.Key = s.CandidateKey
+	s.wg.Add(1)
+	go s.shadow(&candidate, copyTensors(inputs), copyTensors(outputs))
+	return outputs, err
+}
+
+func (s *ShadowPredictor) shadow(candidate *Predictor, inputs, liveOutputs map[string]TensorInterface) {
+	defer s.wg.Done()
+	client := Connect("", candidate.Client.Pool)
+	defer client.Close()
+	candidate.Client = client
+	outputs, err := candidate.Predict(inputs)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.inFlight--
+	if err != nil {
+		s.stats.Errors++
+		s.stats.LastError = err
+		return
+	}
+	if s.stats.Outputs == nil {
+		s.stats.Outputs = make(map[string]ShadowOutputStats)
+	}
+	for _, name := range candidate.Outputs {
+		stats := s.stats.Outputs[name]
+		compareShadowOutputs(&stats, liveOutputs[name], outputs[name])
+		s.stats.Outputs[name] = stats
+	}
+}
+
+func (s *ShadowPredictor) maxInFlight() int {
+	if s.MaxInFlight <= 0 {
+		return DefaultShadowMaxInFlight
+	}
+	return s.MaxInFlight
+}
+
+// copyTensors returns a copy of tensors, whose shapes and data are not shared with the originals
+func copyTensors(tensors map[string]TensorInterface) map[string]TensorInterface {
+	copies := make(map[string]TensorInterface, len(tensors))
+	for name, tensor := range tensors {
+		if tensor == nil {
+			copies[name] = nil
+			continue
+		}
+		data := tensor.Data()
+		if value := reflect.ValueOf(data); value.Kind() == reflect.Slice {
+			dataCopy := reflect.MakeSlice(value.Type(), value.Len(), value.Len())
+			reflect.Copy(dataCopy, value)
+			data = dataCopy.Interface()
+		}
+		tensorCopy := implementations.NewAiTensorWithShape(append([]int64{}, tensor.Shape()...))
+		tensorCopy.SetData(data)
+		copies[name] = tensorCopy
+	}
+	return copies
+}
+
+// Wait waits for the running candidate executions to complete
+func (s *ShadowPredictor) Wait() {
+	s.wg.Wait()
+}
+
+// Stats returns a snapshot of the comparison statistics
+func (s *ShadowPredictor) Stats() ShadowStats {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	stats := s.stats
+	stats.Outputs = make(map[string]ShadowOutputStats, len(s.stats.Outputs))
+	for name, output := range s.stats.Outputs {
+		stats.Outputs[name] = output
+	}
+	return stats
+}
+
+func compareShadowOutputs(stats *ShadowOutputStats, live, candidate TensorInterface) {
+	if live == nil || candidate == nil || live.Dtype() != candidate.Dtype() || !reflect.DeepEqual(live.Shape(), candidate.Shape()) {
+		stats.Mismatches++
+		return
+	}
+	liveValues, errLive := tensorFloat64s(live.Data())
+	candidateValues, errCandidate := tensorFloat64s(candidate.Data())
+	if errLive != nil || errCandidate != nil || len(liveValues) != len(candidateValues) {
+		stats.Mismatches++
+		return
+	}
+	stats.Compared++
+	maxAbsDiff := 0.0
+	for pos := range liveValues {
+		maxAbsDiff = math.Max(maxAbsDiff, math.Abs(liveValues[pos]-candidateValues[pos]))
+	}
+	stats.MaxAbsDiff = math.Max(stats.MaxAbsDiff, maxAbsDiff)
+	stats.SumMaxAbsDiff += maxAbsDiff
+
+	shape := live.Shape()
+	if len(shape) == 0 || shape[len(shape)-1] <= 0 {
+		return
+	}
+	rowSize := int(shape[len(shape)-1])
+	for start := 0; start+rowSize <= len(liveValues); start += rowSize {
+		stats.ArgmaxRows++
+		if argmax(liveValues[start:start+rowSize]) == argmax(candidateValues[start:start+rowSize]) {
+			stats.ArgmaxAgreements++
+		}
+	}
+}
+
+func argmax(values []float64) int {
+	best := 0
+	for pos, value := range values {
+		if value > values[best] {
+			best = pos
+		}
+	}
+	return best
+}
+
+// tensorFloat64s converts a slice of numeric tensor values to float64
+func tensorFloat64s(data interface{}) ([]float64, error) {
+	value := reflect.ValueOf(data)
+	if value.Kind() != reflect.Slice {
+		return nil, fmt.Errorf("redisai: tensor data of type %T is not a slice", data)
+	}
+	values := make([]float64, value.Len())
+	for pos := range values {
+		element := value.Index(pos)
+		switch element.Kind() {
+		case reflect.Float32, reflect.Float64:
+			values[pos] = element.Float()
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			values[pos] = float64(element.Int())
+		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+			values[pos] = float64(element.Uint())
+		default:
+			return nil, fmt.Errorf("redisai: tensor data of type %T is not numeric", data)
+		}
+	}
+	return values, nil
+}
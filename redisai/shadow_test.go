@@ -0,0 +1,125 @@
+package redisai
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/RedisAI/redisai-go/redisai/implementations"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestShadowPredictor_Predict(t *testing.T) {
+	tensorReply := func(values ...string) []interface{} {
+		replyValues := make([]interface{}, len(values))
+		for pos, value := range values {
+			replyValues[pos] = []byte(value)
+		}
+		return []interface{}{[]byte("dtype"), []byte(TypeFloat), []byte("shape"), []interface{}{int64(2), int64(2)}, []byte("values"), replyValues}
+	}
+	var candidateErr error
+	c, _ := createFakeClient(func(cmd string, args []interface{}) (interface{}, error) {
+		if args[1] == "candidate" {
+			if candidateErr != nil {
+				return nil, candidateErr
+			}
+			return []interface{}{"OK", "OK", tensorReply("0.1", "0.5", "0.9", "0.2")}, nil
+		}
+		return []interface{}{"OK", "OK", tensorReply("0.2", "0.4", "0.3", "0.7")}, nil
+	})
+	live := NewModelPredictor(c, "live", []string{"a"}, []string{"b"})
+	s := NewShadowPredictor(live, "candidate", 1)
+	inputs := map[string]TensorInterface{"a": implementations.NewAiTensorWithData(TypeFloat, []int64{1}, []float32{1})}
+
+	outputs, err := s.Predict(inputs)
+	assert.Nil(t, err)
+	assert.Equal(t, []float32{0.2, 0.4, 0.3, 0.7}, outputs["b"].Data())
+	s.Wait()
+
+	candidateErr = errors.New("candidate failure")
+	_, err = s.Predict(inputs)
+	assert.Nil(t, err)
+	s.Wait()
+
+	stats := s.Stats()
+	assert.Equal(t, int64(2), stats.Mirrored)
+	assert.Equal(t, int64(1), stats.Errors)
+	assert.Equal(t, candidateErr, stats.LastError)
+	output := stats.Outputs["b"]
+	assert.Equal(t, int64(1), output.Compared)
+	assert.InDelta(t, 0.6, output.MaxAbsDiff, 1e-6)
+	assert.InDelta(t, 0.6, output.MeanMaxAbsDiff(), 1e-6)
+	// the first row argmax differs, the second one agrees
+	assert.Equal(t, int64(2), output.ArgmaxRows)
+	assert.Equal(t, int64(1), output.ArgmaxAgreements)
+	assert.Equal(t, 0.5, output.ArgmaxAgreement())
+}
+
+func TestShadowPredictor_Sampling(t *testing.T) {
+	c, conn := createFakeClient(func(cmd string, args []interface{}) (interface{}, error) {
+		return []interface{}{"OK", "OK", []interface{}{[]byte("dtype"), []byte(TypeFloat), []byte("shape"), []interface{}{int64(1)}, []byte("values"), []interface{}{[]byte("1")}}}, nil
+	})
+	live := NewModelPredictor(c, "live", []string{"a"}, []string{"b"})
+	inputs := map[string]TensorInterface{"a": implementations.NewAiTensorWithData(TypeFloat, []int64{1}, []float32{1})}
+
+	s := NewShadowPredictor(live, "candidate", 0)
+	_, err := s.Predict(inputs)
+	assert.Nil(t, err)
+	assert.Equal(t, int64(0), s.Stats().Mirrored)
+	assert.Len(t, conn.Commands(), 1)
+
+	// the sampled predictions are dropped while MaxInFlight candidate executions are running
+	release := make(chan struct{})
+	c, _ = createFakeClient(func(cmd string, args []interface{}) (interface{}, error) {
+		if args[1] == "candidate" {
+			<-release
+		}
+		return []interface{}{"OK", "OK", []interface{}{[]byte("dtype"), []byte(TypeFloat), []byte("shape"), []interface{}{int64(1)}, []byte("values"), []interface{}{[]byte("1")}}}, nil
+	})
+	s = NewShadowPredictor(NewModelPredictor(c, "live", []string{"a"}, []string{"b"}), "candidate", 1)
+	s.MaxInFlight = 1
+	for i := 0; i < 2; i++ {
+		_, err = s.Predict(inputs)
+		assert.Nil(t, err)
+	}
+	close(release)
+	s.Wait()
+	assert.Equal(t, int64(1), s.Stats().Mirrored)
+	assert.Equal(t, int64(1), s.Stats().Dropped)
+}
+
+func TestShadowPredictor_Zero(t *testing.T) {
+	c, _ := createFakeClient(func(cmd string, args []interface{}) (interface{}, error) {
+		return []interface{}{"OK", "OK", []interface{}{[]byte("dtype"), []byte(TypeFloat), []byte("shape"), []interface{}{int64(1)}, []byte("values"), []interface{}{[]byte("1")}}}, nil
+	})
+	s := &ShadowPredictor{Live: NewModelPredictor(c, "live", []string{"a"}, []string{"b"}), CandidateKey: "candidate", SampleRate: 1}
+	inputs := map[string]TensorInterface{"a": implementations.NewAiTensorWithData(TypeFloat, []int64{1}, []float32{1})}
+	outputs, err := s.Predict(inputs)
+	assert.Nil(t, err)
+	// the candidate runs on copies, which leaves the caller free to modify the inputs and outputs
+	inputs["a"].Data().([]float32)[0] = 2
+	outputs["b"].Data().([]float32)[0] = 2
+	delete(inputs, "a")
+	s.Wait()
+	assert.Equal(t, int64(0), s.Stats().Dropped)
+	assert.Equal(t, int64(1), s.Stats().Outputs["b"].Compared)
+	assert.Equal(t, 0.0, s.Stats().Outputs["b"].MaxAbsDiff)
+}
+
+func TestCopyTensors(t *testing.T) {
+	tensors := map[string]TensorInterface{"a": implementations.NewAiTensorWithData(TypeFloat, []int64{2}, []float32{1, 2}), "b": nil}
+	copies := copyTensors(tensors)
+	tensors["a"].Data().([]float32)[0] = 3
+	tensors["a"].Shape()[0] = 3
+	assert.Equal(t, []float32{1, 2}, copies["a"].Data())
+	assert.Equal(t, []int64{2}, copies["a"].Shape())
+	assert.Nil(t, copies["b"])
+}
+
+func TestCompareShadowOutputs_Mismatch(t *testing.T) {
+	var stats ShadowOutputStats
+	compareShadowOutputs(&stats, implementations.NewAiTensorWithData(TypeFloat, []int64{2}, []float32{1, 2}), implementations.NewAiTensorWithData(TypeFloat, []int64{1, 2}, []float32{1, 2}))
+	compareShadowOutputs(&stats, implementations.NewAiTensorWithData(TypeFloat, []int64{2}, []float32{1, 2}), implementations.NewAiTensorWithData(TypeInt32, []int64{2}, []int32{1, 2}))
+	compareShadowOutputs(&stats, implementations.NewAiTensorWithData(TypeFloat, []int64{2}, []float32{1, 2}), nil)
+	assert.Equal(t, int64(3), stats.Mismatches)
+	assert.Equal(t, int64(0), stats.Compared)
+}
@@ -1,10 +1,13 @@
 package redisai
 
 import (
+	"bytes"
+	"encoding/binary"
 	"fmt"
 	"github.com/RedisAI/redisai-go/redisai/converters"
 	"github.com/gomodule/redigo/redis"
 	"reflect"
+	"strconv"
 )
 
 // TensorInterface is an interface that represents the skeleton of a tensor ( n-dimensional array of numerical data )
@@ -183,3 +186,139 @@ func ProcessTensorGetReply(reply interface{}, errIn error) (dtype string, shape
 	}
 	return
 }
+
+// TensorDataFromStrings parses the textual values of a tensor into a slice of the Go type matching dtype
+func TensorDataFromStrings(dtype string, values []string) (data interface{}, err error) {
+	bitSize := 0
+	switch dtype {
+	case TypeFloat:
+		result := make([]float32, len(values))
+		for pos, value := range values {
+			var f float64
+			if f, err = strconv.ParseFloat(value, 32); err != nil {
+				return
+			}
+			result[pos] = float32(f)
+		}
+		data = result
+	case TypeDouble:
+		result := make([]float64, len(values))
+		for pos, value := range values {
+			if result[pos], err = strconv.ParseFloat(value, 64); err != nil {
+				return
+			}
+		}
+		data = result
+	case TypeInt8, TypeInt16, TypeInt32, TypeInt64:
+		bitSize, _ = strconv.Atoi(dtype[3:])
+		ints := make([]int64, len(values))
+		for pos, value := range values {
+			if ints[pos], err = strconv.ParseInt(value, 10, bitSize); err != nil {
+				return
+			}
+		}
+		data = convertInts(dtype, ints)
+	case TypeUint8, TypeUint16:
+		bitSize, _ = strconv.Atoi(dtype[4:])
+		uints := make([]uint64, len(values))
+		for pos, value := range values {
+			if uints[pos], err = strconv.ParseUint(value, 10, bitSize); err != nil {
+				return
+			}
+		}
+		if dtype == TypeUint8 {
+			result := make([]uint8, len(uints))
+			for pos, v := range uints {
+				result[pos] = uint8(v)
+			}
+			data = result
+		} else {
+			result := make([]uint16, len(uints))
+			for pos, v := range uints {
+				result[pos] = uint16(v)
+			}
+			data = result
+		}
+	default:
+		err = fmt.Errorf("redisai.TensorDataFromStrings: unsupported tensor type %s", dtype)
+	}
+	return
+}
+
+func convertInts(dtype string, ints []int64) interface{} {
+	switch dtype {
+	case TypeInt8:
+		result := make([]int8, len(ints))
+		for pos, v := range ints {
+			result[pos] = int8(v)
+		}
+		return result
+	case TypeInt16:
+		result := make([]int16, len(ints))
+		for pos, v := range ints {
+			result[pos] = int16(v)
+		}
+		return result
+	case TypeInt32:
+		result := make([]int32, len(ints))
+		for pos, v := range ints {
+			result[pos] = int32(v)
+		}
+		return result
+	}
+	return ints
+}
+
+// TensorDataFromBlob decodes a little-endian tensor blob into a slice of the Go type matching dtype
+func TensorDataFromBlob(dtype string, blob []byte) (data interface{}, err error) {
+	var size int
+	switch dtype {
+	case TypeFloat:
+		data, size = make([]float32, len(blob)/4), 4
+	case TypeDouble:
+		data, size = make([]float64, len(blob)/8), 8
+	case TypeInt8:
+		data, size = make([]int8, len(blob)), 1
+	case TypeInt16:
+		data, size = make([]int16, len(blob)/2), 2
+	case TypeInt32:
+		data, size = make([]int32, len(blob)/4), 4
+	case TypeInt64:
+		data, size = make([]int64, len(blob)/8), 8
+	case TypeUint8:
+		return blob, nil
+	case TypeUint16:
+		data, size = make([]uint16, len(blob)/2), 2
+	default:
+		return nil, fmt.Errorf("redisai.TensorDataFromBlob: unsupported tensor type %s", dtype)
+	}
+	if len(blob)%size != 0 {
+		return nil, fmt.Errorf("redisai.TensorDataFromBlob: blob length %d is not a multiple of the %s size", len(blob), dtype)
+	}
+	err = binary.Read(bytes.NewReader(blob), binary.LittleEndian, data)
+	return
+}
+
+// TensorDataToBlob encodes the slice of tensor values as a little-endian blob
+func TensorDataToBlob(data interface{}) (blob []byte, err error) {
+	switch values := data.(type) {
+	case []byte:
+		return values, nil
+	case []int:
+		// int values are handled as INT32, see TensorGetTypeStrFromType
+		converted := make([]int32, len(values))
+		for pos, v := range values {
+			converted[pos] = int32(v)
+		}
+		data = converted
+	case []uint:
+		converted := make([]uint8, len(values))
+		for pos, v := range values {
+			converted[pos] = uint8(v)
+		}
+		return converted, nil
+	}
+	var buf bytes.Buffer
+	err = binary.Write(&buf, binary.LittleEndian, data)
+	return buf.Bytes(), err
+}
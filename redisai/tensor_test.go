@@ -127,3 +127,79 @@ func TestProcessTensorGetReply(t *testing.T) {
 		})
 	}
 }
+
+func TestTensorDataFromStrings(t *testing.T) {
+	tests := []struct {
+		name    string
+		dtype   string
+		values  []string
+		want    interface{}
+		wantErr bool
+	}{
+		{"float", TypeFloat, []string{"1.5", "-2"}, []float32{1.5, -2}, false},
+		{"double", TypeDouble, []string{"1.5"}, []float64{1.5}, false},
+		{"int8", TypeInt8, []string{"-128", "127"}, []int8{-128, 127}, false},
+		{"int8-overflow", TypeInt8, []string{"128"}, nil, true},
+		{"int16", TypeInt16, []string{"1"}, []int16{1}, false},
+		{"int32", TypeInt32, []string{"1"}, []int32{1}, false},
+		{"int64", TypeInt64, []string{"9007199254740993"}, []int64{9007199254740993}, false},
+		{"uint8", TypeUint8, []string{"255"}, []uint8{255}, false},
+		{"uint16-negative", TypeUint16, []string{"-1"}, nil, true},
+		{"uint16", TypeUint16, []string{"1"}, []uint16{1}, false},
+		{"not-a-number", TypeFloat, []string{"a"}, nil, true},
+		{"unsupported", "BOOL", []string{"1"}, nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := TensorDataFromStrings(tt.dtype, tt.values)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("TensorDataFromStrings() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("TensorDataFromStrings() got = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTensorDataBlob(t *testing.T) {
+	tests := []struct {
+		name  string
+		dtype string
+		data  interface{}
+	}{
+		{"float", TypeFloat, []float32{1.5, -2}},
+		{"double", TypeDouble, []float64{1.5}},
+		{"int8", TypeInt8, []int8{-1}},
+		{"int16", TypeInt16, []int16{-1}},
+		{"int32", TypeInt32, []int32{-1}},
+		{"int64", TypeInt64, []int64{-1}},
+		{"uint8", TypeUint8, []uint8{1, 2}},
+		{"uint16", TypeUint16, []uint16{1}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			blob, err := TensorDataToBlob(tt.data)
+			if err != nil {
+				t.Errorf("TensorDataToBlob() error = %v", err)
+				return
+			}
+			got, err := TensorDataFromBlob(tt.dtype, blob)
+			if err != nil {
+				t.Errorf("TensorDataFromBlob() error = %v", err)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.data) {
+				t.Errorf("TensorDataFromBlob() got = %v, want %v", got, tt.data)
+			}
+		})
+	}
+	f32Bytes, _ := TensorDataToBlob([]int{1})
+	if !reflect.DeepEqual(f32Bytes, []byte{1, 0, 0, 0}) {
+		t.Errorf("TensorDataToBlob() got = %v, want %v", f32Bytes, []byte{1, 0, 0, 0})
+	}
+	if _, err := TensorDataFromBlob(TypeFloat, []byte{1, 2, 3}); err == nil {
+		t.Errorf("TensorDataFromBlob() expected an error for a truncated blob")
+	}
+}
@@ -0,0 +1,125 @@
+// Package tracing creates a span per RedisAI command, through a redisai.CommandHook.
+//
+// The Tracer and Span interfaces hold the subset of the OpenTelemetry tracing API used by the hook, so that the
+// package does not depend on OpenTelemetry. An OpenTelemetry tracer is adapted in a few lines:
+//
+//	type otelTracer struct{ trace.Tracer }
+//
+//	func (t otelTracer) Start(ctx context.Context, name string) (context.Context, tracing.Span) {
+//		ctx, span := t.Tracer.Start(ctx, name, trace.WithSpanKind(trace.SpanKindClient))
+//		return ctx, otelSpan{span}
+//	}
+//
+//	type otelSpan struct{ trace.Span }
+//
+//	func (s otelSpan) SetAttributes(attributes ...tracing.Attribute) {
+//		for _, a := range attributes {
+//			switch v := a.Value.(type) {
+//			case string:
+//				s.Span.SetAttributes(attribute.String(a.Key, v))
+//			case int64:
+//				s.Span.SetAttributes(attribute.Int64(a.Key, v))
+//			case bool:
+//				s.Span.SetAttributes(attribute.Bool(a.Key, v))
+//			}
+//		}
+//	}
+//
+//	func (s otelSpan) RecordError(err error) {
+//		s.Span.RecordError(err)
+//		s.Span.SetStatus(codes.Error, err.Error())
+//	}
+//
+//	func (s otelSpan) End() { s.Span.End() }
+//
+// The spans are named after the command, and carry the attributes below, along with db.system set to redis and
+// db.operation set to the command name. The attributes of the empty values are omitted.
+package tracing
+
+import (
+	"context"
+	"time"
+
+	"github.com/RedisAI/redisai-go/redisai"
+)
+
+// Attributes of the command spans
+const (
+	AttributeDBSystem    = "db.system"
+	AttributeDBOperation = "db.operation"
+	AttributeKey         = "db.redis.key"
+	AttributeModel       = "redisai.model"
+	AttributeScript      = "redisai.script"
+	AttributeDagOps      = "redisai.dag.ops"
+	AttributePayloadSize = "redisai.payload.size"
+	AttributePipelined   = "redisai.pipelined"
+	// AttributeDuration is the duration of the command in microseconds
+	AttributeDuration = "redisai.duration_us"
+)
+
+// Attribute is a span attribute, whose value is either a string, an int64 or a bool
+type Attribute struct {
+	Key   string
+	Value interface{}
+}
+
+// Tracer starts spans, as the OpenTelemetry trace.Tracer
+type Tracer interface {
+	Start(ctx context.Context, name string) (context.Context, Span)
+}
+
+// Span is a span started by a Tracer, as the OpenTelemetry trace.Span
+type Span interface {
+	SetAttributes(attributes ...Attribute)
+	RecordError(err error)
+	End()
+}
+
+// Hook is a redisai.CommandHook creating a span per command
+type Hook struct {
+	tracer Tracer
+}
+
+// NewHook returns a hook creating the spans with tracer. It is registered with redisai.Client.AddHook
+func NewHook(tracer Tracer) *Hook {
+	return &Hook{tracer: tracer}
+}
+
+type spanKey struct{}
+
+// BeforeCommand starts the span of the command, as a child of the span of ctx if any
+func (h *Hook) BeforeCommand(ctx context.Context, info *redisai.CommandInfo) context.Context {
+	ctx, span := h.tracer.Start(ctx, info.Name)
+	attributes := []Attribute{{AttributeDBSystem, "redis"}, {AttributeDBOperation, info.Name}}
+	if len(info.Key) > 0 {
+		attributes = append(attributes, Attribute{AttributeKey, info.Key})
+	}
+	if len(info.Model) > 0 {
+		attributes = append(attributes, Attribute{AttributeModel, info.Model})
+	}
+	if len(info.Script) > 0 {
+		attributes = append(attributes, Attribute{AttributeScript, info.Script})
+	}
+	if info.DagOps > 0 {
+		attributes = append(attributes, Attribute{AttributeDagOps, int64(info.DagOps)})
+	}
+	if info.Pipelined {
+		attributes = append(attributes, Attribute{AttributePipelined, true})
+	}
+	attributes = append(attributes, Attribute{AttributePayloadSize, int64(info.PayloadSize)})
+	span.SetAttributes(attributes...)
+	return context.WithValue(ctx, spanKey{}, span)
+}
+
+// AfterCommand records the duration and error of the command, and ends its span
+func (h *Hook) AfterCommand(ctx context.Context, info *redisai.CommandInfo) {
+	span, ok := ctx.Value(spanKey{}).(Span)
+	if !ok {
+		return
+	}
+	span.SetAttributes(Attribute{AttributeDuration, int64(info.Duration / time.Microsecond)})
+	if info.Err != nil {
+		span.RecordError(info.Err)
+	}
+	span.End()
+}
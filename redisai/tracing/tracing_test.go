@@ -0,0 +1,84 @@
+package tracing
+
+import (
+	"context"
+	"testing"
+
+	"github.com/RedisAI/redisai-go/redisai"
+	"github.com/RedisAI/redisai-go/redisai/internal/redistest"
+	"github.com/gomodule/redigo/redis"
+	"github.com/stretchr/testify/assert"
+)
+
+type fakeSpan struct {
+	name       string
+	parent     *fakeSpan
+	attributes map[string]interface{}
+	err        error
+	ended      bool
+}
+
+func (s *fakeSpan) SetAttributes(attributes ...Attribute) {
+	for _, attribute := range attributes {
+		s.attributes[attribute.Key] = attribute.Value
+	}
+}
+
+func (s *fakeSpan) RecordError(err error) { s.err = err }
+
+func (s *fakeSpan) End() { s.ended = true }
+
+type parentKey struct{}
+
+type fakeTracer struct {
+	spans []*fakeSpan
+}
+
+func (t *fakeTracer) Start(ctx context.Context, name string) (context.Context, Span) {
+	parent, _ := ctx.Value(parentKey{}).(*fakeSpan)
+	span := &fakeSpan{name: name, parent: parent, attributes: map[string]interface{}{}}
+	t.spans = append(t.spans, span)
+	return context.WithValue(ctx, parentKey{}, span), span
+}
+
+func TestHook(t *testing.T) {
+	pool, _ := redistest.NewPool(func(cmd string, args []interface{}) (interface{}, error) {
+		if cmd == "AI.MODELDEL" {
+			return nil, redis.Error("ERR model key is empty")
+		}
+		return []interface{}{"OK", "OK", "OK"}, nil
+	})
+	c := redisai.Connect("", pool)
+	tracer := &fakeTracer{}
+	c.AddHook(NewHook(tracer))
+
+	request := &fakeSpan{name: "request"}
+	dag := redisai.NewDag().TensorSet("a", redisai.TypeFloat32, []int64{1}, []float32{1}).
+		ModelExecute("mymodel{1}", []string{"a"}, []string{"b"}, 0).TensorGet("b", redisai.TensorContentTypeValues)
+	err := c.RunWithContext(context.WithValue(context.Background(), parentKey{}, request), func() error {
+		_, err := c.DagExecuteROAuto(0, dag.(*redisai.Dag))
+		return err
+	})
+	assert.Nil(t, err)
+	assert.NotNil(t, c.ModelDel("mymodel{1}"))
+
+	assert.Len(t, tracer.spans, 2)
+	span := tracer.spans[0]
+	assert.Equal(t, "AI.DAGEXECUTE_RO", span.name)
+	assert.Equal(t, request, span.parent)
+	assert.True(t, span.ended)
+	assert.Nil(t, span.err)
+	assert.Equal(t, "redis", span.attributes[AttributeDBSystem])
+	assert.Equal(t, "mymodel{1}", span.attributes[AttributeKey])
+	assert.Equal(t, "mymodel{1}", span.attributes[AttributeModel])
+	assert.Equal(t, int64(3), span.attributes[AttributeDagOps])
+	assert.Contains(t, span.attributes, AttributeDuration)
+	assert.Contains(t, span.attributes, AttributePayloadSize)
+	assert.NotContains(t, span.attributes, AttributeScript)
+
+	span = tracer.spans[1]
+	assert.Equal(t, "AI.MODELDEL", span.name)
+	assert.Nil(t, span.parent)
+	assert.Equal(t, redis.Error("ERR model key is empty"), span.err)
+	assert.True(t, span.ended)
+}
@@ -0,0 +1,158 @@
+package redisai
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/RedisAI/redisai-go/redisai/implementations"
+)
+
+// TensorSignature describes a model input by its name, type and shape
+type TensorSignature struct {
+	Name  string
+	Dtype string
+	Shape []int64
+}
+
+// ModelWarmup describes the executions issued to warm up a model, so that the lazy initialization of the
+// ORT and TF backends does not delay the first user request
+type ModelWarmup struct {
+	// Runs is the number of executions. Zero means a single one
+	Runs int
+	// Inputs describes the model inputs, in order
+	Inputs []TensorSignature
+	// Tensors holds the caller-supplied input tensors by input name. The inputs missing from it are fed
+	// with zero-filled tensors built from their signature
+	Tensors map[string]TensorInterface
+	// Outputs holds the names of the model outputs
+	Outputs []string
+	// Timeout is the execution timeout in milliseconds. Zero means no timeout
+	Timeout int64
+}
+
+// WarmupReport holds the latency of the warm-up executions
+type WarmupReport struct {
+	Latencies []time.Duration
+}
+
+// First returns the latency of the first execution, which includes the model initialization
+func (r *WarmupReport) First() time.Duration {
+	if len(r.Latencies) == 0 {
+		return 0
+	}
+	return r.Latencies[0]
+}
+
+// Last returns the latency of the last execution, expected to be close to the steady state latency
+func (r *WarmupReport) Last() time.Duration {
+	if len(r.Latencies) == 0 {
+		return 0
+	}
+	return r.Latencies[len(r.Latencies)-1]
+}
+
+// Total returns the cumulated latency of the executions
+func (r *WarmupReport) Total() (total time.Duration) {
+	for _, latency := range r.Latencies {
+		total += latency
+	}
+	return
+}
+
+// ModelStoreFromModelWithWarmup stores the model, then warms it up.
+// The warm-up inputs and outputs default to the model ones when not given. The inputs defaulted this way have no
+// type nor shape, and must be supplied through the warm-up Tensors. The warm-up is validated before the model is
+// stored, so that an invalid warm-up leaves the keyspace untouched.
+func (c *Client) ModelStoreFromModelWithWarmup(keyName string, model ModelInterface, warmup ModelWarmup) (*WarmupReport, error) {
+	if len(warmup.Inputs) == 0 {
+		for _, name := range model.Inputs() {
+			warmup.Inputs = append(warmup.Inputs, TensorSignature{Name: name})
+		}
+	}
+	if len(warmup.Outputs) == 0 {
+		warmup.Outputs = model.Outputs()
+	}
+	names, inputs, err := warmupInputs(warmup)
+	if err != nil {
+		return nil, err
+	}
+	if err := c.ModelStoreFromModel(keyName, model); err != nil {
+		return nil, err
+	}
+	return c.modelWarmup(keyName, warmup, names, inputs)
+}
+
+// ModelWarmup runs the model stored at keyName the requested number of times and reports the latency of every run.
+// The executions are issued as AI.DAGEXECUTE without PERSIST, so no tensor is left in the keyspace.
+func (c *Client) ModelWarmup(keyName string, warmup ModelWarmup) (*WarmupReport, error) {
+	names, inputs, err := warmupInputs(warmup)
+	if err != nil {
+		return nil, err
+	}
+	return c.modelWarmup(keyName, warmup, names, inputs)
+}
+
+func (c *Client) modelWarmup(keyName string, warmup ModelWarmup, names []string, inputs map[string]TensorInterface) (*WarmupReport, error) {
+	predictor := NewModelPredictor(c, keyName, names, warmup.Outputs)
+	predictor.Timeout = warmup.Timeout
+	runs := warmup.Runs
+	if runs <= 0 {
+		runs = 1
+	}
+	report := &WarmupReport{Latencies: make([]time.Duration, 0, runs)}
+	for run := 0; run < runs; run++ {
+		start := time.Now()
+		if _, err := predictor.Predict(inputs); err != nil {
+			return report, err
+		}
+		report.Latencies = append(report.Latencies, time.Since(start))
+	}
+	return report, nil
+}
+
+// warmupInputs returns the ordered input names of the warm-up and its input tensors, the caller-supplied ones or
+// zero-filled ones generated from the signatures
+func warmupInputs(warmup ModelWarmup) (names []string, inputs map[string]TensorInterface, err error) {
+	if len(warmup.Outputs) == 0 {
+		return nil, nil, errors.New("redisai.ModelWarmup: the model outputs are required")
+	}
+	names = make([]string, len(warmup.Inputs))
+	inputs = make(map[string]TensorInterface, len(warmup.Inputs))
+	for pos, signature := range warmup.Inputs {
+		names[pos] = signature.Name
+		if tensor, ok := warmup.Tensors[signature.Name]; ok {
+			inputs[signature.Name] = tensor
+			continue
+		}
+		tensor, err := zeroTensor(signature)
+		if err != nil {
+			return nil, nil, fmt.Errorf("redisai.ModelWarmup: input %s: %v", signature.Name, err)
+		}
+		inputs[signature.Name] = tensor
+	}
+	return names, inputs, nil
+}
+
+// zeroTensor returns a zero-filled tensor matching the signature
+func zeroTensor(signature TensorSignature) (TensorInterface, error) {
+	if len(signature.Dtype) == 0 || len(signature.Shape) == 0 {
+		return nil, errors.New("a type and a shape are required to generate the tensor")
+	}
+	size := int64(1)
+	for _, dim := range signature.Shape {
+		if dim <= 0 {
+			return nil, fmt.Errorf("invalid dimension %d", dim)
+		}
+		size *= dim
+	}
+	values := make([]string, size)
+	for pos := range values {
+		values[pos] = "0"
+	}
+	data, err := TensorDataFromStrings(signature.Dtype, values)
+	if err != nil {
+		return nil, err
+	}
+	return implementations.NewAiTensorWithData(signature.Dtype, signature.Shape, data), nil
+}
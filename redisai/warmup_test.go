@@ -0,0 +1,62 @@
+package redisai
+
+import (
+	"testing"
+
+	"github.com/RedisAI/redisai-go/redisai/implementations"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestClient_ModelStoreFromModelWithWarmup(t *testing.T) {
+	reply := []interface{}{[]byte("dtype"), []byte(TypeFloat), []byte("shape"), []interface{}{int64(1)}, []byte("values"), []interface{}{[]byte("0")}}
+	c, conn := createFakeClient(func(cmd string, args []interface{}) (interface{}, error) {
+		if cmd == "AI.DAGEXECUTE" {
+			return []interface{}{"OK", "OK", "OK", reply}, nil
+		}
+		return "OK", nil
+	})
+	model := implementations.NewModel(BackendTF, DeviceCPU)
+	model.SetInputs([]string{"a", "b"})
+	model.SetOutputs([]string{"mul"})
+	model.SetBlob([]byte("blob"))
+	report, err := c.ModelStoreFromModelWithWarmup("mymodel", model, ModelWarmup{
+		Runs:    3,
+		Inputs:  []TensorSignature{{Name: "a"}, {Name: "b", Dtype: TypeFloat, Shape: []int64{2, 2}}},
+		Tensors: map[string]TensorInterface{"a": implementations.NewAiTensorWithData(TypeFloat, []int64{1}, []float32{1})},
+	})
+	assert.Nil(t, err)
+	assert.Len(t, report.Latencies, 3)
+	assert.Equal(t, report.Latencies[0], report.First())
+	assert.Equal(t, report.Latencies[2], report.Last())
+	assert.True(t, report.Total() >= report.First())
+	assert.Equal(t, []string{"AI.MODELSTORE", "AI.DAGEXECUTE", "AI.DAGEXECUTE", "AI.DAGEXECUTE"}, conn.CommandNames())
+	dag := conn.Commands()[1]
+	assert.Equal(t, []interface{}{"FLOAT", int64(1), "VALUES", float32(1)}, dag[6:10])
+	assert.Equal(t, []interface{}{"FLOAT", int64(2), int64(2), "VALUES", float32(0), float32(0), float32(0), float32(0)}, dag[13:21])
+
+	// the inputs defaulted to the model ones have no signature, and the model is not stored without their tensors
+	c, conn = createFakeClient(nil)
+	report, err = c.ModelStoreFromModelWithWarmup("mymodel", model, ModelWarmup{})
+	assert.NotNil(t, err)
+	assert.Nil(t, report)
+	assert.Len(t, conn.Commands(), 0)
+}
+
+func TestClient_ModelWarmup_Errors(t *testing.T) {
+	c, _ := createFakeClient(nil)
+	tests := []struct {
+		name   string
+		warmup ModelWarmup
+	}{
+		{"no-outputs", ModelWarmup{Inputs: []TensorSignature{{Name: "a", Dtype: TypeFloat, Shape: []int64{1}}}}},
+		{"no-signature", ModelWarmup{Inputs: []TensorSignature{{Name: "a"}}, Outputs: []string{"b"}}},
+		{"invalid-shape", ModelWarmup{Inputs: []TensorSignature{{Name: "a", Dtype: TypeFloat, Shape: []int64{0}}}, Outputs: []string{"b"}}},
+		{"invalid-dtype", ModelWarmup{Inputs: []TensorSignature{{Name: "a", Dtype: "COMPLEX", Shape: []int64{1}}}, Outputs: []string{"b"}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := c.ModelWarmup("mymodel", tt.warmup)
+			assert.NotNil(t, err)
+		})
+	}
+}